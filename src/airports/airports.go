@@ -18,6 +18,10 @@ import (
 	"../application"
 	"../countries"
 	"../datatypes"
+	"../failures"
+	"../filters"
+	"../paging"
+	"../timezones"
 )
 
 // Airports is the representation of the collection of Airports in the geography database
@@ -25,6 +29,8 @@ type Airports struct {
 	context    *application.Context
 	collection *mongo.Collection
 	countries  *countries.Countries
+	timeZones  *timezones.Zones
+	boundaries *countries.Boundaries
 }
 
 // Airport is the external representation for an ICAO-airport including both a bson (for mongo)
@@ -41,13 +47,50 @@ type Airport struct {
 	CountryCode  string             `bson:"iso-country-code" json:"iso-country-code"`
 	RegionCode   string             `bson:"iso-region-code" json:"iso-region-code,omitempty"`
 	Municipality string             `bson:"municipality" json:"municipality,omitempty"`
+	TimeZone     string             `bson:"time-zone" json:"time-zone,omitempty"`
 	IATA         string             `bson:"iata-airport-code" json:"iata-airport-code,omitempty"`
 	Website      string             `bson:"website" json:"website,omitempty"`
 	Wikipedia    string             `bson:"wikipedia" json:"wikipedia,omitempty"`
+	Scheduled    bool               `bson:"scheduled-service" json:"scheduled-service,omitempty"`
+	Keywords     []string           `bson:"keywords" json:"keywords,omitempty"`
 	Runways      []*Runway          `bson:"runways" json:"runways,omitempty"`
 	Frequencies  []*Frequency       `bson:"frequencies" json:"frequencies,omitempty"`
 }
 
+// Fields are the fields a list of airports can be limited to
+var Fields = []string{"icao-airport-code", "airport-name", "airport-type", "latitude", "longitude", "elevation",
+	"iso-country-code", "iso-region-code", "municipality", "time-zone", "iata-airport-code", "website",
+	"wikipedia", "scheduled-service", "keywords", "runways", "frequencies"}
+
+// SortFields are the fields a list of airports can be sorted on, each is indexed with the code and
+// also after the country and after the region, so the airports of a country or a region are sorted
+// from an index too. The other filters are applied while reading the index.
+var SortFields = []string{"icao-airport-code", "iata-airport-code", "airport-name", "airport-type", "latitude",
+	"longitude", "elevation", "iso-country-code", "iso-region-code", "municipality"}
+
+// FilterFields are the fields a list of airports can be filtered on, see filters.Parse
+var FilterFields = filters.Fields{
+	"icao-airport-code":          filters.String,
+	"iata-airport-code":          filters.String,
+	"airport-name":               filters.String,
+	"airport-type":               filters.String,
+	"latitude":                   filters.Number,
+	"longitude":                  filters.Number,
+	"elevation":                  filters.Number,
+	"iso-country-code":           filters.String,
+	"iso-region-code":            filters.String,
+	"municipality":               filters.String,
+	"time-zone":                  filters.String,
+	"scheduled-service":          filters.Boolean,
+	"keywords":                   filters.String,
+	"runways.length":             filters.Number,
+	"runways.surface":            filters.String,
+	"runways.lighted":            filters.Boolean,
+	"runways.closed":             filters.Boolean,
+	"frequencies.frequency-type": filters.String,
+	"frequencies.frequency-mhz":  filters.Number,
+}
+
 // NewAirports sets up the connection to the database
 func NewAirports(application *application.Context, countries *countries.Countries) *Airports {
 	airports := Airports{
@@ -60,6 +103,24 @@ func NewAirports(application *application.Context, countries *countries.Countrie
 	airports.collection.Indexes().CreateOne(application.DBContext, airportIndex1)
 	airportIndex2 := mongo.IndexModel{Keys: bson.M{"iata-airport-code": 1}}
 	airports.collection.Indexes().CreateOne(application.DBContext, airportIndex2)
+	airportIndex3 := mongo.IndexModel{Keys: bson.D{{Key: "latitude", Value: 1}, {Key: "longitude", Value: 1}}}
+	airports.collection.Indexes().CreateOne(application.DBContext, airportIndex3)
+	for _, field := range SortFields {
+		keys := bson.D{{Key: "icao-airport-code", Value: 1}}
+		if field != "icao-airport-code" {
+			keys = append(bson.D{{Key: field, Value: 1}}, keys...)
+			sortIndex := mongo.IndexModel{Keys: keys}
+			airports.collection.Indexes().CreateOne(application.DBContext, sortIndex)
+		}
+		if field != "iso-country-code" {
+			countryIndex := mongo.IndexModel{Keys: append(bson.D{{Key: "iso-country-code", Value: 1}}, keys...)}
+			airports.collection.Indexes().CreateOne(application.DBContext, countryIndex)
+		}
+		if field != "iso-region-code" {
+			regionIndex := mongo.IndexModel{Keys: append(bson.D{{Key: "iso-region-code", Value: 1}}, keys...)}
+			airports.collection.Indexes().CreateOne(application.DBContext, regionIndex)
+		}
+	}
 
 	return &airports
 }
@@ -70,14 +131,14 @@ func (airports *Airports) GetByAirportCode(airportCode string) (*Airport, error)
 
 	parameter, err := datatypes.ICAOAirportCode(airportCode, false, false)
 	if err != nil {
-		return nil, fmt.Errorf("GetByAirportCode.AirportCode(%s): %v", airportCode, err)
+		return nil, failures.New(failures.InvalidArgument, "GetByAirportCode.AirportCode(%s): %v", airportCode, err)
 	}
 
 	err = airports.collection.FindOne(airports.context.DBContext,
 		bson.D{{Key: "icao-airport-code", Value: parameter}}).Decode(&result)
 
 	if err != nil {
-		return nil, fmt.Errorf("Not Found")
+		return nil, failures.Database(err)
 	}
 
 	return &result, nil
@@ -89,29 +150,29 @@ func (airports *Airports) GetByIATACode(iataCode string) (*Airport, error) {
 
 	parameter, err := datatypes.IATAAirportCode(iataCode, false, false)
 	if err != nil {
-		return nil, fmt.Errorf("GetByIATACode.AirportCode(%s): %v", iataCode, err)
+		return nil, failures.New(failures.InvalidArgument, "GetByIATACode.AirportCode(%s): %v", iataCode, err)
 	}
 
 	err = airports.collection.FindOne(airports.context.DBContext,
 		bson.D{{Key: "iata-airport-code", Value: parameter}}).Decode(&result)
 
 	if err != nil {
-		return nil, fmt.Errorf("Not Found")
+		return nil, failures.Database(err)
 	}
 
 	return &result, nil
 }
 
-// GetList retrieves a list of Airports based on filter arguments
-func (airports *Airports) GetList(countryCode string, regionCode string,
-	fromICAO string, untilICAO string, fromIATA string, untilIATA string) ([]*Airport, error) {
+// listQuery builds the query for a list of Airports from the filter arguments and a filter
+// expression on the FilterFields
+func listQuery(countryCode string, regionCode string,
+	fromICAO string, untilICAO string, fromIATA string, untilIATA string, filter string) (bson.D, error) {
 
-	var result []*Airport
 	var query = bson.D{{}}
 
 	parameter, err := datatypes.ISOCountryCode(countryCode, false, true)
 	if err != nil {
-		return nil, fmt.Errorf("GetList.CountryCode(%s): %v", countryCode, err)
+		return nil, failures.New(failures.InvalidArgument, "GetList.CountryCode(%s): %v", countryCode, err)
 	}
 	if len(parameter) != 0 {
 		query = append(query, bson.E{Key: "iso-country-code", Value: parameter})
@@ -119,7 +180,7 @@ func (airports *Airports) GetList(countryCode string, regionCode string,
 
 	parameter, err = datatypes.ISORegionCode(regionCode, false, true)
 	if err != nil {
-		return nil, fmt.Errorf("GetList.RegionCode(%s): %v", regionCode, err)
+		return nil, failures.New(failures.InvalidArgument, "GetList.RegionCode(%s): %v", regionCode, err)
 	}
 	if len(parameter) != 0 {
 		query = append(query, bson.E{Key: "iso-region-code", Value: parameter})
@@ -127,7 +188,7 @@ func (airports *Airports) GetList(countryCode string, regionCode string,
 
 	parameter, err = datatypes.ICAOAirportCode(fromICAO, true, true)
 	if err != nil {
-		return nil, fmt.Errorf("GetList.FromICAO(%s): %v", fromICAO, err)
+		return nil, failures.New(failures.InvalidArgument, "GetList.FromICAO(%s): %v", fromICAO, err)
 	}
 	if len(parameter) != 0 {
 		query = append(query, bson.E{Key: "icao-airport-code", Value: bson.D{{Key: "$gte", Value: parameter}}})
@@ -135,7 +196,7 @@ func (airports *Airports) GetList(countryCode string, regionCode string,
 
 	parameter, err = datatypes.ICAOAirportCode(untilICAO, true, true)
 	if err != nil {
-		return nil, fmt.Errorf("GetList.UntilICAO(%s): %v", untilICAO, err)
+		return nil, failures.New(failures.InvalidArgument, "GetList.UntilICAO(%s): %v", untilICAO, err)
 	}
 	if len(parameter) != 0 {
 		query = append(query, bson.E{Key: "icao-airport-code", Value: bson.D{{Key: "$lte", Value: parameter}}})
@@ -143,7 +204,7 @@ func (airports *Airports) GetList(countryCode string, regionCode string,
 
 	parameter, err = datatypes.IATAAirportCode(fromIATA, true, true)
 	if err != nil {
-		return nil, fmt.Errorf("GetList.FromIATA(%s): %v", fromIATA, err)
+		return nil, failures.New(failures.InvalidArgument, "GetList.FromIATA(%s): %v", fromIATA, err)
 	}
 	if len(parameter) != 0 {
 		query = append(query, bson.E{Key: "iata-airport-code", Value: bson.D{{Key: "$gte", Value: parameter}}})
@@ -151,18 +212,40 @@ func (airports *Airports) GetList(countryCode string, regionCode string,
 
 	parameter, err = datatypes.IATAAirportCode(untilIATA, true, true)
 	if err != nil {
-		return nil, fmt.Errorf("GetList.UntilIATA(%s): %v", untilIATA, err)
+		return nil, failures.New(failures.InvalidArgument, "GetList.UntilIATA(%s): %v", untilIATA, err)
 	}
 	if len(parameter) != 0 {
 		query = append(query, bson.E{Key: "iata-airport-code", Value: bson.D{{Key: "$lte", Value: parameter}}})
 	}
 
+	expression, err := filters.Parse(filter, FilterFields)
+	if err != nil {
+		return nil, err
+	}
+	if expression != nil {
+		query = append(query, bson.E{Key: "$and", Value: bson.A{expression}})
+	}
+
+	return query, nil
+}
+
+// GetList retrieves a list of Airports based on filter arguments
+func (airports *Airports) GetList(countryCode string, regionCode string,
+	fromICAO string, untilICAO string, fromIATA string, untilIATA string) ([]*Airport, error) {
+
+	var result []*Airport
+
+	query, err := listQuery(countryCode, regionCode, fromICAO, untilICAO, fromIATA, untilIATA, "")
+	if err != nil {
+		return nil, err
+	}
+
 	findOptions := options.Find()
 	findOptions.SetLimit(airports.context.MaxResults + 1)
 
 	cur, err := airports.collection.Find(airports.context.DBContext, query, findOptions)
 	if err != nil {
-		return nil, fmt.Errorf("Not found")
+		return nil, failures.Database(err)
 	}
 
 	for cur.Next(airports.context.DBContext) {
@@ -174,16 +257,49 @@ func (airports *Airports) GetList(countryCode string, regionCode string,
 	cur.Close(airports.context.DBContext)
 
 	if int64(len(result)) > airports.context.MaxResults {
-		return nil, fmt.Errorf("Too many results")
+		return nil, failures.New(failures.TooLarge, "Too many results")
 	}
 
 	if len(result) == 0 {
-		return nil, fmt.Errorf("Not found")
+		return nil, failures.New(failures.NotFound, "Not found")
 	}
 
 	return result, nil
 }
 
+// GetPage retrieves a page of the list GetList retrieves, narrowed down by a filter expression,
+// in order of the ICAO code unless the request sorts it. Only the fields of the request are read
+// from the database. Only the first page of an empty list is Not found, paging past the end
+// gives an empty page.
+func (airports *Airports) GetPage(countryCode string, regionCode string,
+	fromICAO string, untilICAO string, fromIATA string, untilIATA string, filter string,
+	request *paging.Request) ([]*Airport, *paging.Page, error) {
+
+	var result []*Airport
+
+	query, err := listQuery(countryCode, regionCode, fromICAO, untilICAO, fromIATA, untilIATA, filter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	page, err := paging.Find(airports.context.DBContext, airports.collection, query, "icao-airport-code", request,
+		func(document bson.Raw) error {
+			var airport Airport
+			err := bson.Unmarshal(document, &airport)
+			result = append(result, &airport)
+			return err
+		})
+	if err != nil {
+		return nil, nil, failures.Database(err)
+	}
+
+	if len(result) == 0 && request.First() {
+		return nil, nil, failures.New(failures.NotFound, "Not found")
+	}
+
+	return result, page, nil
+}
+
 // RetrieveFromURL downloads the file into the etc directory
 func (airports *Airports) RetrieveFromURL() error {
 	// Get the data
@@ -260,6 +376,9 @@ func (airports *Airports) importCSVLine(lineNumber int, line []string) error {
 		return fmt.Errorf("Airport[%d].Elevation: %v", lineNumber, err)
 	}
 
+	// Cross-check Country and Region with the boundaries
+	airports.checkLocation(lineNumber, airportCode, country.CountryCode, region.RegionCode, latitude, longitude)
+
 	// Define an insert structure without the ID to prevent race-conditions
 	// in the upsert function.
 	type insertAirport struct {
@@ -273,9 +392,12 @@ func (airports *Airports) importCSVLine(lineNumber int, line []string) error {
 		CountryCode  string             `bson:"iso-country-code"`
 		RegionCode   string             `bson:"iso-region-code"`
 		Municipality string             `bson:"municipality"`
+		TimeZone     string             `bson:"time-zone,omitempty"`
 		IATA         string             `bson:"iata-airport-code"`
 		Website      string             `bson:"website"`
 		Wikipedia    string             `bson:"wikipedia"`
+		Scheduled    bool               `bson:"scheduled-service"`
+		Keywords     []string           `bson:"keywords"`
 	}
 	
 	// Build internal representation
@@ -290,9 +412,20 @@ func (airports *Airports) importCSVLine(lineNumber int, line []string) error {
 		CountryCode:  country.CountryCode,
 		RegionCode:   region.RegionCode,
 		Municipality: line[10],
+		TimeZone:     airports.lookupTimeZone(latitude, longitude),
 		IATA:         airportIATA,
 		Website:      line[15],
 		Wikipedia:    line[16],
+		Scheduled:    line[11] == "yes",
+	}
+
+	// The keywords are other names of the airport, separated by commas
+	if len(line) > 17 {
+		for _, keyword := range strings.Split(line[17], ",") {
+			if keyword = strings.TrimSpace(keyword); len(keyword) != 0 {
+				airport.Keywords = append(airport.Keywords, keyword)
+			}
+		}
 	}
 
 	// Dump in mongo
@@ -0,0 +1,380 @@
+package airports
+
+import (
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"../datatypes"
+	"../failures"
+	"../search"
+)
+
+// Autocomplete suggests airports while their code, name or municipality is typed. The index is
+// kept in memory as sorted arrays of the codes and of the folded words of the names and
+// municipalities, the airports starting with what was typed are a range of those arrays. A code
+// ranks above a name, the larger airports come first like in a search. The airports are indexed
+// in tiers of their boost, the tiers of the smaller airports are only looked at when they can
+// still make it into the suggestions, which keeps the first letters typed fast.
+
+// The scores of the matches, before the boost of the airport
+const (
+	exactCodeScore = 3.0
+	codeScore      = 2.0
+	nameScore      = 1.0
+	placeScore     = 0.8
+	firstWordScore = 0.2 // added for a word at the start of the name or municipality
+)
+
+// Suggestion is an airport starting with what was typed, the highlights point out the parts that
+// match
+type Suggestion struct {
+	AirportCode  string      `json:"icao-airport-code"`
+	IATA         string      `json:"iata-airport-code,omitempty"`
+	AirportName  string      `json:"airport-name"`
+	Municipality string      `json:"municipality,omitempty"`
+	CountryCode  string      `json:"iso-country-code"`
+	Highlights   []Highlight `json:"highlights"`
+}
+
+// Highlight is the part of a field that matches, counted in letters from 0 up to the end
+type Highlight struct {
+	Field string `json:"field"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+}
+
+// Completions is the index of the airports for autocomplete
+type Completions struct {
+	airports []*completion
+	tiers    []*completionTier // by boost, the largest first
+}
+
+// completionTier indexes the airports of a boost
+type completionTier struct {
+	boost float64
+	codes []completionKey // sorted on the key
+	words []completionKey
+}
+
+// completion is an airport in the index
+type completion struct {
+	suggestion Suggestion
+	words      []completionKey // the words of its name and municipality
+}
+
+// completionKey is a code or a folded word of an airport
+type completionKey struct {
+	key     string
+	airport int
+	field   string
+	word    search.Word
+}
+
+// completionMatch is how well an airport matches what was typed, with the codes and words it
+// matches
+type completionMatch struct {
+	score float64
+	codes []Highlight
+	words []wordMatch
+}
+
+// wordMatch is a word of an airport starting with a word typed
+type wordMatch struct {
+	key    *completionKey
+	prefix string
+}
+
+// NewCompletions indexes the codes, names and municipalities of airports
+func NewCompletions(list []*Airport) *Completions {
+	var result Completions
+	tiers := map[float64]*completionTier{}
+
+	for i, airport := range list {
+		item := completion{
+			suggestion: Suggestion{
+				AirportCode:  airport.AirportCode,
+				IATA:         airport.IATA,
+				AirportName:  airport.AirportName,
+				Municipality: airport.Municipality,
+				CountryCode:  airport.CountryCode,
+			},
+		}
+
+		boost := searchBoosts[airport.AirportType]
+		if airport.Scheduled {
+			boost += scheduledBoost
+		}
+		tier, ok := tiers[boost]
+		if !ok {
+			tier = &completionTier{boost: boost}
+			tiers[boost] = tier
+			result.tiers = append(result.tiers, tier)
+		}
+
+		tier.codes = append(tier.codes, completionKey{key: airport.AirportCode, airport: i,
+			field: "icao-airport-code"})
+		if len(airport.IATA) != 0 {
+			tier.codes = append(tier.codes, completionKey{key: airport.IATA, airport: i,
+				field: "iata-airport-code"})
+		}
+		for _, field := range []struct{ name, value string }{
+			{"airport-name", airport.AirportName},
+			{"municipality", airport.Municipality},
+		} {
+			for _, word := range search.Words(field.value) {
+				key := completionKey{key: word.Text, airport: i, field: field.name, word: word}
+				item.words = append(item.words, key)
+				tier.words = append(tier.words, key)
+			}
+		}
+
+		result.airports = append(result.airports, &item)
+	}
+
+	sort.Slice(result.tiers, func(i, j int) bool { return result.tiers[i].boost > result.tiers[j].boost })
+	for _, tier := range result.tiers {
+		sort.Slice(tier.codes, func(i, j int) bool { return tier.codes[i].key < tier.codes[j].key })
+		sort.Slice(tier.words, func(i, j int) bool { return tier.words[i].key < tier.words[j].key })
+	}
+
+	return &result
+}
+
+// LoadCompletions reads the airports into the index for autocomplete
+func (airports *Airports) LoadCompletions() (*Completions, error) {
+	var list []*Airport
+
+	findOptions := options.Find().SetProjection(bson.D{
+		{Key: "icao-airport-code", Value: 1},
+		{Key: "iata-airport-code", Value: 1},
+		{Key: "airport-name", Value: 1},
+		{Key: "airport-type", Value: 1},
+		{Key: "iso-country-code", Value: 1},
+		{Key: "municipality", Value: 1},
+		{Key: "scheduled-service", Value: 1},
+	})
+	cur, err := airports.collection.Find(airports.context.DBContext, bson.D{}, findOptions)
+	if err != nil {
+		return nil, failures.Database(err)
+	}
+	defer cur.Close(airports.context.DBContext)
+
+	for cur.Next(airports.context.DBContext) {
+		var airport Airport
+		err = cur.Decode(&airport)
+		if err != nil {
+			return nil, failures.Database(err)
+		}
+		list = append(list, &airport)
+	}
+
+	if cur.Err() != nil {
+		return nil, failures.Database(cur.Err())
+	}
+	return NewCompletions(list), nil
+}
+
+// codePrefix is what was typed as the start of a code
+type codePrefix struct {
+	field  string
+	prefix string
+}
+
+// Complete suggests the airports starting with what was typed, best first. What looks like the
+// start of an ICAO or IATA code is looked up as a code, every word typed has to start a word of
+// the name or municipality.
+func (completions *Completions) Complete(query string, limit int) []*Suggestion {
+	// The validators clean up a partial code, or tell it can not be one
+	var codes []codePrefix
+	for _, code := range []struct {
+		field string
+		check func(string, bool, bool) (string, error)
+	}{
+		{"icao-airport-code", datatypes.ICAOAirportCode},
+		{"iata-airport-code", datatypes.IATAAirportCode},
+	} {
+		if prefix, err := code.check(query, true, false); err == nil {
+			codes = append(codes, codePrefix{field: code.field, prefix: prefix})
+		}
+	}
+
+	words := search.Terms(query)
+
+	matches := map[int]*completionMatch{}
+	var ranked []int
+	for _, tier := range completions.tiers {
+		if len(ranked) >= limit && matches[ranked[limit-1]].score >= tier.bound(codes) {
+			// The airports of this tier can not do better
+			continue
+		}
+
+		found := map[int]*completionMatch{}
+		completions.matchCodes(tier, codes, found)
+		completions.matchWords(tier, words, found)
+		for airport, match := range found {
+			match.score *= 1 + tier.boost
+			matches[airport] = match
+			ranked = append(ranked, airport)
+		}
+
+		sort.Slice(ranked, func(i, j int) bool {
+			a, b := matches[ranked[i]], matches[ranked[j]]
+			if a.score != b.score {
+				return a.score > b.score
+			}
+			return completions.airports[ranked[i]].suggestion.AirportCode <
+				completions.airports[ranked[j]].suggestion.AirportCode
+		})
+		if len(ranked) > limit {
+			ranked = ranked[:limit]
+		}
+	}
+
+	result := []*Suggestion{}
+	for _, airport := range ranked {
+		result = append(result, completions.airports[airport].suggest(matches[airport]))
+	}
+	return result
+}
+
+// bound is the best score an airport of a tier can get for what was typed
+func (tier *completionTier) bound(codes []codePrefix) float64 {
+	result := nameScore + firstWordScore
+	for _, code := range codes {
+		keys := prefixed(tier.codes, code.prefix)
+		if len(keys) != 0 && keys[0].key == code.prefix {
+			result = exactCodeScore
+		} else if len(keys) != 0 && result < codeScore {
+			result = codeScore
+		}
+	}
+	return result * (1 + tier.boost)
+}
+
+// matchCodes finds the airports of a tier with a code starting with what was typed
+func (completions *Completions) matchCodes(tier *completionTier, codes []codePrefix,
+	found map[int]*completionMatch) {
+
+	for _, code := range codes {
+		for _, key := range prefixed(tier.codes, code.prefix) {
+			if key.field != code.field {
+				continue
+			}
+			score := codeScore
+			if key.key == code.prefix {
+				score = exactCodeScore
+			}
+			match, ok := found[key.airport]
+			if !ok {
+				match = &completionMatch{}
+				found[key.airport] = match
+			}
+			if score > match.score {
+				match.score = score
+			}
+			match.codes = append(match.codes, Highlight{Field: key.field, Start: 0, End: len(code.prefix)})
+		}
+	}
+}
+
+// matchWords finds the airports of a tier with a name or municipality starting with every word
+// typed
+func (completions *Completions) matchWords(tier *completionTier, words []string,
+	found map[int]*completionMatch) {
+
+	if len(words) == 0 {
+		return
+	}
+
+	// The airports with the longest word are the fewest to check
+	longest := words[0]
+	for _, word := range words[1:] {
+		if len(word) > len(longest) {
+			longest = word
+		}
+	}
+
+	checked := map[int]bool{}
+	for _, key := range prefixed(tier.words, longest) {
+		if checked[key.airport] {
+			continue
+		}
+		checked[key.airport] = true
+
+		score, matched := completions.airports[key.airport].matchWords(words)
+		if matched == nil {
+			continue
+		}
+		match, ok := found[key.airport]
+		if !ok {
+			match = &completionMatch{}
+			found[key.airport] = match
+		}
+		if score > match.score {
+			match.score = score
+		}
+		match.words = matched
+	}
+}
+
+// matchWords matches every word typed with the start of a word of the airport, nil when one of
+// them does not match
+func (item *completion) matchWords(words []string) (float64, []wordMatch) {
+	var result []wordMatch
+	total := 0.0
+
+	for _, word := range words {
+		var best *completionKey
+		bestScore := 0.0
+		for i := range item.words {
+			key := &item.words[i]
+			if !strings.HasPrefix(key.key, word) {
+				continue
+			}
+			score := nameScore
+			if key.field == "municipality" {
+				score = placeScore
+			}
+			if key.word.Start == 0 {
+				score += firstWordScore
+			}
+			if score > bestScore {
+				best, bestScore = key, score
+			}
+		}
+		if best == nil {
+			return 0, nil
+		}
+		result = append(result, wordMatch{key: best, prefix: word})
+		total += bestScore
+	}
+
+	return total / float64(len(words)), result
+}
+
+// suggest makes the suggestion of an airport with the highlights of what matched
+func (item *completion) suggest(match *completionMatch) *Suggestion {
+	result := item.suggestion
+	result.Highlights = append([]Highlight{}, match.codes...)
+	for _, word := range match.words {
+		value := item.suggestion.AirportName
+		if word.key.field == "municipality" {
+			value = item.suggestion.Municipality
+		}
+		start, end := search.Prefix(value, word.key.word, word.prefix)
+		result.Highlights = append(result.Highlights, Highlight{Field: word.key.field, Start: start, End: end})
+	}
+	return &result
+}
+
+// prefixed is the range of sorted keys that start with a prefix
+func prefixed(keys []completionKey, prefix string) []completionKey {
+	start := sort.Search(len(keys), func(i int) bool { return keys[i].key >= prefix })
+	end := start + sort.Search(len(keys)-start, func(i int) bool {
+		return !strings.HasPrefix(keys[start+i].key, prefix)
+	})
+	return keys[start:end]
+}
@@ -0,0 +1,70 @@
+package airports
+
+import (
+	"reflect"
+	"testing"
+)
+
+var testCompletions = NewCompletions([]*Airport{
+	{AirportCode: "EHAM", IATA: "AMS", AirportName: "Amsterdam Airport Schiphol", AirportType: "large_airport",
+		Municipality: "Amsterdam", CountryCode: "NL", Scheduled: true},
+	{AirportCode: "EHLE", IATA: "LEY", AirportName: "Lelystad Airport", AirportType: "medium_airport",
+		Municipality: "Lelystad", CountryCode: "NL"},
+	{AirportCode: "EHHE", AirportName: "Schiphol Heliport", AirportType: "heliport", Municipality: "Amsterdam",
+		CountryCode: "NL"},
+	{AirportCode: "SBGR", IATA: "GRU", AirportName: "São Paulo/Guarulhos International Airport",
+		AirportType: "large_airport", Municipality: "São Paulo", CountryCode: "BR", Scheduled: true},
+	{AirportCode: "LSZH", IATA: "ZRH", AirportName: "Zürich Airport", AirportType: "large_airport",
+		Municipality: "Zurich", CountryCode: "CH", Scheduled: true},
+})
+
+func TestComplete(t *testing.T) {
+	var tests = []struct {
+		query string
+		codes []string
+	}{
+		{"EH", []string{"EHAM", "EHLE", "EHHE"}},
+		{"eha", []string{"EHAM"}},
+		{"ams", []string{"EHAM", "EHHE"}},
+		{"schip", []string{"EHAM", "EHHE"}},
+		{"schiphol heli", []string{"EHHE"}},
+		{"sao pa", []string{"SBGR"}},
+		{"zur", []string{"LSZH"}},
+		{"guarulhos", []string{"SBGR"}},
+		{"xyz", []string{}},
+		{"", []string{}},
+	}
+
+	for _, test := range tests {
+		codes := []string{}
+		for _, suggestion := range testCompletions.Complete(test.query, 10) {
+			codes = append(codes, suggestion.AirportCode)
+		}
+		if !reflect.DeepEqual(codes, test.codes) {
+			t.Errorf("Complete(%s) expected %v, got %v", test.query, test.codes, codes)
+		}
+	}
+
+	if suggestions := testCompletions.Complete("a", 1); len(suggestions) != 1 {
+		t.Errorf("Complete expected the suggestions up to the limit, got %d", len(suggestions))
+	}
+}
+
+func TestCompleteHighlights(t *testing.T) {
+	var tests = []struct {
+		query      string
+		highlights []Highlight
+	}{
+		{"eha", []Highlight{{"icao-airport-code", 0, 3}}},
+		{"schip", []Highlight{{"airport-name", 18, 23}}},
+		{"sao gua", []Highlight{{"airport-name", 0, 3}, {"airport-name", 10, 13}}},
+		{"zu", []Highlight{{"airport-name", 0, 2}}},
+	}
+
+	for _, test := range tests {
+		suggestions := testCompletions.Complete(test.query, 1)
+		if len(suggestions) != 1 || !reflect.DeepEqual(suggestions[0].Highlights, test.highlights) {
+			t.Errorf("Complete(%s) expected highlights %v, got %+v", test.query, test.highlights, suggestions)
+		}
+	}
+}
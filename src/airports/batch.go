@@ -0,0 +1,155 @@
+package airports
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+
+	"../datatypes"
+	"../failures"
+)
+
+// A batch looks up many airports by a mixed list of ICAO and IATA codes with one query per kind
+// of code. A code of three letters can be either, it is looked up as both and an airport with the
+// IATA code goes first. The items of the result are in the order of the codes, each tells whether
+// its airport was found.
+
+// The statuses of the items of a batch
+const (
+	BatchFound    = "found"
+	BatchNotFound = "not-found"
+	BatchInvalid  = "invalid"
+)
+
+// DefaultMaxBatchSize is the number of codes of a batch when the options do not tell
+const DefaultMaxBatchSize = 1000
+
+// BatchItem is the airport of a code of a batch
+type BatchItem struct {
+	Code    string   `json:"code"`
+	Status  string   `json:"status"`
+	Error   string   `json:"error,omitempty"`
+	Airport *Airport `json:"airport,omitempty"`
+}
+
+// batchCodes are the codes of a batch sorted out by kind
+type batchCodes struct {
+	icao []string
+	iata []string
+}
+
+// GetBatch retrieves the airports of a list of ICAO and IATA codes
+func (airports *Airports) GetBatch(codes []string) ([]*BatchItem, error) {
+	maxSize := airports.context.MaxBatchSize
+	if maxSize <= 0 {
+		maxSize = DefaultMaxBatchSize
+	}
+	if len(codes) > maxSize {
+		return nil, failures.New(failures.InvalidArgument, "GetBatch.Codes(%d): use %d codes at most",
+			len(codes), maxSize)
+	}
+
+	items, kinds := newBatch(codes)
+	var found []*Airport
+	for _, kind := range []struct {
+		field string
+		codes []string
+	}{
+		{"icao-airport-code", kinds.icao},
+		{"iata-airport-code", kinds.iata},
+	} {
+		if len(kind.codes) == 0 {
+			continue
+		}
+		list, err := airports.find(bson.D{{Key: kind.field, Value: bson.D{{Key: "$in", Value: kind.codes}}}})
+		if err != nil {
+			return nil, err
+		}
+		found = append(found, list...)
+	}
+
+	resolveBatch(items, found)
+	return items, nil
+}
+
+// find retrieves the airports of a query
+func (airports *Airports) find(query bson.D) ([]*Airport, error) {
+	var result []*Airport
+
+	cur, err := airports.collection.Find(airports.context.DBContext, query)
+	if err != nil {
+		return nil, failures.Database(err)
+	}
+	defer cur.Close(airports.context.DBContext)
+
+	for cur.Next(airports.context.DBContext) {
+		var airport Airport
+		err = cur.Decode(&airport)
+		if err != nil {
+			return nil, failures.Database(err)
+		}
+		result = append(result, &airport)
+	}
+
+	if cur.Err() != nil {
+		return nil, failures.Database(cur.Err())
+	}
+	return result, nil
+}
+
+// newBatch makes the items of the codes, an invalid code is done right away, and sorts out the
+// codes to look up by kind
+func newBatch(codes []string) ([]*BatchItem, batchCodes) {
+	var items []*BatchItem
+	var kinds batchCodes
+	seen := map[string]bool{}
+
+	for _, code := range codes {
+		item := BatchItem{Code: code}
+		items = append(items, &item)
+
+		icao, icaoErr := datatypes.ICAOAirportCode(code, false, false)
+		iata, iataErr := datatypes.IATAAirportCode(code, false, false)
+		if icaoErr != nil && iataErr != nil {
+			item.Status = BatchInvalid
+			item.Error = icaoErr.Error()
+			continue
+		}
+		if icaoErr == nil && !seen["icao:"+icao] {
+			seen["icao:"+icao] = true
+			kinds.icao = append(kinds.icao, icao)
+		}
+		if iataErr == nil && !seen["iata:"+iata] {
+			seen["iata:"+iata] = true
+			kinds.iata = append(kinds.iata, iata)
+		}
+	}
+	return items, kinds
+}
+
+// resolveBatch gives the items that are not invalid their airport, or tells it was not found
+func resolveBatch(items []*BatchItem, found []*Airport) {
+	byICAO := map[string]*Airport{}
+	byIATA := map[string]*Airport{}
+	for _, airport := range found {
+		if _, ok := byICAO[airport.AirportCode]; !ok {
+			byICAO[airport.AirportCode] = airport
+		}
+		if _, ok := byIATA[airport.IATA]; !ok && len(airport.IATA) != 0 {
+			byIATA[airport.IATA] = airport
+		}
+	}
+
+	for _, item := range items {
+		if item.Status == BatchInvalid {
+			continue
+		}
+		if iata, err := datatypes.IATAAirportCode(item.Code, false, false); err == nil && byIATA[iata] != nil {
+			item.Status, item.Airport = BatchFound, byIATA[iata]
+			continue
+		}
+		if icao, err := datatypes.ICAOAirportCode(item.Code, false, false); err == nil && byICAO[icao] != nil {
+			item.Status, item.Airport = BatchFound, byICAO[icao]
+			continue
+		}
+		item.Status = BatchNotFound
+	}
+}
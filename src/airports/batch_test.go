@@ -0,0 +1,51 @@
+package airports
+
+import (
+	"reflect"
+	"testing"
+
+	"../application"
+)
+
+func TestBatch(t *testing.T) {
+	items, kinds := newBatch([]string{"eham", "AMS", "LHR", "EGLL", "E-LL", "XXXX", "ams", "A"})
+
+	if expected := []string{"EHAM", "AMS", "LHR", "EGLL", "XXXX"}; !reflect.DeepEqual(kinds.icao, expected) {
+		t.Errorf("newBatch expected ICAO codes %v, got %v", expected, kinds.icao)
+	}
+	if expected := []string{"AMS", "LHR"}; !reflect.DeepEqual(kinds.iata, expected) {
+		t.Errorf("newBatch expected IATA codes %v, got %v", expected, kinds.iata)
+	}
+
+	schiphol := &Airport{AirportCode: "EHAM", IATA: "AMS"}
+	heathrow := &Airport{AirportCode: "EGLL", IATA: "LHR"}
+	resolveBatch(items, []*Airport{schiphol, heathrow})
+
+	var tests = []struct {
+		status  string
+		airport *Airport
+	}{
+		{BatchFound, schiphol},
+		{BatchFound, schiphol},
+		{BatchFound, heathrow},
+		{BatchFound, heathrow},
+		{BatchInvalid, nil},
+		{BatchNotFound, nil},
+		{BatchFound, schiphol},
+		{BatchInvalid, nil},
+	}
+
+	for i, test := range tests {
+		if items[i].Status != test.status || items[i].Airport != test.airport {
+			t.Errorf("Batch(%s) expected %s %v, got %s %v", items[i].Code, test.status, test.airport,
+				items[i].Status, items[i].Airport)
+		}
+	}
+}
+
+func TestBatchSize(t *testing.T) {
+	airports := Airports{context: &application.Context{MaxBatchSize: 2}}
+	if _, err := airports.GetBatch([]string{"EHAM", "EGLL", "KJFK"}); err == nil {
+		t.Errorf("GetBatch expected an error for too many codes")
+	}
+}
@@ -0,0 +1,32 @@
+package airports
+
+import (
+	"time"
+
+	"../solar"
+)
+
+// Daylight calculates sunrise, sunset and twilight at the airport, also in local time when the
+// time zone of the airport is known
+func (airport *Airport) Daylight(date time.Time) *solar.Daylight {
+	daylight := solar.Calculate(airport.Latitude, airport.Longitude, airport.Elevation, date)
+
+	location, err := airport.Location()
+	if err == nil {
+		daylight.InLocation(airport.TimeZone, location)
+	}
+
+	return daylight
+}
+
+// Today is the current date at the airport, in UTC when its time zone is unknown
+func (airport *Airport) Today() time.Time {
+	now := time.Now().UTC()
+
+	location, err := airport.Location()
+	if err == nil {
+		now = now.In(location)
+	}
+
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,266 @@
+package airports
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"strings"
+
+	"../failures"
+)
+
+// DiagramOptions are the choices for drawing a runway diagram
+type DiagramOptions struct {
+	Size  int    // width and height in pixels
+	Units string // of the scale bar, feet or meters
+	Dark  bool
+}
+
+// DefaultDiagramSize is used when no size is given
+const DefaultDiagramSize = 800
+
+const (
+	minDiagramSize   = 100
+	maxDiagramSize   = 4000
+	metersPerFoot    = 0.3048
+	metersPerDegree  = 60 * 1852.0
+	minDiagramExtent = 300.0 // meters, so a single helipad does not fill the drawing
+	defaultWidth     = 100   // feet, for runways without a width
+)
+
+// diagramTheme holds the colors of a diagram
+type diagramTheme struct {
+	background string
+	runway     string
+	text       string
+}
+
+var lightTheme = diagramTheme{background: "#ffffff", runway: "#404040", text: "#000000"}
+var darkTheme = diagramTheme{background: "#1e1e1e", runway: "#c8c8c8", text: "#ffffff"}
+
+// diagramRunway is a runway in meters east and north of the airport reference point
+type diagramRunway struct {
+	low      [2]float64
+	high     [2]float64
+	width    float64
+	lowCode  string
+	highCode string
+	closed   bool
+}
+
+// Diagram draws the runways of the airport to scale as SVG, with a north arrow and a scale
+// bar. Runways without coordinates are drawn through the airport reference point using their
+// heading and length.
+func (airport *Airport) Diagram(options DiagramOptions) ([]byte, error) {
+	if options.Size == 0 {
+		options.Size = DefaultDiagramSize
+	}
+	if options.Size < minDiagramSize || options.Size > maxDiagramSize {
+		return nil, failures.New(failures.InvalidArgument, "Diagram(%d): Invalid size", options.Size)
+	}
+
+	unitLength := 1.0
+	unitName := "m"
+	switch options.Units {
+	case "", "feet":
+		unitLength = metersPerFoot
+		unitName = "ft"
+	case "meters":
+	default:
+		return nil, failures.New(failures.InvalidArgument, "Diagram(%s): Invalid units", options.Units)
+	}
+
+	theme := lightTheme
+	if options.Dark {
+		theme = darkTheme
+	}
+
+	var runways []diagramRunway
+	for _, runway := range airport.Runways {
+		drawn, ok := runway.diagramRunway(airport.Latitude, airport.Longitude)
+		if ok {
+			runways = append(runways, drawn)
+		}
+	}
+	if len(runways) == 0 {
+		return nil, failures.New(failures.NotFound, "Diagram(%s): No runways to draw", airport.AirportCode)
+	}
+
+	// Fit the runways in the drawing, leaving a margin for the labels, arrow and scale bar
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, runway := range runways {
+		for _, end := range [][2]float64{runway.low, runway.high} {
+			minX, maxX = math.Min(minX, end[0]), math.Max(maxX, end[0])
+			minY, maxY = math.Min(minY, end[1]), math.Max(maxY, end[1])
+		}
+	}
+	extent := math.Max(minDiagramExtent, math.Max(maxX-minX, maxY-minY))
+
+	size := float64(options.Size)
+	margin := size * 0.12
+	scale := (size - 2*margin) / extent
+	centerX, centerY := (minX+maxX)/2, (minY+maxY)/2
+	project := func(point [2]float64) (float64, float64) {
+		return size/2 + (point[0]-centerX)*scale, size/2 - (point[1]-centerY)*scale
+	}
+
+	fontSize := math.Max(8, size/50)
+
+	var svg bytes.Buffer
+	fmt.Fprintf(&svg, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n",
+		options.Size, options.Size, options.Size, options.Size)
+	fmt.Fprintf(&svg, "<title>%s</title>\n",
+		escapeXML(strings.TrimSpace(airport.AirportCode+" "+airport.AirportName)))
+	fmt.Fprintf(&svg, `<defs><pattern id="closed" width="8" height="8" patternUnits="userSpaceOnUse" `+
+		`patternTransform="rotate(45)"><line x1="0" y1="0" x2="0" y2="8" stroke="%s" stroke-width="3"/>`+
+		"</pattern></defs>\n", theme.runway)
+	fmt.Fprintf(&svg, `<rect width="100%%" height="100%%" fill="%s"/>`+"\n", theme.background)
+	fmt.Fprintf(&svg, `<g font-family="sans-serif" font-size="%.1f" fill="%s">`+"\n", fontSize, theme.text)
+
+	for _, runway := range runways {
+		x1, y1 := project(runway.low)
+		x2, y2 := project(runway.high)
+
+		// Direction along the runway and perpendicular to it, in pixels
+		length := math.Hypot(x2-x1, y2-y1)
+		dx, dy := 0.0, -1.0
+		if length > 0 {
+			dx, dy = (x2-x1)/length, (y2-y1)/length
+		}
+		nx, ny := -dy, dx
+		halfWidth := math.Max(1, runway.width*scale/2)
+
+		fill := theme.runway
+		if runway.closed {
+			fill = "url(#closed)"
+		}
+		fmt.Fprintf(&svg, `<polygon points="%.1f,%.1f %.1f,%.1f %.1f,%.1f %.1f,%.1f" fill="%s" stroke="%s"/>`+"\n",
+			x1+nx*halfWidth, y1+ny*halfWidth, x2+nx*halfWidth, y2+ny*halfWidth,
+			x2-nx*halfWidth, y2-ny*halfWidth, x1-nx*halfWidth, y1-ny*halfWidth, fill, theme.runway)
+
+		// The designators just beyond each end
+		offset := halfWidth + fontSize
+		if len(runway.lowCode) > 0 {
+			writeLabel(&svg, x1-dx*offset, y1-dy*offset, runway.lowCode)
+		}
+		if len(runway.highCode) > 0 {
+			writeLabel(&svg, x2+dx*offset, y2+dy*offset, runway.highCode)
+		}
+	}
+
+	// North is always up, the arrow is in the top right corner with the N below it
+	arrowX, arrowY := size-margin/2, margin/2-fontSize/2
+	arrow := margin / 4
+	fmt.Fprintf(&svg, `<polygon points="%.1f,%.1f %.1f,%.1f %.1f,%.1f %.1f,%.1f"/>`+"\n",
+		arrowX, arrowY-arrow, arrowX+arrow/2, arrowY+arrow, arrowX, arrowY+arrow/2, arrowX-arrow/2, arrowY+arrow)
+	writeLabel(&svg, arrowX, arrowY+arrow+fontSize, "N")
+
+	// The scale bar in the bottom left corner covers a round distance
+	distance := scaleDistance(extent / unitLength / 4)
+	barLength := distance * unitLength * scale
+	barX, barY := margin/2, size-margin/2
+	fmt.Fprintf(&svg, `<path d="M%.1f,%.1f v-%.1f h%.1f v%.1f" fill="none" stroke="%s" stroke-width="2"/>`+"\n",
+		barX, barY-fontSize/2, fontSize/2, barLength, fontSize/2, theme.text)
+	fmt.Fprintf(&svg, `<text x="%.1f" y="%.1f">%g %s</text>`+"\n", barX, barY+fontSize, distance, unitName)
+
+	svg.WriteString("</g>\n</svg>\n")
+
+	return svg.Bytes(), nil
+}
+
+// diagramRunway positions a runway relative to the airport reference point, false when there
+// is neither a position nor a heading and length to draw it with
+func (runway *Runway) diagramRunway(latitude float64, longitude float64) (diagramRunway, bool) {
+	result := diagramRunway{
+		width:  float64(runway.Width) * metersPerFoot,
+		closed: runway.Closed}
+	if runway.Width == 0 {
+		result.width = defaultWidth * metersPerFoot
+	}
+	if runway.LowEnd != nil {
+		result.lowCode = runway.LowEnd.RunwayCode
+	}
+	if runway.HighEnd != nil {
+		result.highCode = runway.HighEnd.RunwayCode
+	}
+
+	line := runway.line()
+	if line != nil {
+		// A flat projection is accurate enough over the size of an airport
+		cosine := math.Cos(latitude * math.Pi / 180.0)
+		for i, position := range line {
+			east := position.Longitude - longitude
+			if east > 180 {
+				east -= 360
+			} else if east < -180 {
+				east += 360
+			}
+			point := [2]float64{east * cosine * metersPerDegree, (position.Latitude - latitude) * metersPerDegree}
+			if i == 0 {
+				result.low = point
+			} else {
+				result.high = point
+			}
+		}
+		return result, true
+	}
+
+	heading := runway.heading()
+	if heading < 0 || runway.Length == 0 {
+		return result, false
+	}
+
+	half := float64(runway.Length) * metersPerFoot / 2
+	angle := heading * math.Pi / 180.0
+	result.low = [2]float64{-half * math.Sin(angle), -half * math.Cos(angle)}
+	result.high = [2]float64{half * math.Sin(angle), half * math.Cos(angle)}
+	return result, true
+}
+
+// heading is the direction from the low end to the high end, -1 when it is not known
+func (runway *Runway) heading() float64 {
+	if runway.LowEnd != nil {
+		if runway.LowEnd.Heading != 0 {
+			return float64(runway.LowEnd.Heading)
+		}
+		if heading := DesignatorHeading(runway.LowEnd.RunwayCode); heading != 0 {
+			return float64(heading)
+		}
+	}
+	if runway.HighEnd != nil {
+		if runway.HighEnd.Heading != 0 {
+			return math.Mod(float64(runway.HighEnd.Heading)+180, 360)
+		}
+		if heading := DesignatorHeading(runway.HighEnd.RunwayCode); heading != 0 {
+			return math.Mod(float64(heading)+180, 360)
+		}
+	}
+	return -1
+}
+
+// scaleDistance rounds a distance down to 1, 2 or 5 times a power of ten
+func scaleDistance(distance float64) float64 {
+	if distance < 1 {
+		return 1
+	}
+	power := math.Pow(10, math.Floor(math.Log10(distance)))
+	for _, step := range []float64{5, 2} {
+		if distance >= step*power {
+			return step * power
+		}
+	}
+	return power
+}
+
+func writeLabel(svg *bytes.Buffer, x float64, y float64, label string) {
+	fmt.Fprintf(svg, `<text x="%.1f" y="%.1f" text-anchor="middle" dominant-baseline="central">%s</text>`+"\n",
+		x, y, escapeXML(label))
+}
+
+func escapeXML(text string) string {
+	var result bytes.Buffer
+	xml.EscapeText(&result, []byte(text))
+	return result.String()
+}
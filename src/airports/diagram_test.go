@@ -0,0 +1,103 @@
+package airports
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+func TestDiagram(t *testing.T) {
+	airport := Airport{
+		AirportCode: "EHXX",
+		AirportName: "Polder & Dunes",
+		Latitude:    52.3,
+		Longitude:   4.75,
+		Runways: []*Runway{
+			{Length: 10000, Width: 150,
+				LowEnd:  &RunwaySide{RunwayCode: "09", Latitude: 52.3, Longitude: 4.7},
+				HighEnd: &RunwaySide{RunwayCode: "27", Latitude: 52.3, Longitude: 4.8}},
+			{Length: 3000, Width: 75, Closed: true, // without coordinates
+				LowEnd:  &RunwaySide{RunwayCode: "18"},
+				HighEnd: &RunwaySide{RunwayCode: "36"}},
+			{LowEnd: &RunwaySide{RunwayCode: "H1"}}, // nothing to draw
+		}}
+
+	result, err := airport.Diagram(DiagramOptions{Units: "meters", Dark: true})
+	if err != nil {
+		t.Fatalf("Diagram expected a drawing, got %v", err)
+	}
+	for _, expected := range []string{`width="800"`, ">09<", ">27<", ">18<", ">36<", "url(#closed)",
+		">N<", " m<", "Polder &amp; Dunes", darkTheme.background} {
+		if !bytes.Contains(result, []byte(expected)) {
+			t.Errorf("Diagram expected %s in the drawing", expected)
+		}
+	}
+	if bytes.Contains(result, []byte(">H1<")) {
+		t.Errorf("Diagram expected no helipad in the drawing")
+	}
+
+	var tests = []struct {
+		options DiagramOptions
+		correct bool
+	}{
+		{DiagramOptions{}, true},
+		{DiagramOptions{Size: 200, Units: "feet"}, true},
+		{DiagramOptions{Size: 50}, false},
+		{DiagramOptions{Size: 5000}, false},
+		{DiagramOptions{Units: "furlongs"}, false},
+	}
+
+	for _, test := range tests {
+		_, err := airport.Diagram(test.options)
+		if (test.correct && err != nil) || (!test.correct && err == nil) {
+			t.Errorf("Diagram(%+v) expected %t, got %v", test.options, test.correct, err)
+		}
+	}
+
+	if _, err := (&Airport{AirportCode: "EHXX"}).Diagram(DiagramOptions{}); err == nil {
+		t.Errorf("Diagram expected an error without runways")
+	}
+}
+
+func TestDiagramRunway(t *testing.T) {
+	// Runway 09 at the reference point runs 3000 ft to the east
+	runway := Runway{Length: 3000, LowEnd: &RunwaySide{RunwayCode: "09"}}
+	drawn, ok := runway.diagramRunway(52.3, 4.75)
+	if !ok {
+		t.Fatalf("diagramRunway expected a runway")
+	}
+	if math.Abs(drawn.high[0]-457.2) > 0.1 || math.Abs(drawn.high[1]) > 0.1 || drawn.low[0] > 0 {
+		t.Errorf("diagramRunway expected 457.2 m east, got %v", drawn.high)
+	}
+
+	var tests = []struct {
+		runway  Runway
+		heading float64
+	}{
+		{Runway{LowEnd: &RunwaySide{RunwayCode: "09L", Heading: 92}}, 92},
+		{Runway{LowEnd: &RunwaySide{RunwayCode: "09L"}}, 90},
+		{Runway{LowEnd: &RunwaySide{RunwayCode: "N"}, HighEnd: &RunwaySide{RunwayCode: "S", Heading: 180}}, 0},
+		{Runway{LowEnd: &RunwaySide{RunwayCode: "H1"}}, -1},
+	}
+
+	for _, test := range tests {
+		if heading := test.runway.heading(); heading != test.heading {
+			t.Errorf("heading(%s) expected %f, got %f", test.runway.designator(), test.heading, heading)
+		}
+	}
+}
+
+func TestScaleDistance(t *testing.T) {
+	var tests = []struct {
+		distance float64
+		result   float64
+	}{
+		{0.5, 1}, {1, 1}, {3, 2}, {7, 5}, {999, 500}, {1000, 1000}, {2500, 2000},
+	}
+
+	for _, test := range tests {
+		if result := scaleDistance(test.distance); result != test.result {
+			t.Errorf("scaleDistance(%f) expected %f, got %f", test.distance, test.result, result)
+		}
+	}
+}
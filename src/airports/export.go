@@ -0,0 +1,32 @@
+package airports
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+
+	"../datatypes"
+	"../failures"
+	"../paging"
+)
+
+// exportBatchSize is the number of airports read from the database at a time, which bounds the
+// memory an export takes
+const exportBatchSize = 200
+
+// Export reads every airport from an ICAO code on, in order of the code, without the limit of a
+// list. The airports are handed to each one by one, an error of each ends the export.
+func (airports *Airports) Export(fromICAO string, each func(*Airport) error) error {
+	parameter, err := datatypes.ICAOAirportCode(fromICAO, true, true)
+	if err != nil {
+		return failures.New(failures.InvalidArgument, "Export.AirportCode(%s): %v", fromICAO, err)
+	}
+
+	return paging.Export(airports.context.DBContext, airports.collection, "icao-airport-code", parameter, exportBatchSize,
+		func(document bson.Raw) error {
+			var airport Airport
+			err := bson.Unmarshal(document, &airport)
+			if err != nil {
+				return failures.Database(err)
+			}
+			return each(&airport)
+		})
+}
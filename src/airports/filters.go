@@ -0,0 +1,55 @@
+package airports
+
+// RunwayFilter selects runway directions, a field that is not set does not filter
+type RunwayFilter struct {
+	FromRunwayCode  string
+	UntilRunwayCode string
+	FromHeading     *int
+	UntilHeading    *int
+	FromLength      *int
+	UntilLength     *int
+	Closed          *bool
+}
+
+// Matches checks one direction of a runway, given by its runway code and heading
+func (filter *RunwayFilter) Matches(runway *Runway, runwayCode string, heading int) bool {
+	if len(filter.FromRunwayCode) != 0 && runwayCode < filter.FromRunwayCode {
+		return false
+	}
+	if len(filter.UntilRunwayCode) != 0 && runwayCode > filter.UntilRunwayCode {
+		return false
+	}
+	if filter.FromHeading != nil && heading < *filter.FromHeading {
+		return false
+	}
+	if filter.UntilHeading != nil && heading > *filter.UntilHeading {
+		return false
+	}
+	if filter.FromLength != nil && runway.Length < *filter.FromLength {
+		return false
+	}
+	if filter.UntilLength != nil && runway.Length > *filter.UntilLength {
+		return false
+	}
+	if filter.Closed != nil && runway.Closed != *filter.Closed {
+		return false
+	}
+	return true
+}
+
+// FrequencyFilter selects frequencies by a range of frequency types, an empty bound does not filter
+type FrequencyFilter struct {
+	FromFrequencyType  string
+	UntilFrequencyType string
+}
+
+// Matches checks a frequency
+func (filter *FrequencyFilter) Matches(frequency *Frequency) bool {
+	if len(filter.FromFrequencyType) != 0 && frequency.FrequencyType < filter.FromFrequencyType {
+		return false
+	}
+	if len(filter.UntilFrequencyType) != 0 && frequency.FrequencyType > filter.UntilFrequencyType {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,69 @@
+package airports
+
+import (
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestRunwayFilter(t *testing.T) {
+	runway := Runway{Length: 10000, Closed: false}
+	heading, length, closed := 100, 8000, true
+
+	var tests = []struct {
+		filter  RunwayFilter
+		matches bool
+	}{
+		{RunwayFilter{}, true},
+		{RunwayFilter{FromRunwayCode: "09", UntilRunwayCode: "18"}, true},
+		{RunwayFilter{FromRunwayCode: "18"}, false},
+		{RunwayFilter{FromHeading: &heading}, false},
+		{RunwayFilter{UntilHeading: &heading}, true},
+		{RunwayFilter{FromLength: &length}, true},
+		{RunwayFilter{UntilLength: &length}, false},
+		{RunwayFilter{Closed: &closed}, false},
+	}
+
+	for _, test := range tests {
+		if matches := test.filter.Matches(&runway, "09", 92); matches != test.matches {
+			t.Errorf("Matches(%+v) expected %t, got %t", test.filter, test.matches, matches)
+		}
+	}
+}
+
+func TestFrequencyFilter(t *testing.T) {
+	frequency := Frequency{FrequencyType: "TWR"}
+
+	var tests = []struct {
+		filter  FrequencyFilter
+		matches bool
+	}{
+		{FrequencyFilter{}, true},
+		{FrequencyFilter{FromFrequencyType: "APP", UntilFrequencyType: "TWR"}, true},
+		{FrequencyFilter{FromFrequencyType: "UNIC"}, false},
+		{FrequencyFilter{UntilFrequencyType: "GND"}, false},
+	}
+
+	for _, test := range tests {
+		if matches := test.filter.Matches(&frequency); matches != test.matches {
+			t.Errorf("Matches(%+v) expected %t, got %t", test.filter, test.matches, matches)
+		}
+	}
+}
+
+func TestListQueryFilter(t *testing.T) {
+	query, err := listQuery("NL", "", "", "", "", "", "airport-type==large_airport;runways.length=ge=3000")
+	if err != nil {
+		t.Fatalf("listQuery failed: %v", err)
+	}
+	last := query[len(query)-1]
+	if last.Key != "$and" || len(last.Value.(bson.A)) != 1 {
+		t.Errorf("listQuery expected the filter with the arguments, got %v", query)
+	}
+
+	_, err = listQuery("", "", "", "", "", "", "website==x")
+	if err == nil || !strings.HasPrefix(err.Error(), "Invalid Filter(website) at 1") {
+		t.Errorf("listQuery expected an error for a field that is not filtered on, got %v", err)
+	}
+}
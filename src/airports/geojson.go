@@ -0,0 +1,44 @@
+package airports
+
+import (
+	"../geometry"
+)
+
+// Feature represents the airport as a GeoJSON point. The properties are the JSON fields, the
+// runways are left out as they are features of their own.
+func (airport *Airport) Feature() *geometry.Feature {
+	feature := geometry.NewFeature(
+		geometry.NewPoint(geometry.Position{Latitude: airport.Latitude, Longitude: airport.Longitude}),
+		airport, "runways")
+	feature.Properties["feature-type"] = "airport"
+	return feature
+}
+
+// RunwayFeatures represents the runways with coordinates for both ends as GeoJSON lines
+func (airport *Airport) RunwayFeatures() []*geometry.Feature {
+	var result []*geometry.Feature
+
+	for _, runway := range airport.Runways {
+		line := runway.line()
+		if line == nil {
+			continue
+		}
+		feature := geometry.NewFeature(geometry.NewLineString(line), runway)
+		feature.Properties["feature-type"] = "runway"
+		feature.Properties["icao-airport-code"] = airport.AirportCode
+		feature.Properties["runway-code"] = runway.designator()
+		result = append(result, feature)
+	}
+
+	return result
+}
+
+// NewFeatureCollection turns airports and their runways into a single feature collection
+func NewFeatureCollection(list []*Airport) *geometry.FeatureCollection {
+	var features []*geometry.Feature
+	for _, airport := range list {
+		features = append(features, airport.Feature())
+		features = append(features, airport.RunwayFeatures()...)
+	}
+	return geometry.NewFeatureCollection(features)
+}
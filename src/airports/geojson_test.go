@@ -0,0 +1,34 @@
+package airports
+
+import "testing"
+
+func TestNewFeatureCollection(t *testing.T) {
+	airport := Airport{
+		AirportCode: "EHXX",
+		AirportName: "Polder",
+		Latitude:    52.3,
+		Longitude:   4.75,
+		Runways: []*Runway{
+			{Length: 10000,
+				LowEnd:  &RunwaySide{RunwayCode: "09", Latitude: 52.3, Longitude: 4.7},
+				HighEnd: &RunwaySide{RunwayCode: "27", Latitude: 52.3, Longitude: 4.8}},
+			{Length: 3000, LowEnd: &RunwaySide{RunwayCode: "18"}}, // without coordinates
+		}}
+
+	collection := NewFeatureCollection([]*Airport{&airport})
+	if len(collection.Features) != 2 {
+		t.Fatalf("NewFeatureCollection expected the airport and one runway, got %d features", len(collection.Features))
+	}
+
+	point := collection.Features[0]
+	if point.Geometry.Type != "Point" || point.Property("icao-airport-code") != "EHXX" ||
+		point.Property("airport-name") != "Polder" || point.Properties["runways"] != nil {
+		t.Errorf("Feature expected the airport without runways, got %v", point.Properties)
+	}
+
+	line := collection.Features[1]
+	if line.Geometry.Type != "LineString" || line.Property("runway-code") != "09/27" ||
+		line.Property("icao-airport-code") != "EHXX" || line.Property("length") != "10000" {
+		t.Errorf("RunwayFeatures expected runway 09/27, got %v", line.Properties)
+	}
+}
@@ -0,0 +1,291 @@
+package airports
+
+import (
+	"archive/zip"
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"../datatypes"
+	"../failures"
+	"../geometry"
+)
+
+// Selection chooses the airports of an export: those in a country, a region, a box or with the
+// given codes. The criteria combine, at least one is needed as the world is too much.
+type Selection struct {
+	CountryCode  string
+	RegionCode   string
+	Box          *geometry.Box
+	AirportCodes []string
+}
+
+// NewSelection validates the criteria of a selection, the box is written as in geometry.ParseBox
+// and the airport codes are separated by commas
+func NewSelection(countryCode string, regionCode string, box string, airportCodes string) (*Selection, error) {
+	var selection Selection
+	var err error
+
+	selection.CountryCode, err = datatypes.ISOCountryCode(countryCode, false, true)
+	if err != nil {
+		return nil, failures.New(failures.InvalidArgument, "Selection.CountryCode(%s): %v", countryCode, err)
+	}
+
+	selection.RegionCode, err = datatypes.ISORegionCode(regionCode, false, true)
+	if err != nil {
+		return nil, failures.New(failures.InvalidArgument, "Selection.RegionCode(%s): %v", regionCode, err)
+	}
+
+	if len(box) != 0 {
+		parsed, err := geometry.ParseBox(box)
+		if err != nil {
+			return nil, failures.New(failures.InvalidArgument, "Selection.Box(%s): %v", box, err)
+		}
+		selection.Box = &parsed
+	}
+
+	if len(airportCodes) != 0 {
+		for _, code := range strings.Split(airportCodes, ",") {
+			parameter, err := datatypes.ICAOAirportCode(code, false, false)
+			if err != nil {
+				return nil, failures.New(failures.InvalidArgument, "Selection.AirportCode(%s): %v", code, err)
+			}
+			selection.AirportCodes = append(selection.AirportCodes, parameter)
+		}
+	}
+
+	if len(selection.CountryCode) == 0 && len(selection.RegionCode) == 0 &&
+		selection.Box == nil && len(selection.AirportCodes) == 0 {
+		return nil, failures.New(failures.InvalidArgument, "Selection: Missing country, region, box or airport codes")
+	}
+
+	return &selection, nil
+}
+
+// Select hands the selected Airports to fn one at a time in order of their code. Unlike lists an
+// export has no maximum, but when nothing is selected fn is not called at all.
+func (airports *Airports) Select(selection *Selection, fn func(*Airport) error) error {
+	var query = bson.D{}
+
+	if len(selection.CountryCode) != 0 {
+		query = append(query, bson.E{Key: "iso-country-code", Value: selection.CountryCode})
+	}
+	if len(selection.RegionCode) != 0 {
+		query = append(query, bson.E{Key: "iso-region-code", Value: selection.RegionCode})
+	}
+	if selection.Box != nil {
+		query = append(query,
+			bson.E{Key: "latitude", Value: bson.D{
+				{Key: "$gte", Value: selection.Box.MinLatitude}, {Key: "$lte", Value: selection.Box.MaxLatitude}}},
+			bson.E{Key: "longitude", Value: bson.D{
+				{Key: "$gte", Value: selection.Box.MinLongitude}, {Key: "$lte", Value: selection.Box.MaxLongitude}}})
+	}
+	if len(selection.AirportCodes) != 0 {
+		query = append(query, bson.E{Key: "icao-airport-code", Value: bson.D{{Key: "$in", Value: selection.AirportCodes}}})
+	}
+
+	count, err := airports.collection.CountDocuments(airports.context.DBContext, query, options.Count().SetLimit(1))
+	if err != nil {
+		return failures.Database(err)
+	}
+	if count == 0 {
+		return failures.New(failures.NotFound, "Not found")
+	}
+
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "icao-airport-code", Value: 1}})
+
+	cur, err := airports.collection.Find(airports.context.DBContext, query, findOptions)
+	if err != nil {
+		return failures.Database(err)
+	}
+	defer cur.Close(airports.context.DBContext)
+
+	for cur.Next(airports.context.DBContext) {
+		var airport Airport
+		cur.Decode(&airport)
+		err = fn(&airport)
+		if err != nil {
+			return err
+		}
+	}
+
+	return cur.Err()
+}
+
+// kmlStyles are the colors (aabbggrr) and sizes of the airport types, closed runways use the
+// closed style
+var kmlStyles = []struct {
+	airportType string
+	color       string
+	scale       float64
+}{
+	{"large_airport", "ff0000ff", 1.2},
+	{"medium_airport", "ff00a5ff", 1.0},
+	{"small_airport", "ff00ffff", 0.8},
+	{"seaplane_base", "ffff8000", 0.8},
+	{"heliport", "ff00ff00", 0.7},
+	{"balloonport", "ffff00ff", 0.7},
+	{"closed", "ff808080", 0.6},
+}
+
+const kmlIcon = "http://maps.google.com/mapfiles/kml/shapes/airports.png"
+
+// KMLWriter writes airports with their runways as KML, or as KMZ which is the KML zipped. The
+// document starts with the first airport, so a failed selection leaves the output untouched.
+type KMLWriter struct {
+	name    string
+	buffer  *bufio.Writer
+	zip     *zip.Writer
+	output  io.Writer
+	started bool
+}
+
+// NewKMLWriter sets up writing a KML document with a name, compressed as KMZ when asked for
+func NewKMLWriter(w io.Writer, name string, compressed bool) *KMLWriter {
+	writer := KMLWriter{name: name, output: w}
+	if compressed {
+		writer.zip = zip.NewWriter(w)
+	}
+	return &writer
+}
+
+// Started tells whether anything was written yet
+func (writer *KMLWriter) Started() bool {
+	return writer.started
+}
+
+func (writer *KMLWriter) begin() error {
+	writer.started = true
+
+	output := writer.output
+	if writer.zip != nil {
+		var err error
+		output, err = writer.zip.Create("doc.kml")
+		if err != nil {
+			return err
+		}
+	}
+	writer.buffer = bufio.NewWriter(output)
+
+	fmt.Fprintf(writer.buffer, `<?xml version="1.0" encoding="UTF-8"?>`+"\n"+
+		`<kml xmlns="http://www.opengis.net/kml/2.2">`+"\n<Document>\n<name>%s</name>\n", escapeXML(writer.name))
+	for _, style := range kmlStyles {
+		fmt.Fprintf(writer.buffer, `<Style id="%s"><IconStyle><color>%s</color><scale>%.1f</scale>`+
+			"<Icon><href>%s</href></Icon></IconStyle></Style>\n", style.airportType, style.color, style.scale, kmlIcon)
+	}
+	writer.buffer.WriteString(`<Style id="runway"><LineStyle><color>ff404040</color><width>4</width></LineStyle></Style>` + "\n")
+	_, err := writer.buffer.WriteString(`<Style id="closed-runway"><LineStyle><color>ff0000ff</color><width>2</width></LineStyle></Style>` + "\n")
+	return err
+}
+
+// Write adds an airport as a folder with a placemark for the airport and one per runway
+func (writer *KMLWriter) Write(airport *Airport) error {
+	if !writer.started {
+		err := writer.begin()
+		if err != nil {
+			return err
+		}
+	}
+
+	title := escapeXML(strings.TrimSpace(airport.AirportCode + " " + airport.AirportName))
+	fmt.Fprintf(writer.buffer, "<Folder>\n<name>%s</name>\n", title)
+	fmt.Fprintf(writer.buffer, "<Placemark>\n<name>%s</name>\n<styleUrl>#%s</styleUrl>\n"+
+		"<description>%s</description>\n<Point><coordinates>%f,%f,%.0f</coordinates></Point>\n</Placemark>\n",
+		title, airportStyle(airport.AirportType), escapeXML(airport.kmlDescription()),
+		airport.Longitude, airport.Latitude, airport.Elevation*metersPerFoot)
+
+	for _, runway := range airport.Runways {
+		line := runway.line()
+		if line == nil {
+			continue
+		}
+		style := "runway"
+		if runway.Closed {
+			style = "closed-runway"
+		}
+		fmt.Fprintf(writer.buffer, "<Placemark>\n<name>%s</name>\n<styleUrl>#%s</styleUrl>\n"+
+			"<description>%s</description>\n<LineString><tessellate>1</tessellate><coordinates>",
+			escapeXML(runway.designator()), style, escapeXML(runway.kmlDescription()))
+		for _, position := range line {
+			fmt.Fprintf(writer.buffer, "%f,%f ", position.Longitude, position.Latitude)
+		}
+		writer.buffer.WriteString("</coordinates></LineString>\n</Placemark>\n")
+	}
+
+	_, err := writer.buffer.WriteString("</Folder>\n")
+	return err
+}
+
+// Close ends the document, without airports it is an empty document
+func (writer *KMLWriter) Close() error {
+	if !writer.started {
+		err := writer.begin()
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.buffer.WriteString("</Document>\n</kml>\n")
+	err := writer.buffer.Flush()
+	if err != nil {
+		return err
+	}
+
+	if writer.zip != nil {
+		return writer.zip.Close()
+	}
+	return nil
+}
+
+// airportStyle picks the style of an airport type, unknown types look like small airports
+func airportStyle(airportType string) string {
+	for _, style := range kmlStyles {
+		if style.airportType == airportType {
+			return airportType
+		}
+	}
+	return "small_airport"
+}
+
+// kmlDescription is the HTML shown in the balloon of an airport
+func (airport *Airport) kmlDescription() string {
+	var description strings.Builder
+
+	fmt.Fprintf(&description, "<b>%s</b><br/>", escapeXML(airport.AirportName))
+	if len(airport.IATA) > 0 {
+		fmt.Fprintf(&description, "IATA: %s<br/>", escapeXML(airport.IATA))
+	}
+	if len(airport.Municipality) > 0 {
+		fmt.Fprintf(&description, "%s, %s<br/>", escapeXML(airport.Municipality), escapeXML(airport.CountryCode))
+	}
+	fmt.Fprintf(&description, "Type: %s<br/>Elevation: %.0f ft<br/>", escapeXML(airport.AirportType), airport.Elevation)
+
+	if len(airport.Frequencies) > 0 {
+		description.WriteString("<table>")
+		for _, frequency := range airport.Frequencies {
+			fmt.Fprintf(&description, "<tr><td>%s</td><td>%s</td><td>%.3f</td></tr>",
+				escapeXML(frequency.FrequencyType), escapeXML(frequency.Description), frequency.Frequency)
+		}
+		description.WriteString("</table>")
+	}
+
+	return description.String()
+}
+
+// kmlDescription is the HTML shown in the balloon of a runway
+func (runway *Runway) kmlDescription() string {
+	description := fmt.Sprintf("Length: %d ft<br/>Width: %d ft<br/>Surface: %s<br/>",
+		runway.Length, runway.Width, escapeXML(runway.Surface))
+	if runway.Lighted {
+		description += "Lighted<br/>"
+	}
+	if runway.Closed {
+		description += "Closed<br/>"
+	}
+	return description
+}
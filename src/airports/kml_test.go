@@ -0,0 +1,97 @@
+package airports
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestNewSelection(t *testing.T) {
+	var tests = []struct {
+		countryCode  string
+		regionCode   string
+		box          string
+		airportCodes string
+		correct      bool
+	}{
+		{"NL", "", "", "", true},
+		{"", "NL-NH", "", "", true},
+		{"", "", "3.3,50.7,7.2,53.6", "", true},
+		{"", "", "", "eham, EHRD", true},
+		{"", "", "", "", false}, // the world
+		{"NLD", "", "", "", false},
+		{"", "", "3.3,50.7,7.2", "", false},
+		{"", "", "", "EHAM,,EHRD", false},
+	}
+
+	for _, test := range tests {
+		selection, err := NewSelection(test.countryCode, test.regionCode, test.box, test.airportCodes)
+		if (test.correct && err != nil) || (!test.correct && err == nil) {
+			t.Errorf("NewSelection(%s, %s, %s, %s) expected %t, got %v", test.countryCode, test.regionCode,
+				test.box, test.airportCodes, test.correct, err)
+		}
+		if err == nil && len(test.airportCodes) > 0 && selection.AirportCodes[0] != "EHAM" {
+			t.Errorf("NewSelection expected EHAM first, got %v", selection.AirportCodes)
+		}
+	}
+}
+
+var kmlAirport = Airport{
+	AirportCode: "EHXX",
+	AirportName: "Polder & Dunes",
+	AirportType: "medium_airport",
+	Latitude:    52.3,
+	Longitude:   4.75,
+	Elevation:   -11,
+	Runways: []*Runway{
+		{Length: 10000, Width: 150, Closed: true,
+			LowEnd:  &RunwaySide{RunwayCode: "09", Latitude: 52.3, Longitude: 4.7},
+			HighEnd: &RunwaySide{RunwayCode: "27", Latitude: 52.3, Longitude: 4.8}},
+	},
+	Frequencies: []*Frequency{{FrequencyType: "TWR", Description: "Tower", Frequency: 118.1}},
+}
+
+func TestKMLWriter(t *testing.T) {
+	var output bytes.Buffer
+	writer := NewKMLWriter(&output, "Export", false)
+	if writer.Started() {
+		t.Errorf("Started expected nothing written yet")
+	}
+	writer.Write(&kmlAirport)
+	writer.Close()
+
+	result := output.String()
+	for _, expected := range []string{"<name>Export</name>", "<name>EHXX Polder &amp; Dunes</name>",
+		"<styleUrl>#medium_airport</styleUrl>", "<coordinates>4.750000,52.300000,-3</coordinates>",
+		"<name>09/27</name>", "#closed-runway", "4.700000,52.300000 4.800000,52.300000",
+		"Elevation: -11 ft", "&lt;td&gt;TWR&lt;/td&gt;", "</kml>"} {
+		if !strings.Contains(result, expected) {
+			t.Errorf("KMLWriter expected %s in\n%s", expected, result)
+		}
+	}
+}
+
+func TestKMZ(t *testing.T) {
+	var output bytes.Buffer
+	writer := NewKMLWriter(&output, "Export", true)
+	writer.Write(&kmlAirport)
+	writer.Close()
+
+	archive, err := zip.NewReader(bytes.NewReader(output.Bytes()), int64(output.Len()))
+	if err != nil || len(archive.File) != 1 || archive.File[0].Name != "doc.kml" {
+		t.Fatalf("KMZ expected a zip with doc.kml, got %v", err)
+	}
+	file, _ := archive.File[0].Open()
+	content, _ := ioutil.ReadAll(file)
+	if !bytes.Contains(content, []byte("EHXX")) {
+		t.Errorf("KMZ expected EHXX in doc.kml")
+	}
+}
+
+func TestAirportStyle(t *testing.T) {
+	if airportStyle("heliport") != "heliport" || airportStyle("spaceport") != "small_airport" {
+		t.Errorf("airportStyle expected heliport and the small airport fallback")
+	}
+}
@@ -0,0 +1,35 @@
+package airports
+
+import (
+	"fmt"
+
+	"../countries"
+)
+
+// SetBoundaries supplies the country and region boundaries used to cross-check the country and
+// region of the airports on import
+func (airports *Airports) SetBoundaries(boundaries *countries.Boundaries) {
+	airports.boundaries = boundaries
+}
+
+// checkLocation logs a warning when the position of an airport lies in another country or region
+// than the one it is filed under. Positions outside all boundaries, like airports on the coast
+// of a coarse boundary, are not reported.
+func (airports *Airports) checkLocation(lineNumber int, airportCode string, countryCode string,
+	regionCode string, latitude float64, longitude float64) {
+
+	if airports.boundaries == nil {
+		return
+	}
+
+	location, err := airports.boundaries.Locate(latitude, longitude)
+	if err != nil {
+		return
+	}
+
+	if location.CountryCode != countryCode ||
+		(len(location.RegionCode) > 0 && location.RegionCode != regionCode) {
+		airports.context.LogPrintln(fmt.Sprintf("Airport[%d](%s): Warning, filed under %s-%s but located in %s-%s",
+			lineNumber, airportCode, countryCode, regionCode, location.CountryCode, location.RegionCode))
+	}
+}
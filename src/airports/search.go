@@ -0,0 +1,81 @@
+package airports
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"../failures"
+	"../search"
+)
+
+// searchBoosts are the boosts of the airport types in a search, the larger airports come first
+var searchBoosts = map[string]float64{
+	"large_airport":  0.6,
+	"medium_airport": 0.3,
+	"small_airport":  0.1,
+	"closed":         -0.5,
+}
+
+// scheduledBoost is added for an airport with scheduled service, the airports people fly from
+const scheduledBoost = 0.4
+
+// SearchEntries retrieves the airports as entries of the search index, an airport is found by the
+// name of its country as well
+func (airports *Airports) SearchEntries(countryNames map[string]string) ([]*search.Entry, error) {
+	var result []*search.Entry
+
+	findOptions := options.Find().SetProjection(bson.D{
+		{Key: "icao-airport-code", Value: 1},
+		{Key: "iata-airport-code", Value: 1},
+		{Key: "airport-name", Value: 1},
+		{Key: "airport-type", Value: 1},
+		{Key: "iso-country-code", Value: 1},
+		{Key: "municipality", Value: 1},
+		{Key: "scheduled-service", Value: 1},
+		{Key: "keywords", Value: 1},
+	})
+	cur, err := airports.collection.Find(airports.context.DBContext, bson.D{}, findOptions)
+	if err != nil {
+		return nil, failures.Database(err)
+	}
+	defer cur.Close(airports.context.DBContext)
+
+	for cur.Next(airports.context.DBContext) {
+		var airport Airport
+		err = cur.Decode(&airport)
+		if err != nil {
+			return nil, failures.Database(err)
+		}
+		result = append(result, searchEntry(&airport, countryNames[airport.CountryCode]))
+	}
+
+	if cur.Err() != nil {
+		return nil, failures.Database(cur.Err())
+	}
+	return result, nil
+}
+
+// searchEntry makes the entry of an airport
+func searchEntry(airport *Airport, countryName string) *search.Entry {
+	entry := search.Entry{
+		Kind:        search.Airport,
+		Code:        airport.AirportCode,
+		CountryCode: airport.CountryCode,
+		Name:        airport.AirportName,
+		Texts: []search.Text{
+			{Value: airport.AirportCode, Weight: search.CodeWeight},
+			{Value: airport.IATA, Weight: search.CodeWeight},
+			{Value: airport.AirportName, Weight: search.NameWeight},
+			{Value: airport.Municipality, Weight: search.PlaceWeight},
+			{Value: countryName, Weight: search.ParentWeight},
+		},
+		Boost: searchBoosts[airport.AirportType],
+	}
+	for _, keyword := range airport.Keywords {
+		entry.Texts = append(entry.Texts, search.Text{Value: keyword, Weight: search.KeywordWeight})
+	}
+	if airport.Scheduled {
+		entry.Boost += scheduledBoost
+	}
+	return &entry
+}
@@ -0,0 +1,28 @@
+package airports
+
+import (
+	"testing"
+
+	"../search"
+)
+
+func TestSearchEntry(t *testing.T) {
+	schiphol := searchEntry(&Airport{AirportCode: "EHAM", IATA: "AMS", AirportName: "Amsterdam Airport Schiphol",
+		AirportType: "large_airport", CountryCode: "NL", Municipality: "Amsterdam", Scheduled: true,
+		Keywords: []string{"AMS"}}, "Netherlands")
+	heliport := searchEntry(&Airport{AirportCode: "EHHE", AirportName: "Schiphol Heliport",
+		AirportType: "heliport", CountryCode: "NL"}, "Netherlands")
+
+	if schiphol.Kind != search.Airport || schiphol.Code != "EHAM" || len(schiphol.Texts) != 6 {
+		t.Errorf("searchEntry expected the codes, name, place, country and keywords, got %+v", schiphol)
+	}
+	if schiphol.Boost != 1.0 || heliport.Boost != 0 {
+		t.Errorf("searchEntry expected the boost of the type and scheduled service, got %f and %f",
+			schiphol.Boost, heliport.Boost)
+	}
+
+	results := search.NewIndex([]*search.Entry{heliport, schiphol}).Search("schiphol", nil, 10)
+	if len(results) != 2 || results[0].Code != "EHAM" {
+		t.Errorf("Search expected the larger airport first, got %v", results)
+	}
+}
@@ -0,0 +1,141 @@
+package airports
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"../failures"
+	"../geometry"
+	"../tiles"
+)
+
+// Runways are drawn from this zoom level on, below it they are too small to see
+const runwayZoom = 11
+
+// tileLimit caps the number of airports in a single tile
+const tileLimit = 20000
+
+// tileAirportTypes decides which airports are shown at a zoom level, the bigger ones first
+func tileAirportTypes(zoom int) []string {
+	switch {
+	case zoom < 5:
+		return []string{"large_airport"}
+	case zoom < 8:
+		return []string{"large_airport", "medium_airport"}
+	case zoom < 10:
+		return []string{"large_airport", "medium_airport", "small_airport", "seaplane_base"}
+	case zoom < 12:
+		return []string{"large_airport", "medium_airport", "small_airport", "seaplane_base", "heliport"}
+	}
+	return []string{"large_airport", "medium_airport", "small_airport", "seaplane_base", "heliport",
+		"balloonport", "closed"}
+}
+
+// GetInBox retrieves the airports of the given types within a box, with their runways when asked
+// for. It is meant for maps so the frequencies are left out and MaxResults does not apply.
+func (airports *Airports) GetInBox(box geometry.Box, airportTypes []string, withRunways bool) ([]*Airport, error) {
+	var result []*Airport
+
+	query := bson.D{
+		{Key: "latitude", Value: bson.D{{Key: "$gte", Value: box.MinLatitude}, {Key: "$lte", Value: box.MaxLatitude}}},
+		{Key: "longitude", Value: bson.D{{Key: "$gte", Value: box.MinLongitude}, {Key: "$lte", Value: box.MaxLongitude}}}}
+	if len(airportTypes) > 0 {
+		query = append(query, bson.E{Key: "airport-type", Value: bson.D{{Key: "$in", Value: airportTypes}}})
+	}
+
+	projection := bson.D{{Key: "frequencies", Value: 0}}
+	if !withRunways {
+		projection = append(projection, bson.E{Key: "runways", Value: 0})
+	}
+
+	findOptions := options.Find()
+	findOptions.SetProjection(projection)
+	findOptions.SetLimit(tileLimit)
+
+	cur, err := airports.collection.Find(airports.context.DBContext, query, findOptions)
+	if err != nil {
+		return nil, failures.Database(err)
+	}
+
+	for cur.Next(airports.context.DBContext) {
+		var airport Airport
+		cur.Decode(&airport)
+		result = append(result, &airport)
+	}
+
+	cur.Close(airports.context.DBContext)
+
+	return result, nil
+}
+
+// Tile renders the airports, and at high zoom levels their runways, as a vector tile
+func (airports *Airports) Tile(zoom int, x int, y int) ([]byte, error) {
+	tile, err := tiles.NewTile(zoom, x, y)
+	if err != nil {
+		return nil, failures.Wrap(failures.InvalidArgument, err)
+	}
+
+	withRunways := zoom >= runwayZoom
+	list, err := airports.GetInBox(tile.Bounds(), tileAirportTypes(zoom), withRunways)
+	if err != nil {
+		return nil, err
+	}
+
+	airportLayer := tile.AddLayer("airports")
+	runwayLayer := tile.AddLayer("runways")
+
+	for _, airport := range list {
+		airportLayer.AddPoint(airport.Latitude, airport.Longitude, map[string]interface{}{
+			"icao-airport-code": airport.AirportCode,
+			"iata-airport-code": airport.IATA,
+			"airport-name":      airport.AirportName,
+			"airport-type":      airport.AirportType})
+
+		if !withRunways {
+			continue
+		}
+		for _, runway := range airport.Runways {
+			line := runway.line()
+			if line == nil {
+				continue
+			}
+			runwayLayer.AddLine(line, map[string]interface{}{
+				"icao-airport-code": airport.AirportCode,
+				"runway-code":       runway.designator(),
+				"length":            runway.Length,
+				"width":             runway.Width,
+				"surface":           runway.Surface,
+				"lighted":           runway.Lighted,
+				"closed":            runway.Closed})
+		}
+	}
+
+	return tile.Encode(), nil
+}
+
+// line is the center line of the runway between its ends, nil without coordinates for both
+func (runway *Runway) line() []geometry.Position {
+	if runway.LowEnd == nil || runway.HighEnd == nil {
+		return nil
+	}
+	if (runway.LowEnd.Latitude == 0 && runway.LowEnd.Longitude == 0) ||
+		(runway.HighEnd.Latitude == 0 && runway.HighEnd.Longitude == 0) {
+		return nil
+	}
+
+	return []geometry.Position{
+		{Latitude: runway.LowEnd.Latitude, Longitude: runway.LowEnd.Longitude},
+		{Latitude: runway.HighEnd.Latitude, Longitude: runway.HighEnd.Longitude}}
+}
+
+// designator names the runway by its ends, like 09/27
+func (runway *Runway) designator() string {
+	var result string
+	if runway.LowEnd != nil {
+		result = runway.LowEnd.RunwayCode
+	}
+	if runway.HighEnd != nil && len(runway.HighEnd.RunwayCode) > 0 {
+		result += "/" + runway.HighEnd.RunwayCode
+	}
+	return result
+}
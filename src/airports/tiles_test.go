@@ -0,0 +1,47 @@
+package airports
+
+import "testing"
+
+func TestTileAirportTypes(t *testing.T) {
+	// Every zoom level shows at least what the level below it shows
+	previous := map[string]bool{}
+	for zoom := 0; zoom <= 22; zoom++ {
+		current := map[string]bool{}
+		for _, airportType := range tileAirportTypes(zoom) {
+			current[airportType] = true
+		}
+		for airportType := range previous {
+			if !current[airportType] {
+				t.Errorf("tileAirportTypes(%d) lost %s", zoom, airportType)
+			}
+		}
+		previous = current
+	}
+
+	if len(tileAirportTypes(0)) != 1 || tileAirportTypes(0)[0] != "large_airport" {
+		t.Errorf("tileAirportTypes(0) expected only large airports, got %v", tileAirportTypes(0))
+	}
+}
+
+func TestRunwayLine(t *testing.T) {
+	var tests = []struct {
+		runway     Runway
+		designator string
+		hasLine    bool
+	}{
+		{Runway{LowEnd: &RunwaySide{RunwayCode: "09", Latitude: 52.3, Longitude: 4.7},
+			HighEnd: &RunwaySide{RunwayCode: "27", Latitude: 52.3, Longitude: 4.8}}, "09/27", true},
+		{Runway{LowEnd: &RunwaySide{RunwayCode: "09", Latitude: 52.3, Longitude: 4.7},
+			HighEnd: &RunwaySide{RunwayCode: "27"}}, "09/27", false}, // one end without coordinates
+		{Runway{LowEnd: &RunwaySide{RunwayCode: "H1", Latitude: 52.3, Longitude: 4.7}}, "H1", false}, // helipad
+	}
+
+	for _, test := range tests {
+		if designator := test.runway.designator(); designator != test.designator {
+			t.Errorf("designator expected %s, got %s", test.designator, designator)
+		}
+		if line := test.runway.line(); (line != nil) != test.hasLine {
+			t.Errorf("line(%s) expected %t, got %v", test.designator, test.hasLine, line)
+		}
+	}
+}
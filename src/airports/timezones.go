@@ -0,0 +1,83 @@
+package airports
+
+import (
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"../timezones"
+)
+
+// SetTimeZones supplies the boundaries used to assign a time zone to the airports on import.
+// Without them the time zone of an airport is left as it is.
+func (airports *Airports) SetTimeZones(zones *timezones.Zones) {
+	airports.timeZones = zones
+}
+
+func (airports *Airports) lookupTimeZone(latitude float64, longitude float64) string {
+	if airports.timeZones == nil {
+		return ""
+	}
+	return airports.timeZones.Lookup(latitude, longitude)
+}
+
+// AssignTimeZones (re)assigns the time zone of all airports already in the database
+func (airports *Airports) AssignTimeZones() error {
+	if airports.timeZones == nil {
+		return fmt.Errorf("AssignTimeZones: No time zone boundaries")
+	}
+
+	// Open the logfile
+	_, err := airports.context.LogFile("timezones")
+	if err != nil {
+		return err
+	}
+	defer airports.context.LogClose()
+
+	airports.context.LogPrintln("Start Assignment")
+
+	cur, err := airports.collection.Find(airports.context.DBContext, bson.D{{}})
+	if err != nil {
+		return err
+	}
+	defer cur.Close(airports.context.DBContext)
+
+	count := 0
+	for cur.Next(airports.context.DBContext) {
+		var airport Airport
+		err = cur.Decode(&airport)
+		if err != nil {
+			airports.context.LogError(err)
+			continue
+		}
+
+		timeZone := airports.timeZones.Lookup(airport.Latitude, airport.Longitude)
+		if timeZone == airport.TimeZone {
+			continue
+		}
+
+		_, err = airports.collection.UpdateOne(airports.context.DBContext,
+			bson.D{{Key: "_id", Value: airport.Airport}},
+			bson.M{"$set": bson.M{"time-zone": timeZone}})
+		if err != nil {
+			airports.context.LogError(fmt.Errorf("Airport(%s).TimeZone: %v", airport.AirportCode, err))
+			continue
+		}
+		count++
+	}
+
+	airports.context.LogPrintln(fmt.Sprintf("Assigned %d time zones", count))
+	airports.context.LogPrintln("End Assignment")
+
+	return cur.Err()
+}
+
+// Location returns the time zone of the airport
+func (airport *Airport) Location() (*time.Location, error) {
+	location, err := timezones.Location(airport.TimeZone)
+	if err != nil {
+		return nil, fmt.Errorf("Airport(%s): %v", airport.AirportCode, err)
+	}
+	return location, nil
+}
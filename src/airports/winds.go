@@ -0,0 +1,138 @@
+package airports
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// RunwayWind expresses how a given wind acts on a single runway direction. Headwind is
+// negative for a tailwind, Crosswind is positive for wind from the right.
+type RunwayWind struct {
+	RunwayCode string  `json:"runway-code"`
+	Heading    int     `json:"heading"`
+	Length     int     `json:"length"`
+	Closed     bool    `json:"closed"`
+	Headwind   float64 `json:"headwind"`
+	Crosswind  float64 `json:"crosswind"`
+	Usable     bool    `json:"usable"`
+	Rank       int     `json:"rank,omitempty"`
+}
+
+// Sides returns the runway directions that carry a runway code, low end first
+func (runway *Runway) Sides() []*RunwaySide {
+	var result []*RunwaySide
+
+	if runway.LowEnd != nil && len(runway.LowEnd.RunwayCode) > 0 {
+		result = append(result, runway.LowEnd)
+	}
+	if runway.HighEnd != nil && len(runway.HighEnd.RunwayCode) > 0 {
+		result = append(result, runway.HighEnd)
+	}
+
+	return result
+}
+
+// DesignatorHeading derives a heading from the runway designator ("09L" gives 90), it
+// returns 0 when the designator holds no number (e.g. "H1" or "N")
+func DesignatorHeading(runwayCode string) int {
+	suffix := strings.TrimLeftFunc(runwayCode, unicode.IsDigit)
+	digits := runwayCode[:len(runwayCode)-len(suffix)]
+	if len(digits) == 0 || len(digits) > 2 {
+		return 0
+	}
+
+	value, err := strconv.Atoi(digits)
+	if err != nil || value < 1 || value > 36 {
+		return 0
+	}
+
+	return value * 10
+}
+
+// WindComponents splits a wind (direction it blows from, in degrees, and speed) into the
+// headwind and crosswind components for the given runway heading.
+func WindComponents(heading int, direction int, speed int) (float64, float64) {
+	angle := float64(direction-heading) * math.Pi / 180.0
+
+	headwind := float64(speed) * math.Cos(angle)
+	crosswind := float64(speed) * math.Sin(angle)
+
+	// Round to one decimal, more precision isn't meaningful for wind
+	return math.Round(headwind*10) / 10, math.Round(crosswind*10) / 10
+}
+
+// NewRunwayWind calculates the wind components for a single runway direction and decides
+// whether the direction is usable: not closed, no tailwind and a crosswind within the limit.
+func NewRunwayWind(runwayCode string, heading int, length int, closed bool,
+	direction int, speed int, crosswindLimit int) *RunwayWind {
+
+	if heading == 0 {
+		heading = DesignatorHeading(runwayCode)
+	}
+
+	headwind, crosswind := WindComponents(heading, direction, speed)
+
+	result := RunwayWind{
+		RunwayCode: runwayCode,
+		Heading:    heading,
+		Length:     length,
+		Closed:     closed,
+		Headwind:   headwind,
+		Crosswind:  crosswind}
+
+	result.Usable = !closed && heading != 0 && headwind >= 0 &&
+		math.Abs(crosswind) <= float64(crosswindLimit)
+
+	return &result
+}
+
+// RankRunwayWinds orders the runway directions from best to worst and numbers the usable
+// ones: the most headwind wins, on a tie the longest runway.
+func RankRunwayWinds(winds []*RunwayWind) {
+	sort.SliceStable(winds, func(i, j int) bool {
+		if winds[i].Usable != winds[j].Usable {
+			return winds[i].Usable
+		}
+		if winds[i].Headwind != winds[j].Headwind {
+			return winds[i].Headwind > winds[j].Headwind
+		}
+		if winds[i].Length != winds[j].Length {
+			return winds[i].Length > winds[j].Length
+		}
+		return winds[i].RunwayCode < winds[j].RunwayCode
+	})
+
+	rank := 1
+	for _, wind := range winds {
+		wind.Rank = 0
+		if wind.Usable {
+			wind.Rank = rank
+			rank++
+		}
+	}
+}
+
+// RunwayWinds calculates and ranks the wind components for every runway direction of the airport
+func (airport *Airport) RunwayWinds(direction int, speed int, crosswindLimit int) []*RunwayWind {
+	var result []*RunwayWind
+
+	for _, runway := range airport.Runways {
+		for _, side := range runway.Sides() {
+			result = append(result, NewRunwayWind(side.RunwayCode, side.Heading, runway.Length,
+				runway.Closed, direction, speed, crosswindLimit))
+		}
+	}
+
+	RankRunwayWinds(result)
+
+	return result
+}
+
+// CrosswindLimit returns the configured crosswind limit in knots, used when the caller has no
+// limit of its own
+func (airports *Airports) CrosswindLimit() int {
+	return airports.context.CrosswindLimit
+}
@@ -0,0 +1,99 @@
+package airports
+
+import "testing"
+
+func TestDesignatorHeading(t *testing.T) {
+	var tests = []struct {
+		value  string
+		result int
+	}{
+		{"", 0},      // nothing to go on
+		{"H1", 0},    // helipads have no heading
+		{"N", 0},     // neither do compass designators
+		{"09", 90},   // plain runway
+		{"9", 90},    // leading zero is optional
+		{"27L", 270}, // parallel runways
+		{"36", 360},  // north is 36, not 0
+		{"37", 0},    // beyond the compass
+		{"123", 0},   // too many digits
+	}
+
+	for _, test := range tests {
+		result := DesignatorHeading(test.value)
+		if test.result != result {
+			t.Errorf("DesignatorHeading(%s) expected %d, got %d", test.value, test.result, result)
+		}
+	}
+}
+
+func TestWindComponents(t *testing.T) {
+	var tests = []struct {
+		heading   int
+		direction int
+		speed     int
+		headwind  float64
+		crosswind float64
+	}{
+		{90, 90, 10, 10.0, 0.0},   // straight down the runway
+		{270, 90, 10, -10.0, 0.0}, // straight from behind
+		{90, 180, 10, 0.0, 10.0},  // from the right
+		{90, 0, 10, 0.0, -10.0},   // from the left
+		{360, 30, 20, 17.3, 10.0}, // 30 degrees off
+		{10, 350, 20, 18.8, -6.8}, // crossing north
+		{180, 180, 0, 0.0, 0.0},   // calm
+	}
+
+	for _, test := range tests {
+		headwind, crosswind := WindComponents(test.heading, test.direction, test.speed)
+		if headwind != test.headwind || crosswind != test.crosswind {
+			t.Errorf("WindComponents(%d, %d, %d) expected %.1f/%.1f, got %.1f/%.1f",
+				test.heading, test.direction, test.speed, test.headwind, test.crosswind, headwind, crosswind)
+		}
+	}
+}
+
+func TestRunwayWinds(t *testing.T) {
+	airport := Airport{
+		AirportCode: "EHAM",
+		Runways: []*Runway{
+			{Length: 11483, Closed: false,
+				LowEnd:  &RunwaySide{RunwayCode: "06", Heading: 58},
+				HighEnd: &RunwaySide{RunwayCode: "24", Heading: 238}},
+			{Length: 10830, Closed: false,
+				LowEnd:  &RunwaySide{RunwayCode: "18C", Heading: 183},
+				HighEnd: &RunwaySide{RunwayCode: "36C", Heading: 3}},
+			{Length: 6608, Closed: true,
+				LowEnd:  &RunwaySide{RunwayCode: "04", Heading: 41},
+				HighEnd: &RunwaySide{RunwayCode: "22", Heading: 221}},
+		},
+	}
+
+	winds := airport.RunwayWinds(230, 15, 20)
+	if len(winds) != 6 {
+		t.Fatalf("RunwayWinds expected 6 directions, got %d", len(winds))
+	}
+
+	// 22 has the best headwind but is closed, so 24 goes first
+	if winds[0].RunwayCode != "24" || winds[0].Rank != 1 {
+		t.Errorf("RunwayWinds expected 24 to rank first, got %s (%d)", winds[0].RunwayCode, winds[0].Rank)
+	}
+	if winds[1].RunwayCode != "18C" || winds[1].Rank != 2 {
+		t.Errorf("RunwayWinds expected 18C to rank second, got %s (%d)", winds[1].RunwayCode, winds[1].Rank)
+	}
+	for _, wind := range winds {
+		if wind.RunwayCode == "22" && (wind.Usable || wind.Rank != 0) {
+			t.Errorf("RunwayWinds expected closed runway 22 to be unusable")
+		}
+		if wind.Headwind < 0 && wind.Usable {
+			t.Errorf("RunwayWinds expected tailwind runway %s to be unusable", wind.RunwayCode)
+		}
+	}
+
+	// A tight crosswind limit rules out 18C
+	winds = airport.RunwayWinds(230, 15, 5)
+	for _, wind := range winds {
+		if wind.RunwayCode == "18C" && wind.Usable {
+			t.Errorf("RunwayWinds expected 18C to exceed a 5kt crosswind limit")
+		}
+	}
+}
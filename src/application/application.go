@@ -25,11 +25,18 @@ type Context struct {
 	logBuffer      *bytes.Buffer
 	logTopic       string
 	MaxResults     int64
+	MaxBatchSize   int
+	CrosswindLimit int
+	WeatherHistory int
+	MagneticModel  string
+	TimeZones      string
 	CountriesURL   string
 	RegionsURL     string
 	AirportsURL    string
 	RunwaysURL     string
 	FrequenciesURL string
+	CacheControl   map[string]string
+	ExportKeys     []string
 }
 
 // Optionfile descibes the content of the options file
@@ -48,10 +55,17 @@ type storageOptions struct {
 }
 
 type optionFile struct {
-	Source     sourceOptions  `json:"source"`
-	Storage    storageOptions `json:"storage"`
-	Database   string         `json:"database"`
-	MaxResults int64          `json:"max-results"`
+	Source         sourceOptions     `json:"source"`
+	Storage        storageOptions    `json:"storage"`
+	Database       string            `json:"database"`
+	MaxResults     int64             `json:"max-results"`
+	MaxBatchSize   int               `json:"max-batch-size"`
+	CrosswindLimit int               `json:"crosswind-limit"`
+	WeatherHistory int               `json:"weather-history-hours"`
+	MagneticModel  string            `json:"magnetic-model"`
+	TimeZones      string            `json:"timezone-boundaries"`
+	CacheControl   map[string]string `json:"cache-control"`
+	ExportKeys     []string          `json:"export-keys"`
 }
 
 func readOptions() (*optionFile, error) {
@@ -133,6 +147,13 @@ func GetContext() (*Context, error) {
 		DBClient:       client,
 		DBContext:      context.TODO(),
 		MaxResults:     applicationOptions.MaxResults,
+		MaxBatchSize:   applicationOptions.MaxBatchSize,
+		CrosswindLimit: applicationOptions.CrosswindLimit,
+		WeatherHistory: applicationOptions.WeatherHistory,
+		CacheControl:   applicationOptions.CacheControl,
+		ExportKeys:     applicationOptions.ExportKeys,
+		MagneticModel:  applicationOptions.MagneticModel,
+		TimeZones:      applicationOptions.TimeZones,
 		CountriesURL:   applicationOptions.Source.CountriesURL,
 		RegionsURL:     applicationOptions.Source.RegionsURL,
 		AirportsURL:    applicationOptions.Source.AirportsURL,
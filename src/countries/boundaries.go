@@ -0,0 +1,396 @@
+package countries
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/minio/minio-go"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"../application"
+	"../datatypes"
+	"../failures"
+	"../geometry"
+)
+
+// boundaries implements the boundary polygons of countries and regions, used to find the
+// country and region containing a position
+
+// Boundaries represents the connection to the boundaries collection
+type Boundaries struct {
+	context    *application.Context
+	collection *mongo.Collection
+	parent     *Countries
+}
+
+// Boundary is the area of a country, or of a region when the RegionCode is filled
+type Boundary struct {
+	CountryCode string                `bson:"iso-country-code" json:"iso-country-code"`
+	RegionCode  string                `bson:"iso-region-code" json:"iso-region-code,omitempty"`
+	Bounds      geometry.Box          `bson:"bounds" json:"bounds"`
+	Centroid    geometry.Position     `bson:"centroid" json:"centroid"`
+	Area        geometry.MultiPolygon `bson:"area" json:"-"`
+	Simplified  []*SimplifiedArea     `bson:"simplified" json:"-"`
+}
+
+// SimplifiedArea is the area simplified to a tolerance, for display at lower zoom levels
+type SimplifiedArea struct {
+	Tolerance float64               `bson:"tolerance"`
+	Area      geometry.MultiPolygon `bson:"area"`
+}
+
+// Tolerances are the tolerances in degrees the areas are simplified to on import, roughly
+// 100m, 1km and 10km
+var Tolerances = []float64{0.001, 0.01, 0.1}
+
+// SimplifiedTolerance is the tolerance used when asking for a "simplified" geometry
+const SimplifiedTolerance = 0.01
+
+// Location is the country and region containing a position
+type Location struct {
+	Latitude    float64 `json:"latitude"`
+	Longitude   float64 `json:"longitude"`
+	CountryCode string  `json:"iso-country-code"`
+	CountryName string  `json:"country-name"`
+	RegionCode  string  `json:"iso-region-code,omitempty"`
+	RegionName  string  `json:"region-name,omitempty"`
+}
+
+// Property names for the codes, as used in our own files and in the Natural Earth
+// admin-0 (countries) and admin-1 (regions) files
+var (
+	countryProperties = []string{"iso-country-code", "ISO_A2", "iso_a2"}
+	regionProperties  = []string{"iso-region-code", "iso_3166_2", "ISO_3166_2"}
+)
+
+// NewBoundaries establishes the connection to the database
+func (countries *Countries) NewBoundaries() *Boundaries {
+	boundaries := Boundaries{
+		context: countries.context,
+		parent:  countries,
+	}
+
+	boundaries.collection = countries.context.DBClient.Database("flight-schedule").Collection("boundaries")
+	boundaryIndex1 := mongo.IndexModel{Keys: bson.D{
+		{Key: "iso-country-code", Value: 1},
+		{Key: "iso-region-code", Value: 1}}}
+	boundaries.collection.Indexes().CreateOne(countries.context.DBContext, boundaryIndex1)
+	boundaryIndex2 := mongo.IndexModel{Keys: bson.D{
+		{Key: "bounds.min-latitude", Value: 1},
+		{Key: "bounds.max-latitude", Value: 1}}}
+	boundaries.collection.Indexes().CreateOne(countries.context.DBContext, boundaryIndex2)
+
+	return &boundaries
+}
+
+// Locate finds the country and region containing a position
+func (boundaries *Boundaries) Locate(latitude float64, longitude float64) (*Location, error) {
+	var candidates []*Boundary
+
+	findOptions := options.Find()
+	findOptions.SetProjection(bson.D{{Key: "simplified", Value: 0}})
+
+	cur, err := boundaries.collection.Find(boundaries.context.DBContext,
+		bson.D{
+			{Key: "bounds.min-latitude", Value: bson.D{{Key: "$lte", Value: latitude}}},
+			{Key: "bounds.max-latitude", Value: bson.D{{Key: "$gte", Value: latitude}}},
+			{Key: "bounds.min-longitude", Value: bson.D{{Key: "$lte", Value: longitude}}},
+			{Key: "bounds.max-longitude", Value: bson.D{{Key: "$gte", Value: longitude}}}},
+		findOptions)
+	if err != nil {
+		return nil, failures.Database(err)
+	}
+
+	for cur.Next(boundaries.context.DBContext) {
+		var boundary Boundary
+		cur.Decode(&boundary)
+		candidates = append(candidates, &boundary)
+	}
+
+	cur.Close(boundaries.context.DBContext)
+
+	location := locate(candidates, latitude, longitude)
+	if location == nil {
+		return nil, failures.New(failures.NotFound, "Not found")
+	}
+
+	// Add the names
+	country, err := boundaries.parent.GetByCountryCode(location.CountryCode)
+	if err == nil {
+		location.CountryName = country.CountryName
+		for _, region := range country.Regions {
+			if region.RegionCode == location.RegionCode {
+				location.RegionName = region.RegionName
+			}
+		}
+	}
+
+	return location, nil
+}
+
+// Feature represents a country as a GeoJSON feature with its area in the given tolerance. The
+// properties are the JSON fields without the regions, a country without a boundary has no geometry.
+func (boundaries *Boundaries) Feature(country *Country, tolerance float64) *geometry.Feature {
+	var shape *geometry.Geometry
+
+	area, err := boundaries.GetGeometry(country.CountryCode, "", tolerance)
+	if err == nil {
+		shape = geometry.NewGeometry(area)
+	}
+
+	return geometry.NewFeature(shape, country, "regions")
+}
+
+// GetGeometry retrieves the area of a country, or of a region when a region code is given. The
+// area comes in the coarsest stored simplification within the tolerance, zero is the full area.
+func (boundaries *Boundaries) GetGeometry(countryCode string, regionCode string, tolerance float64) (geometry.MultiPolygon, error) {
+	var result Boundary
+
+	countryParameter, err := datatypes.ISOCountryCode(countryCode, false, false)
+	if err != nil {
+		return nil, failures.New(failures.InvalidArgument, "GetGeometry.CountryCode(%s): %v", countryCode, err)
+	}
+	regionParameter, err := datatypes.ISORegionCode(regionCode, false, true)
+	if err != nil {
+		return nil, failures.New(failures.InvalidArgument, "GetGeometry.RegionCode(%s): %v", regionCode, err)
+	}
+
+	// Only fetch the area that is asked for
+	findOptions := options.FindOne()
+	if simplified := simplifiedIndex(tolerance); simplified >= 0 {
+		findOptions.SetProjection(bson.D{
+			{Key: "iso-country-code", Value: 1},
+			{Key: "iso-region-code", Value: 1},
+			{Key: "simplified", Value: bson.D{{Key: "$elemMatch", Value: bson.D{
+				{Key: "tolerance", Value: Tolerances[simplified]}}}}}})
+	} else {
+		findOptions.SetProjection(bson.D{{Key: "simplified", Value: 0}})
+	}
+
+	err = boundaries.collection.FindOne(boundaries.context.DBContext,
+		bson.D{
+			{Key: "iso-country-code", Value: countryParameter},
+			{Key: "iso-region-code", Value: regionParameter}},
+		findOptions).Decode(&result)
+
+	if err != nil {
+		return nil, failures.Database(err)
+	}
+
+	if len(result.Simplified) > 0 {
+		return result.Simplified[0].Area, nil
+	}
+	if len(result.Area) == 0 {
+		return nil, failures.New(failures.NotFound, "Not found")
+	}
+
+	return result.Area, nil
+}
+
+// simplifiedIndex finds the coarsest tolerance within the requested one, -1 for the full area
+func simplifiedIndex(tolerance float64) int {
+	result := -1
+	for i, stored := range Tolerances {
+		if stored <= tolerance && (result < 0 || stored > Tolerances[result]) {
+			result = i
+		}
+	}
+	return result
+}
+
+// locate picks the country and region containing the position from the candidates
+func locate(candidates []*Boundary, latitude float64, longitude float64) *Location {
+	location := Location{Latitude: latitude, Longitude: longitude}
+
+	for _, boundary := range candidates {
+		if !boundary.Area.Contains(latitude, longitude) {
+			continue
+		}
+		if len(boundary.RegionCode) == 0 {
+			location.CountryCode = boundary.CountryCode
+		} else if len(location.RegionCode) == 0 {
+			location.RegionCode = boundary.RegionCode
+			if len(location.CountryCode) == 0 {
+				location.CountryCode = boundary.CountryCode
+			}
+		}
+	}
+
+	if len(location.CountryCode) == 0 {
+		return nil
+	}
+
+	return &location
+}
+
+// featureCodes finds the country and region codes of a feature. The region codes are in the
+// ISO 3166-2 form (country, dash, region), the region is stored without the country like it is
+// in the regions.
+func featureCodes(feature *geometry.Feature) (string, string, error) {
+	var countryText, regionText string
+	for _, name := range countryProperties {
+		if text := feature.Property(name); len(text) > 0 {
+			countryText = text
+			break
+		}
+	}
+	for _, name := range regionProperties {
+		if text := feature.Property(name); len(text) > 0 {
+			regionText = text
+			break
+		}
+	}
+
+	if len(regionText) > 0 {
+		regionKey := strings.SplitN(regionText, "-", 2)
+		if len(regionKey) != 2 {
+			return "", "", fmt.Errorf("Region(%s): Bad region key", regionText)
+		}
+		countryText = regionKey[0]
+		regionText = regionKey[1]
+	}
+
+	countryCode, err := datatypes.ISOCountryCode(countryText, false, false)
+	if err != nil {
+		return "", "", fmt.Errorf("Country(%s): %v", countryText, err)
+	}
+
+	regionCode, err := datatypes.ISORegionCode(regionText, false, true)
+	if err != nil {
+		return "", "", fmt.Errorf("Region(%s): %v", regionText, err)
+	}
+
+	return countryCode, regionCode, nil
+}
+
+// ImportFile copies a local GeoJSON file into the csv bucket and imports it
+func (boundaries *Boundaries) ImportFile(fileName string) error {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	objectName := "boundaries-" + filepath.Base(fileName)
+
+	// Copy the file to S3
+	s3Client := boundaries.context.S3Client
+	_, err = s3Client.PutObject("csv", objectName, file, -1,
+		minio.PutObjectOptions{ContentType: "application/geo+json"})
+	if err != nil {
+		return err
+	}
+
+	return boundaries.ImportObject(objectName)
+}
+
+// ImportObject imports a GeoJSON feature collection from the csv bucket into the boundaries
+// collection. A feature with a region code is a region boundary, otherwise a country boundary.
+func (boundaries *Boundaries) ImportObject(objectName string) error {
+	s3Client := boundaries.context.S3Client
+	object, err := s3Client.GetObject("csv", objectName,
+		minio.GetObjectOptions{})
+	if err != nil {
+		return err
+	}
+	defer object.Close()
+
+	// Open the logfile
+	_, err = boundaries.context.LogFile("boundaries")
+	if err != nil {
+		return err
+	}
+	defer boundaries.context.LogClose()
+
+	boundaries.context.LogPrintln("Start Import")
+
+	err = boundaries.importGeoJSON(object)
+	if err != nil {
+		return err
+	}
+
+	boundaries.context.LogPrintln("End Import")
+
+	return nil
+}
+
+func (boundaries *Boundaries) importGeoJSON(reader io.Reader) error {
+	var collection geometry.FeatureCollection
+
+	err := json.NewDecoder(reader).Decode(&collection)
+	if err != nil {
+		return err
+	}
+
+	for i, feature := range collection.Features {
+		boundaries.context.LogError(boundaries.importFeature(i, feature))
+	}
+
+	return nil
+}
+
+func (boundaries *Boundaries) importFeature(featureNumber int, feature *geometry.Feature) error {
+	countryCode, regionCode, err := featureCodes(feature)
+	if err != nil {
+		return fmt.Errorf("Boundary[%d].%v", featureNumber, err)
+	}
+
+	area, err := feature.Geometry.MultiPolygon()
+	if err != nil {
+		return fmt.Errorf("Boundary[%d](%s-%s): %v", featureNumber, countryCode, regionCode, err)
+	}
+
+	boundary := Boundary{
+		CountryCode: countryCode,
+		RegionCode:  regionCode,
+		Bounds:      area.Bounds(),
+		Centroid:    area.Centroid(),
+		Area:        area}
+	for _, tolerance := range Tolerances {
+		boundary.Simplified = append(boundary.Simplified,
+			&SimplifiedArea{Tolerance: tolerance, Area: area.Simplify(tolerance)})
+	}
+
+	// Dump in mongo
+	_, err = boundaries.collection.UpdateOne(boundaries.context.DBContext,
+		bson.D{
+			{Key: "iso-country-code", Value: boundary.CountryCode},
+			{Key: "iso-region-code", Value: boundary.RegionCode}},
+		bson.M{"$set": boundary},
+		options.Update().SetUpsert(true))
+
+	if err != nil {
+		return fmt.Errorf("Boundary[%d](%s-%s): %v", featureNumber, countryCode, regionCode, err)
+	}
+
+	// Add centroid and bounding box to the country or region
+	var query bson.D
+	var update bson.M
+	if len(regionCode) == 0 {
+		query = bson.D{{Key: "iso-country-code", Value: countryCode}}
+		update = bson.M{"centroid": boundary.Centroid, "bounds": boundary.Bounds}
+	} else {
+		query = bson.D{
+			{Key: "iso-country-code", Value: countryCode},
+			{Key: "regions.iso-region-code", Value: regionCode}}
+		update = bson.M{"regions.$.centroid": boundary.Centroid, "regions.$.bounds": boundary.Bounds}
+	}
+
+	result, err := boundaries.parent.collection.UpdateOne(boundaries.context.DBContext,
+		query, bson.M{"$set": update})
+	if err != nil {
+		return fmt.Errorf("Boundary[%d](%s-%s): %v", featureNumber, countryCode, regionCode, err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("Boundary[%d](%s-%s): Country or region not found", featureNumber, countryCode, regionCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package countries
+
+import (
+	"testing"
+
+	"../geometry"
+)
+
+func TestFeatureCodes(t *testing.T) {
+	var tests = []struct {
+		properties  map[string]interface{}
+		countryCode string
+		regionCode  string
+		correct     bool
+	}{
+		{map[string]interface{}{"iso-country-code": "nl"}, "NL", "", true},                // own format
+		{map[string]interface{}{"ISO_A2": "NL", "NAME": "Netherlands"}, "NL", "", true},   // admin-0
+		{map[string]interface{}{"iso_a2": "NL", "iso_3166_2": "NL-NH"}, "NL", "NH", true}, // admin-1
+		{map[string]interface{}{"iso-region-code": "US-CA"}, "US", "CA", true},            // country from the region
+		{map[string]interface{}{"ISO_A2": "-99", "NAME": "Somaliland"}, "", "", false},    // no code assigned
+		{map[string]interface{}{"iso_a2": "NL", "iso_3166_2": "NH"}, "", "", false},       // bad region key
+		{map[string]interface{}{"NAME": "Atlantis"}, "", "", false},                       // no code at all
+	}
+
+	for _, test := range tests {
+		countryCode, regionCode, err := featureCodes(&geometry.Feature{Properties: test.properties})
+		if (test.correct && err != nil) || (!test.correct && err == nil) {
+			t.Errorf("featureCodes(%v) expected %t, got %t (%v)", test.properties, test.correct, (err == nil), err)
+			continue
+		}
+		if countryCode != test.countryCode || regionCode != test.regionCode {
+			t.Errorf("featureCodes(%v) expected %s-%s, got %s-%s", test.properties,
+				test.countryCode, test.regionCode, countryCode, regionCode)
+		}
+	}
+}
+
+func TestLocate(t *testing.T) {
+	square := func(minLongitude float64, minLatitude float64, maxLongitude float64, maxLatitude float64) geometry.MultiPolygon {
+		return geometry.MultiPolygon{geometry.Polygon{geometry.Ring{
+			{minLongitude, minLatitude}, {maxLongitude, minLatitude}, {maxLongitude, maxLatitude},
+			{minLongitude, maxLatitude}, {minLongitude, minLatitude}}}}
+	}
+
+	candidates := []*Boundary{
+		{CountryCode: "NL", Area: square(3, 50, 7, 54)},
+		{CountryCode: "NL", RegionCode: "NH", Area: square(4, 52, 5, 53)},
+		{CountryCode: "BE", Area: square(2, 49, 6, 50)},
+		{CountryCode: "BE", RegionCode: "VLG", Area: square(2, 49.5, 6, 50)},
+	}
+
+	var tests = []struct {
+		latitude    float64
+		longitude   float64
+		countryCode string
+		regionCode  string
+		found       bool
+	}{
+		{52.3086, 4.7639, "NL", "NH", true}, // country and region
+		{51.0, 6.0, "NL", "", true},         // country only
+		{49.7, 3.0, "BE", "VLG", true},      // another country
+		{45.0, 0.0, "", "", false},          // nowhere
+	}
+
+	for _, test := range tests {
+		location := locate(candidates, test.latitude, test.longitude)
+		if (location != nil) != test.found {
+			t.Errorf("locate(%f, %f) expected found %t, got %v", test.latitude, test.longitude, test.found, location)
+			continue
+		}
+		if location != nil && (location.CountryCode != test.countryCode || location.RegionCode != test.regionCode) {
+			t.Errorf("locate(%f, %f) expected %s-%s, got %s-%s", test.latitude, test.longitude,
+				test.countryCode, test.regionCode, location.CountryCode, location.RegionCode)
+		}
+	}
+}
+
+func TestSimplifiedIndex(t *testing.T) {
+	var tests = []struct {
+		tolerance float64
+		result    int
+	}{
+		{0, -1},      // full
+		{0.0005, -1}, // finer than anything stored
+		{0.001, 0},
+		{0.05, 1}, // coarsest within
+		{SimplifiedTolerance, 1},
+		{1, 2},
+	}
+
+	for _, test := range tests {
+		result := simplifiedIndex(test.tolerance)
+		if result != test.result {
+			t.Errorf("simplifiedIndex(%f) expected %d, got %d", test.tolerance, test.result, result)
+		}
+	}
+}
@@ -16,6 +16,9 @@ import (
 
 	"../application"
 	"../datatypes"
+	"../failures"
+	"../geometry"
+	"../paging"
 )
 
 // Countries implements the datamodel for countries
@@ -34,9 +37,17 @@ type Country struct {
 	CountryName string             `bson:"country-name" json:"country-name"`
 	Continent   string             `bson:"continent" json:"continent"`
 	Wikipedia   string             `bson:"wikipedia" json:"wikipedia,omitempty"`
+	Centroid    *geometry.Position `bson:"centroid,omitempty" json:"centroid,omitempty"`
+	Bounds      *geometry.Box      `bson:"bounds,omitempty" json:"bounds,omitempty"`
 	Regions     []*Region          `bson:"regions" json:"regions,omitempty"`
 }
 
+// Fields are the fields a list of countries can be limited to
+var Fields = []string{"iso-country-code", "country-name", "continent", "wikipedia", "centroid", "bounds", "regions"}
+
+// SortFields are the fields a list of countries can be sorted on, each is indexed with the code
+var SortFields = []string{"iso-country-code", "country-name", "continent"}
+
 // NewCountries instantiates the connection to the database collection
 func NewCountries(application *application.Context) *Countries {
 	countries := Countries{context: application}
@@ -45,6 +56,10 @@ func NewCountries(application *application.Context) *Countries {
 	countries.collection = application.DBClient.Database("flight-schedule").Collection("countries")
 	countryIndex := mongo.IndexModel{Keys: bson.M{"iso-country-code": 1}}
 	countries.collection.Indexes().CreateOne(application.DBContext, countryIndex)
+	for _, field := range SortFields[1:] {
+		sortIndex := mongo.IndexModel{Keys: bson.D{{Key: field, Value: 1}, {Key: "iso-country-code", Value: 1}}}
+		countries.collection.Indexes().CreateOne(application.DBContext, sortIndex)
+	}
 
 	return &countries
 }
@@ -55,14 +70,14 @@ func (countries *Countries) GetByCountryCode(countryCode string) (*Country, erro
 
 	countryCode, err := datatypes.ISOCountryCode(countryCode, false, false)
 	if err != nil {
-		return nil, err
+		return nil, failures.Wrap(failures.InvalidArgument, err)
 	}
 
 	err = countries.collection.FindOne(countries.context.DBContext,
 		bson.D{{Key: "iso-country-code", Value: countryCode}}).Decode(&result)
 
 	if err != nil {
-		return nil, fmt.Errorf("Not found")
+		return nil, failures.Database(err)
 	}
 
 	return &result, nil
@@ -71,24 +86,10 @@ func (countries *Countries) GetByCountryCode(countryCode string) (*Country, erro
 // GetList retrieves a list of countries [fromCountryCode .. untilCountryCode].
 func (countries *Countries) GetList(fromCountryCode string, untilCountryCode string) ([]*Country, error) {
 	var result []*Country
-	var query = bson.D{{}}
-
-	fromCountryCode, err := datatypes.ISOCountryCode(fromCountryCode, true, true)
-	if err != nil {
-		return nil, fmt.Errorf("fromCountry(%s): %v", fromCountryCode, err)
-	}
-	if len(fromCountryCode) != 0 {
-		query = append(query, bson.E{Key: "iso-country-code",
-			Value: bson.D{{Key: "$gte", Value: fromCountryCode}}})
-	}
 
-	untilCountryCode, err = datatypes.ISOCountryCode(untilCountryCode, true, true)
+	query, err := listQuery(fromCountryCode, untilCountryCode)
 	if err != nil {
-		return nil, fmt.Errorf("untilCountry(%s): %v", untilCountryCode, err)
-	}
-	if len(untilCountryCode) != 0 {
-		query = append(query, bson.E{Key: "iso-country-code",
-			Value: bson.D{{Key: "$lte", Value: untilCountryCode}}})
+		return nil, err
 	}
 
 	findOptions := options.Find()
@@ -96,7 +97,7 @@ func (countries *Countries) GetList(fromCountryCode string, untilCountryCode str
 
 	cur, err := countries.collection.Find(countries.context.DBContext, query, findOptions)
 	if err != nil {
-		return nil, fmt.Errorf("Not found")
+		return nil, failures.Database(err)
 	}
 
 	for cur.Next(countries.context.DBContext) {
@@ -108,16 +109,72 @@ func (countries *Countries) GetList(fromCountryCode string, untilCountryCode str
 	cur.Close(countries.context.DBContext)
 
 	if int64(len(result)) > countries.context.MaxResults {
-		return nil, fmt.Errorf("Too many results")
+		return nil, failures.New(failures.TooLarge, "Too many results")
 	}
 
 	if len(result) == 0 {
-		return nil, fmt.Errorf("Not found")
+		return nil, failures.New(failures.NotFound, "Not found")
 	}
 
 	return result, nil
 }
 
+// GetPage retrieves a page of the list GetList retrieves, in order of the country code unless the
+// request sorts it. Only the fields of the request are read from the database. Only the first
+// page of an empty list is Not found, paging past the end gives an empty page.
+func (countries *Countries) GetPage(fromCountryCode string, untilCountryCode string,
+	request *paging.Request) ([]*Country, *paging.Page, error) {
+
+	var result []*Country
+
+	query, err := listQuery(fromCountryCode, untilCountryCode)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	page, err := paging.Find(countries.context.DBContext, countries.collection, query, "iso-country-code", request,
+		func(document bson.Raw) error {
+			var country Country
+			err := bson.Unmarshal(document, &country)
+			result = append(result, &country)
+			return err
+		})
+	if err != nil {
+		return nil, nil, failures.Database(err)
+	}
+
+	if len(result) == 0 && request.First() {
+		return nil, nil, failures.New(failures.NotFound, "Not found")
+	}
+
+	return result, page, nil
+}
+
+// listQuery builds the query for a list of Countries from the filter arguments
+func listQuery(fromCountryCode string, untilCountryCode string) (bson.D, error) {
+	var query = bson.D{{}}
+
+	fromCountryCode, err := datatypes.ISOCountryCode(fromCountryCode, true, true)
+	if err != nil {
+		return nil, failures.New(failures.InvalidArgument, "fromCountry(%s): %v", fromCountryCode, err)
+	}
+	if len(fromCountryCode) != 0 {
+		query = append(query, bson.E{Key: "iso-country-code",
+			Value: bson.D{{Key: "$gte", Value: fromCountryCode}}})
+	}
+
+	untilCountryCode, err = datatypes.ISOCountryCode(untilCountryCode, true, true)
+	if err != nil {
+		return nil, failures.New(failures.InvalidArgument, "untilCountry(%s): %v", untilCountryCode, err)
+	}
+	if len(untilCountryCode) != 0 {
+		query = append(query, bson.E{Key: "iso-country-code",
+			Value: bson.D{{Key: "$lte", Value: untilCountryCode}}})
+	}
+
+	return query, nil
+}
+
 // RetrieveFromURL downloads the file into the etc directory
 func (countries *Countries) RetrieveFromURL() error {
 	// Get the data
@@ -0,0 +1,32 @@
+package countries
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+
+	"../datatypes"
+	"../failures"
+	"../paging"
+)
+
+// exportBatchSize is the number of countries read from the database at a time, which bounds the
+// memory an export takes
+const exportBatchSize = 100
+
+// Export reads every country from a country code on, in order of the code, without the limit of
+// a list. The countries are handed to each one by one, an error of each ends the export.
+func (countries *Countries) Export(fromCountryCode string, each func(*Country) error) error {
+	parameter, err := datatypes.ISOCountryCode(fromCountryCode, false, true)
+	if err != nil {
+		return failures.New(failures.InvalidArgument, "Export.CountryCode(%s): %v", fromCountryCode, err)
+	}
+
+	return paging.Export(countries.context.DBContext, countries.collection, "iso-country-code", parameter,
+		exportBatchSize, func(document bson.Raw) error {
+			var country Country
+			err := bson.Unmarshal(document, &country)
+			if err != nil {
+				return failures.Database(err)
+			}
+			return each(&country)
+		})
+}
@@ -13,6 +13,7 @@ import (
 
 	"../application"
 	"../datatypes"
+	"../geometry"
 )
 
 // regions implements the regions data
@@ -26,9 +27,11 @@ type Regions struct {
 // Region is the external representation for an ISO-Region including both a bson (for mongo)
 // and a json (for REST/GRAPHQL) representation
 type Region struct {
-	RegionCode string `bson:"iso-region-code" json:"iso-region-code"`
-	RegionName string `bson:"region-name" json:"region-name"`
-	Wikipedia  string `bson:"wikipedia" json:"wikipedia,omitempty"`
+	RegionCode string             `bson:"iso-region-code" json:"iso-region-code"`
+	RegionName string             `bson:"region-name" json:"region-name"`
+	Wikipedia  string             `bson:"wikipedia" json:"wikipedia,omitempty"`
+	Centroid   *geometry.Position `bson:"centroid,omitempty" json:"centroid,omitempty"`
+	Bounds     *geometry.Box      `bson:"bounds,omitempty" json:"bounds,omitempty"`
 }
 
 // NewRegions establishes the connection to the database
@@ -86,6 +89,9 @@ func (regions *Regions) importCSVLine(line []string, lineNumber int) error {
 	found := false
 	for i := range country.Regions {
 		if country.Regions[i].RegionCode == region.RegionCode {
+			// Keep what is derived from the boundaries
+			region.Centroid = country.Regions[i].Centroid
+			region.Bounds = country.Regions[i].Bounds
 			country.Regions[i] = &region
 			found = true
 			break
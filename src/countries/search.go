@@ -0,0 +1,77 @@
+package countries
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"../failures"
+	"../search"
+)
+
+// The boosts of countries and regions in a search, a country is found before the places in it
+const (
+	CountryBoost = 1.0
+	RegionBoost  = 0.5
+)
+
+// SearchEntries retrieves the countries and their regions as entries of the search index
+func (countries *Countries) SearchEntries() ([]*search.Entry, error) {
+	var result []*search.Entry
+
+	findOptions := options.Find().SetProjection(bson.D{
+		{Key: "iso-country-code", Value: 1},
+		{Key: "country-name", Value: 1},
+		{Key: "regions.iso-region-code", Value: 1},
+		{Key: "regions.region-name", Value: 1},
+	})
+	cur, err := countries.collection.Find(countries.context.DBContext, bson.D{}, findOptions)
+	if err != nil {
+		return nil, failures.Database(err)
+	}
+	defer cur.Close(countries.context.DBContext)
+
+	for cur.Next(countries.context.DBContext) {
+		var country Country
+		err = cur.Decode(&country)
+		if err != nil {
+			return nil, failures.Database(err)
+		}
+		result = append(result, searchEntries(&country)...)
+	}
+
+	if cur.Err() != nil {
+		return nil, failures.Database(cur.Err())
+	}
+	return result, nil
+}
+
+// searchEntries makes the entries of a country and its regions, a region is found by the name of
+// its country as well
+func searchEntries(country *Country) []*search.Entry {
+	result := []*search.Entry{{
+		Kind:        search.Country,
+		Code:        country.CountryCode,
+		CountryCode: country.CountryCode,
+		Name:        country.CountryName,
+		Texts: []search.Text{
+			{Value: country.CountryCode, Weight: search.CodeWeight},
+			{Value: country.CountryName, Weight: search.NameWeight},
+		},
+		Boost: CountryBoost,
+	}}
+
+	for _, region := range country.Regions {
+		result = append(result, &search.Entry{
+			Kind:        search.Region,
+			Code:        region.RegionCode,
+			CountryCode: country.CountryCode,
+			Name:        region.RegionName,
+			Texts: []search.Text{
+				{Value: region.RegionName, Weight: search.NameWeight},
+				{Value: country.CountryName, Weight: search.ParentWeight},
+			},
+			Boost: RegionBoost,
+		})
+	}
+	return result
+}
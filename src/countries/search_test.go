@@ -0,0 +1,24 @@
+package countries
+
+import (
+	"testing"
+
+	"../search"
+)
+
+func TestSearchEntries(t *testing.T) {
+	entries := searchEntries(&Country{CountryCode: "BR", CountryName: "Brazil",
+		Regions: []*Region{{RegionCode: "SP", RegionName: "São Paulo"}, {RegionCode: "RJ", RegionName: "Rio de Janeiro"}}})
+
+	if len(entries) != 3 || entries[0].Kind != search.Country || entries[0].Code != "BR" {
+		t.Fatalf("searchEntries expected the country and its regions, got %d", len(entries))
+	}
+	if entries[1].Kind != search.Region || entries[1].Code != "SP" || entries[1].CountryCode != "BR" {
+		t.Errorf("searchEntries expected the region with its country, got %+v", entries[1])
+	}
+
+	results := search.NewIndex(entries).Search("sao paulo", nil, 10)
+	if len(results) != 1 || results[0].Code != "SP" {
+		t.Errorf("Search expected the region, got %v", results)
+	}
+}
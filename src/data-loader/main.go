@@ -5,6 +5,20 @@ package main
 //
 // Files are retrieved from ourairports.com/data/xx.csv
 //
+// Weather reports are loaded separately with "data-loader weather <source>..", where a
+// source is either a local file or "s3:<object>" for an object in the csv bucket.
+//
+// Country and region boundaries are loaded with "data-loader boundaries <source>..", from GeoJSON
+// files (the Natural Earth admin-0 and admin-1 codes are recognized) copied to the csv bucket first.
+// When present they are used to cross-check the country and region of the airports on import.
+//
+// Airports get their time zone on import from the boundaries in the "timezone-boundaries"
+// option (same file or s3 notation); "data-loader timezones" only reassigns the time zones.
+// The boundaries are not bundled, data/README.md tells where they come from.
+//
+// Airports are exported to KML (or KMZ, by the file extension) with "data-loader export <file>
+// [country=<code>] [region=<code>] [bbox=<min-lon,min-lat,max-lon,max-lat>] [airports=<code,..>]".
+//
 // Note: it is written quite sloppily:
 // - file names and database connection are hard-coded
 // - error logging is not implemented
@@ -12,12 +26,128 @@ package main
 import (
 	"fmt"
 	"log"
+	"os"
+	"strings"
 
 	"../airports"
 	"../application"
 	"../countries"
+	"../timezones"
+	"../versions"
+	"../weather"
 )
 
+// bumpVersions marks datasets as changed, so derived data like tiles is refreshed
+func bumpVersions(context *application.Context, datasets ...string) {
+	theVersions := versions.NewVersions(context)
+	for _, dataset := range datasets {
+		err := theVersions.Bump(dataset)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+func loadWeather(context *application.Context, sources []string) {
+	reports := weather.NewReports(context)
+
+	for _, source := range sources {
+		fmt.Printf("Loading weather from %s..\n", source)
+
+		var err error
+		if strings.HasPrefix(source, "s3:") {
+			err = reports.ImportObject(strings.TrimPrefix(source, "s3:"))
+		} else {
+			err = reports.ImportFile(source)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	bumpVersions(context, versions.Weather)
+	fmt.Println("Weather loaded.")
+}
+
+func loadBoundaries(context *application.Context, sources []string) {
+	boundaries := countries.NewCountries(context).NewBoundaries()
+
+	for _, source := range sources {
+		fmt.Printf("Loading boundaries from %s..\n", source)
+
+		var err error
+		if strings.HasPrefix(source, "s3:") {
+			err = boundaries.ImportObject(strings.TrimPrefix(source, "s3:"))
+		} else {
+			err = boundaries.ImportFile(source)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	bumpVersions(context, versions.Boundaries, versions.Countries)
+	fmt.Println("Boundaries loaded.")
+}
+
+func loadTimeZones(context *application.Context, airports *airports.Airports) {
+	if len(context.TimeZones) == 0 {
+		fmt.Println("No time zone boundaries, skipping time zones.")
+		return
+	}
+
+	fmt.Printf("Loading time zones from %s..\n", context.TimeZones)
+	zones, err := timezones.LoadZones(context, context.TimeZones)
+	if err != nil {
+		log.Fatal(err)
+	}
+	airports.SetTimeZones(zones)
+}
+
+func exportAirports(context *application.Context, arguments []string) {
+	if len(arguments) == 0 {
+		log.Fatal("Usage: data-loader export <file.kml|file.kmz> [country=..] [region=..] [bbox=..] [airports=..]")
+	}
+
+	criteria := map[string]string{}
+	for _, argument := range arguments[1:] {
+		parts := strings.SplitN(argument, "=", 2)
+		if len(parts) != 2 {
+			log.Fatalf("Invalid argument %s, use name=value", argument)
+		}
+		criteria[parts[0]] = parts[1]
+	}
+
+	selection, err := airports.NewSelection(criteria["country"], criteria["region"], criteria["bbox"], criteria["airports"])
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	file, err := os.Create(arguments[0])
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
+
+	fmt.Printf("Exporting airports to %s..\n", arguments[0])
+	count := 0
+	writer := airports.NewKMLWriter(file, "Airports", strings.HasSuffix(strings.ToLower(arguments[0]), ".kmz"))
+	err = airports.NewAirports(context, countries.NewCountries(context)).Select(selection,
+		func(airport *airports.Airport) error {
+			count++
+			return writer.Write(airport)
+		})
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = writer.Close()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("%d airports exported.\n", count)
+}
+
 func main() {
 
 	fmt.Println("Initializing..")
@@ -26,6 +156,33 @@ func main() {
 		log.Fatal(err)
 	}
 
+	if len(os.Args) > 1 && os.Args[1] == "weather" {
+		loadWeather(context, os.Args[2:])
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "boundaries" {
+		loadBoundaries(context, os.Args[2:])
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "export" {
+		exportAirports(context, os.Args[2:])
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "timezones" {
+		airports := airports.NewAirports(context, countries.NewCountries(context))
+		loadTimeZones(context, airports)
+		err = airports.AssignTimeZones()
+		if err != nil {
+			log.Fatal(err)
+		}
+		bumpVersions(context, versions.Airports)
+		fmt.Println("Time zones assigned.")
+		return
+	}
+
 	fmt.Println("Loading countries..")
 	countries := countries.NewCountries(context)
 	err = countries.RetrieveFromURL()
@@ -50,6 +207,8 @@ func main() {
 
 	fmt.Println("Loading airports..")
 	airports := airports.NewAirports(context, countries)
+	airports.SetBoundaries(countries.NewBoundaries())
+	loadTimeZones(context, airports)
 	err = airports.RetrieveFromURL()
 	if err != nil {
 		log.Fatal(err)
@@ -81,5 +240,6 @@ func main() {
 		log.Fatal(err)
 	}
 
+	bumpVersions(context, versions.Countries, versions.Airports)
 	fmt.Println("Data loaded.")
 }
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 	"unicode"
 )
 
@@ -380,3 +381,95 @@ func Frequency(s string, empty bool) (float64, error) {
 	return frequency, nil
 
 }
+
+// WindDirection converts a string to a valid wind direction in degrees
+func WindDirection(s string, empty bool) (int, error) {
+	// Clean up string
+	text := strings.TrimSpace(s)
+	if len(text) == 0 {
+		if !empty {
+			return 0, fmt.Errorf("Invalid Wind Direction")
+		}
+		return 0, nil
+	}
+
+	// Extract number
+	value, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid Wind Direction")
+	}
+
+	// Direction must be between 0 and 360 inclusive
+	direction := int(value)
+	if direction < 0 || direction > 360 {
+		return 0, fmt.Errorf("Invalid Wind Direction")
+	}
+
+	return direction, nil
+}
+
+// WindSpeed converts a string to a valid wind speed in knots
+func WindSpeed(s string, empty bool) (int, error) {
+	// Clean up string
+	text := strings.TrimSpace(s)
+	if len(text) == 0 {
+		if !empty {
+			return 0, fmt.Errorf("Invalid Wind Speed")
+		}
+		return 0, nil
+	}
+
+	// Extract number
+	value, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid Wind Speed")
+	}
+
+	// Must be between 0 and 250kt (well beyond anything on the surface)
+	speed := int(value)
+	if speed < 0 || speed > 250 {
+		return 0, fmt.Errorf("Invalid Wind Speed")
+	}
+
+	return speed, nil
+}
+
+// Date converts a string (yyyy-mm-dd) to a valid date, midnight UTC
+func Date(s string, empty bool) (time.Time, error) {
+	// Clean up string
+	text := strings.TrimSpace(s)
+	if len(text) == 0 {
+		if !empty {
+			return time.Time{}, fmt.Errorf("Invalid Date")
+		}
+		return time.Time{}, nil
+	}
+
+	// Extract date
+	date, err := time.Parse("2006-01-02", text)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Invalid Date")
+	}
+
+	return date, nil
+}
+
+// DateTime converts a string (RFC 3339, yyyy-mm-ddThh:mm:ssZ or with an offset) to a moment
+func DateTime(s string, empty bool) (time.Time, error) {
+	// Clean up string
+	text := strings.TrimSpace(s)
+	if len(text) == 0 {
+		if !empty {
+			return time.Time{}, fmt.Errorf("Invalid DateTime")
+		}
+		return time.Time{}, nil
+	}
+
+	// Extract moment
+	moment, err := time.Parse(time.RFC3339, text)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Invalid DateTime")
+	}
+
+	return moment, nil
+}
@@ -1,6 +1,9 @@
 package datatypes
 
-import "testing"
+import (
+	"testing"
+	"time"
+)
 
 func TestISOCountryCode(t *testing.T) {
 	var tests = []struct {
@@ -457,3 +460,115 @@ func TestElevation(t *testing.T) {
 		}
 	}
 }
+
+func TestWindDirection(t *testing.T) {
+	var tests = []struct {
+		value   string
+		empty   bool
+		result  int
+		correct bool
+	}{
+		{"", false, 0, false},       // too short
+		{"", true, 0, true},         // too short, but optional
+		{"N", false, 0, false},      // wrong char class
+		{"N", true, 0, false},       // Wrong doesn't count as empty
+		{"0", false, 0, true},       // 0deg works
+		{"270", false, 270, true},   // 270deg works
+		{" 360 ", false, 360, true}, // 360deg works, spaces are killed
+		{"361", false, 0, false},    // 361deg is too big
+		{"-1", false, 0, false},     // -1deg is too small
+	}
+
+	for _, test := range tests {
+		result, err := WindDirection(test.value, test.empty)
+		if (test.correct && err != nil) || (!test.correct && err == nil) {
+			t.Errorf("WindDirection(%s) expected %t, got %t", test.value, test.correct, (err == nil))
+		}
+		if test.result != result {
+			t.Errorf("WindDirection(%s) expected %d, got %d", test.value, test.result, result)
+		}
+	}
+}
+
+func TestWindSpeed(t *testing.T) {
+	var tests = []struct {
+		value   string
+		empty   bool
+		result  int
+		correct bool
+	}{
+		{"", false, 0, false},       // too short
+		{"", true, 0, true},         // too short, but optional
+		{"N", false, 0, false},      // wrong char class
+		{"0", false, 0, true},       // calm works
+		{"15", false, 15, true},     // 15kt works
+		{" 250 ", false, 250, true}, // 250kt works, spaces are killed
+		{"251", false, 0, false},    // 251kt is too much
+		{"-1", false, 0, false},     // negative speeds don't exist
+	}
+
+	for _, test := range tests {
+		result, err := WindSpeed(test.value, test.empty)
+		if (test.correct && err != nil) || (!test.correct && err == nil) {
+			t.Errorf("WindSpeed(%s) expected %t, got %t", test.value, test.correct, (err == nil))
+		}
+		if test.result != result {
+			t.Errorf("WindSpeed(%s) expected %d, got %d", test.value, test.result, result)
+		}
+	}
+}
+
+func TestDate(t *testing.T) {
+	var tests = []struct {
+		value   string
+		empty   bool
+		result  time.Time
+		correct bool
+	}{
+		{"", false, time.Time{}, false}, // too short
+		{"", true, time.Time{}, true},   // too short, but optional
+		{"N", true, time.Time{}, false}, // Wrong doesn't count as empty
+		{"2020-03-14", false, time.Date(2020, time.March, 14, 0, 0, 0, 0, time.UTC), true},   // perfect
+		{" 2020-03-14 ", false, time.Date(2020, time.March, 14, 0, 0, 0, 0, time.UTC), true}, // spaces are killed
+		{"2020-02-30", false, time.Time{}, false},                                            // no such day
+		{"14-03-2020", false, time.Time{}, false},                                            // wrong order
+	}
+
+	for _, test := range tests {
+		result, err := Date(test.value, test.empty)
+		if (test.correct && err != nil) || (!test.correct && err == nil) {
+			t.Errorf("Date(%s) expected %t, got %t", test.value, test.correct, (err == nil))
+		}
+		if !test.result.Equal(result) {
+			t.Errorf("Date(%s) expected %v, got %v", test.value, test.result, result)
+		}
+	}
+}
+
+func TestDateTime(t *testing.T) {
+	var tests = []struct {
+		value   string
+		empty   bool
+		result  time.Time
+		correct bool
+	}{
+		{"", false, time.Time{}, false}, // too short
+		{"", true, time.Time{}, true},   // too short, but optional
+		{"N", true, time.Time{}, false}, // Wrong doesn't count as empty
+		{"2020-03-14T12:30:00Z", false, time.Date(2020, time.March, 14, 12, 30, 0, 0, time.UTC), true},      // perfect
+		{" 2020-03-14T12:30:00Z ", false, time.Date(2020, time.March, 14, 12, 30, 0, 0, time.UTC), true},    // spaces are killed
+		{"2020-03-14T13:30:00+01:00", false, time.Date(2020, time.March, 14, 12, 30, 0, 0, time.UTC), true}, // with an offset
+		{"2020-03-14T12:30:00", false, time.Time{}, false},                                                  // no zone
+		{"2020-03-14", false, time.Time{}, false},                                                           // no time
+	}
+
+	for _, test := range tests {
+		result, err := DateTime(test.value, test.empty)
+		if (test.correct && err != nil) || (!test.correct && err == nil) {
+			t.Errorf("DateTime(%s) expected %t, got %t", test.value, test.correct, (err == nil))
+		}
+		if !test.result.Equal(result) {
+			t.Errorf("DateTime(%s) expected %v, got %v", test.value, test.result, result)
+		}
+	}
+}
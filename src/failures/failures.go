@@ -0,0 +1,83 @@
+package failures
+
+import (
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Failures sort the errors of the datasets by what went wrong, so that the REST and GraphQL
+// interfaces report the same failure the same way. An error without a kind is an internal error.
+
+// Kind is what went wrong, the text is the code clients see
+type Kind string
+
+// The kinds
+const (
+	NotFound        Kind = "NOT_FOUND"
+	InvalidArgument Kind = "INVALID_ARGUMENT"
+	TooLarge        Kind = "RESULT_TOO_LARGE"
+	Unavailable     Kind = "UPSTREAM_UNAVAILABLE"
+	Internal        Kind = "INTERNAL"
+)
+
+// Error is an error of a kind, with the error that caused it if any
+type Error struct {
+	Kind    Kind
+	Message string
+	Err     error
+}
+
+func (err *Error) Error() string {
+	return err.Message
+}
+
+// Unwrap returns the cause of the error
+func (err *Error) Unwrap() error {
+	return err.Err
+}
+
+// New makes an error of a kind
+func New(kind Kind, format string, args ...interface{}) error {
+	return &Error{Kind: kind, Message: fmt.Sprintf(format, args...)}
+}
+
+// Wrap gives an error a kind, keeping its message
+func Wrap(kind Kind, err error) error {
+	return &Error{Kind: kind, Message: err.Error(), Err: err}
+}
+
+// Database sorts the error of a database operation: no document is not found, anything else
+// means the database can not be used right now. An error that is sorted already stays as it is.
+func Database(err error) error {
+	var failure *Error
+	if errors.As(err, &failure) {
+		return err
+	}
+	if err == mongo.ErrNoDocuments {
+		return &Error{Kind: NotFound, Message: "Not found", Err: err}
+	}
+	return &Error{Kind: Unavailable, Message: fmt.Sprintf("Database: %v", err), Err: err}
+}
+
+// KindOf finds the kind of an error, also when it is wrapped
+func KindOf(err error) Kind {
+	var failure *Error
+	if errors.As(err, &failure) {
+		return failure.Kind
+	}
+	return Internal
+}
+
+// Detail is the message of an error that clients see. The messages of internal errors and of an
+// unavailable database tell about the server itself, those are only for its log.
+func Detail(err error) string {
+	switch KindOf(err) {
+	case Internal:
+		return "The request could not be processed"
+	case Unavailable:
+		return "The database is not available, try again later"
+	}
+	return err.Error()
+}
@@ -0,0 +1,58 @@
+package failures
+
+import (
+	"fmt"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestKindOf(t *testing.T) {
+	var tests = []struct {
+		err  error
+		kind Kind
+	}{
+		{New(NotFound, "Not found"), NotFound},
+		{New(InvalidArgument, "AirportCode(%s): Invalid", "XX"), InvalidArgument},
+		{fmt.Errorf("Airports: %w", New(TooLarge, "Too many results")), TooLarge},
+		{Wrap(InvalidArgument, fmt.Errorf("Invalid ICAO Airport Code")), InvalidArgument},
+		{Database(mongo.ErrNoDocuments), NotFound},
+		{Database(fmt.Errorf("connection refused")), Unavailable},
+		{Database(New(InvalidArgument, "Invalid Cursor")), InvalidArgument},
+		{fmt.Errorf("Something else"), Internal},
+	}
+
+	for _, test := range tests {
+		if kind := KindOf(test.err); kind != test.kind {
+			t.Errorf("KindOf(%v) expected %s, got %s", test.err, test.kind, kind)
+		}
+	}
+}
+
+func TestMessage(t *testing.T) {
+	err := New(InvalidArgument, "GetList.CountryCode(%s): %v", "NLD", "Invalid ISO Country Code")
+	if err.Error() != "GetList.CountryCode(NLD): Invalid ISO Country Code" {
+		t.Errorf("New expected the formatted message, got %s", err.Error())
+	}
+	if Database(fmt.Errorf("timeout")).Error() != "Database: timeout" {
+		t.Errorf("Database expected the cause in the message")
+	}
+}
+
+func TestDetail(t *testing.T) {
+	var tests = []struct {
+		err    error
+		detail string
+	}{
+		{New(NotFound, "Not found"), "Not found"},
+		{fmt.Errorf("Airports: %w", New(TooLarge, "Too many results")), "Airports: Too many results"},
+		{Database(fmt.Errorf("connection refused")), "The database is not available, try again later"},
+		{fmt.Errorf("runtime error: index out of range"), "The request could not be processed"},
+	}
+
+	for _, test := range tests {
+		if detail := Detail(test.err); detail != test.detail {
+			t.Errorf("Detail(%v) expected %s, got %s", test.err, test.detail, detail)
+		}
+	}
+}
@@ -0,0 +1,368 @@
+package filters
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"../failures"
+)
+
+// Filters select the items of a list with an expression in the way of RSQL/FIQL, like
+//
+//	airport-type==large_airport;elevation=gt=5000;municipality=like=*berg*
+//
+// A constraint compares a field with a value. Constraints are combined with ; (and), which binds
+// stronger, and , (or), and grouped with parentheses. The operators are == and != (a
+// value with a * is a pattern), =lt= =le= =gt= =ge= (or < <= > >=), =in= and =out= with a list
+// of values like (small_airport,heliport), and =like= for a pattern that ignores case. A value
+// with reserved characters is quoted with ' or ". The expression is turned into a query of the
+// database, only the fields a list allows can be filtered on.
+
+// Kind is the type of the values of a field
+type Kind int
+
+// The kinds
+const (
+	String Kind = iota
+	Number
+	Boolean
+)
+
+// Fields are the fields that can be filtered on, with their kind
+type Fields map[string]Kind
+
+// operators are the operators of the constraints, their database operator and the kinds they apply to
+var operators = map[string]struct {
+	operator string
+	kinds    []Kind
+}{
+	"==":     {"$eq", []Kind{String, Number, Boolean}},
+	"!=":     {"$ne", []Kind{String, Number, Boolean}},
+	"=lt=":   {"$lt", []Kind{String, Number}},
+	"=le=":   {"$lte", []Kind{String, Number}},
+	"=gt=":   {"$gt", []Kind{String, Number}},
+	"=ge=":   {"$gte", []Kind{String, Number}},
+	"<":      {"$lt", []Kind{String, Number}},
+	"<=":     {"$lte", []Kind{String, Number}},
+	">":      {"$gt", []Kind{String, Number}},
+	">=":     {"$gte", []Kind{String, Number}},
+	"=in=":   {"$in", []Kind{String, Number, Boolean}},
+	"=out=":  {"$nin", []Kind{String, Number, Boolean}},
+	"=like=": {"$regex", []Kind{String}},
+}
+
+// namedOperator is an operator like =gt=
+var namedOperator = regexp.MustCompile(`^=[a-z]+=`)
+
+// reserved are the characters that end an unquoted value or field
+const reserved = "\"'();,=!<> \t\r\n"
+
+// parser reads an expression from the start of its input
+type parser struct {
+	expression string
+	position   int
+	fields     Fields
+}
+
+// token is a part of the expression and where it starts
+type token struct {
+	text     string
+	position int
+}
+
+// Parse turns a filter expression into a query, the empty expression has no query
+func Parse(expression string, fields Fields) (bson.D, error) {
+	if len(strings.TrimSpace(expression)) == 0 {
+		return nil, nil
+	}
+
+	p := parser{expression: expression, fields: fields}
+	query, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if !p.end() {
+		return nil, p.fail(p.next(), "expected ; or , between constraints")
+	}
+	return query, nil
+}
+
+// fail makes the error of a token, the position counts characters from 1
+func (p *parser) fail(at token, format string, args ...interface{}) error {
+	text := at.text
+	if len(text) == 0 {
+		text = "end"
+	}
+	args = append([]interface{}{text, at.position + 1}, args...)
+	return failures.New(failures.InvalidArgument, "Invalid Filter(%s) at %d: "+format, args...)
+}
+
+func (p *parser) end() bool {
+	return p.position >= len(p.expression)
+}
+
+func (p *parser) skipSpace() {
+	for !p.end() && strings.IndexByte(" \t\r\n", p.expression[p.position]) >= 0 {
+		p.position++
+	}
+}
+
+// next is the token at the position without reading it, for errors
+func (p *parser) next() token {
+	start := p.position
+	end := start
+	for end < len(p.expression) && strings.IndexByte(reserved, p.expression[end]) < 0 {
+		end++
+	}
+	if end == start && end < len(p.expression) {
+		end++
+	}
+	return token{text: p.expression[start:end], position: start}
+}
+
+// accept reads a character when it is next
+func (p *parser) accept(c byte) bool {
+	p.skipSpace()
+	if !p.end() && p.expression[p.position] == c {
+		p.position++
+		return true
+	}
+	return false
+}
+
+// or reads constraints separated by ,
+func (p *parser) or() (bson.D, error) {
+	var terms bson.A
+	for {
+		term, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, term)
+		if !p.accept(',') {
+			break
+		}
+	}
+	if len(terms) == 1 {
+		return terms[0].(bson.D), nil
+	}
+	return bson.D{{Key: "$or", Value: terms}}, nil
+}
+
+// and reads constraints separated by ;
+func (p *parser) and() (bson.D, error) {
+	var terms bson.A
+	for {
+		term, err := p.constraint()
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, term)
+		if !p.accept(';') {
+			break
+		}
+	}
+	if len(terms) == 1 {
+		return terms[0].(bson.D), nil
+	}
+	return bson.D{{Key: "$and", Value: terms}}, nil
+}
+
+// constraint reads a group or a comparison
+func (p *parser) constraint() (bson.D, error) {
+	p.skipSpace()
+	if p.accept('(') {
+		query, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(')') {
+			return nil, p.fail(p.next(), "expected )")
+		}
+		return query, nil
+	}
+
+	field := p.next()
+	if len(field.text) == 0 || strings.IndexByte(reserved, field.text[0]) >= 0 {
+		return nil, p.fail(field, "expected a field")
+	}
+	kind, ok := p.fields[field.text]
+	if !ok {
+		return nil, p.fail(field, "unknown field, use %s", p.names())
+	}
+	p.position += len(field.text)
+
+	operator, err := p.operator()
+	if err != nil {
+		return nil, err
+	}
+	known := operators[operator.text]
+	if !containsKind(known.kinds, kind) {
+		return nil, p.fail(operator, "not an operator of %s", field.text)
+	}
+
+	if known.operator == "$in" || known.operator == "$nin" {
+		values, err := p.list(kind)
+		if err != nil {
+			return nil, err
+		}
+		return bson.D{{Key: field.text, Value: bson.D{{Key: known.operator, Value: values}}}}, nil
+	}
+
+	argument, err := p.argument()
+	if err != nil {
+		return nil, err
+	}
+
+	if known.operator == "$regex" {
+		return bson.D{{Key: field.text, Value: pattern(argument.text, "i")}}, nil
+	}
+	if kind == String && strings.Contains(argument.text, "*") &&
+		(known.operator == "$eq" || known.operator == "$ne") {
+		if known.operator == "$ne" {
+			return bson.D{{Key: field.text, Value: bson.D{{Key: "$not", Value: pattern(argument.text, "")}}}}, nil
+		}
+		return bson.D{{Key: field.text, Value: pattern(argument.text, "")}}, nil
+	}
+
+	value, err := p.value(argument, kind)
+	if err != nil {
+		return nil, err
+	}
+	if known.operator == "$eq" {
+		return bson.D{{Key: field.text, Value: value}}, nil
+	}
+	return bson.D{{Key: field.text, Value: bson.D{{Key: known.operator, Value: value}}}}, nil
+}
+
+// operator reads one of the operators
+func (p *parser) operator() (token, error) {
+	p.skipSpace()
+	start := p.position
+	rest := p.expression[start:]
+
+	if text := namedOperator.FindString(rest); len(text) != 0 {
+		if _, ok := operators[text]; !ok {
+			return token{}, p.fail(token{text: text, position: start}, "unknown operator")
+		}
+		p.position += len(text)
+		return token{text: text, position: start}, nil
+	}
+	for _, text := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if strings.HasPrefix(rest, text) {
+			p.position += len(text)
+			return token{text: text, position: start}, nil
+		}
+	}
+	return token{}, p.fail(p.next(), "expected an operator")
+}
+
+// argument reads an unquoted or a quoted value
+func (p *parser) argument() (token, error) {
+	p.skipSpace()
+	start := p.position
+	if !p.end() && (p.expression[start] == '\'' || p.expression[start] == '"') {
+		quote := p.expression[start]
+		var text strings.Builder
+		for p.position++; !p.end(); p.position++ {
+			c := p.expression[p.position]
+			if c == '\\' && p.position+1 < len(p.expression) {
+				p.position++
+				text.WriteByte(p.expression[p.position])
+				continue
+			}
+			if c == quote {
+				p.position++
+				return token{text: text.String(), position: start}, nil
+			}
+			text.WriteByte(c)
+		}
+		return token{}, p.fail(token{text: p.expression[start:], position: start}, "missing the closing quote")
+	}
+
+	argument := p.next()
+	if len(argument.text) == 0 || strings.IndexByte(reserved, argument.text[0]) >= 0 {
+		return token{}, p.fail(argument, "expected a value")
+	}
+	p.position += len(argument.text)
+	return argument, nil
+}
+
+// list reads values in parentheses separated by ,
+func (p *parser) list(kind Kind) (bson.A, error) {
+	if !p.accept('(') {
+		return nil, p.fail(p.next(), "expected a list of values like (a,b)")
+	}
+	var result bson.A
+	for {
+		argument, err := p.argument()
+		if err != nil {
+			return nil, err
+		}
+		value, err := p.value(argument, kind)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, value)
+		if !p.accept(',') {
+			break
+		}
+	}
+	if !p.accept(')') {
+		return nil, p.fail(p.next(), "expected )")
+	}
+	return result, nil
+}
+
+// value converts an argument to the kind of its field
+func (p *parser) value(argument token, kind Kind) (interface{}, error) {
+	switch kind {
+	case Number:
+		value, err := strconv.ParseFloat(argument.text, 64)
+		if err != nil {
+			return nil, p.fail(argument, "expected a number")
+		}
+		return value, nil
+	case Boolean:
+		value, err := strconv.ParseBool(argument.text)
+		if err != nil {
+			return nil, p.fail(argument, "expected true or false")
+		}
+		return value, nil
+	}
+	return argument.text, nil
+}
+
+// names lists the fields in order
+func (p *parser) names() string {
+	var result []string
+	for name := range p.fields {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return strings.Join(result, ", ")
+}
+
+// pattern turns a value with * wildcards into a regular expression of the whole value
+func pattern(value string, options string) primitive.Regex {
+	parts := strings.Split(value, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	return primitive.Regex{Pattern: "^" + strings.Join(parts, ".*") + "$", Options: options}
+}
+
+func containsKind(kinds []Kind, kind Kind) bool {
+	for _, candidate := range kinds {
+		if candidate == kind {
+			return true
+		}
+	}
+	return false
+}
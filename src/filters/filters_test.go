@@ -0,0 +1,84 @@
+package filters
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"../failures"
+)
+
+var testFields = Fields{"airport-type": String, "municipality": String, "elevation": Number, "closed": Boolean}
+
+func TestParse(t *testing.T) {
+	var tests = []struct {
+		expression string
+		query      bson.D
+	}{
+		{"", nil},
+		{"airport-type==large_airport", bson.D{{Key: "airport-type", Value: "large_airport"}}},
+		{"elevation=gt=5000", bson.D{{Key: "elevation", Value: bson.D{{Key: "$gt", Value: 5000.0}}}}},
+		{"elevation<=-10.5", bson.D{{Key: "elevation", Value: bson.D{{Key: "$lte", Value: -10.5}}}}},
+		{"closed!=true", bson.D{{Key: "closed", Value: bson.D{{Key: "$ne", Value: true}}}}},
+		{"municipality=like=*berg*", bson.D{{Key: "municipality",
+			Value: primitive.Regex{Pattern: "^.*berg.*$", Options: "i"}}}},
+		{"municipality==St.*", bson.D{{Key: "municipality", Value: primitive.Regex{Pattern: `^St\..*$`}}}},
+		{"municipality=='Den Haag'", bson.D{{Key: "municipality", Value: "Den Haag"}}},
+		{"airport-type=out=(heliport, closed)", bson.D{{Key: "airport-type",
+			Value: bson.D{{Key: "$nin", Value: bson.A{"heliport", "closed"}}}}}},
+		{"airport-type==large_airport;elevation=gt=5000", bson.D{{Key: "$and", Value: bson.A{
+			bson.D{{Key: "airport-type", Value: "large_airport"}},
+			bson.D{{Key: "elevation", Value: bson.D{{Key: "$gt", Value: 5000.0}}}},
+		}}}},
+		{"closed==true,airport-type==heliport;elevation>0", bson.D{{Key: "$or", Value: bson.A{
+			bson.D{{Key: "closed", Value: true}},
+			bson.D{{Key: "$and", Value: bson.A{
+				bson.D{{Key: "airport-type", Value: "heliport"}},
+				bson.D{{Key: "elevation", Value: bson.D{{Key: "$gt", Value: 0.0}}}},
+			}}},
+		}}}},
+		{"(closed==true , airport-type==heliport) ; elevation>0", bson.D{{Key: "$and", Value: bson.A{
+			bson.D{{Key: "$or", Value: bson.A{
+				bson.D{{Key: "closed", Value: true}},
+				bson.D{{Key: "airport-type", Value: "heliport"}},
+			}}},
+			bson.D{{Key: "elevation", Value: bson.D{{Key: "$gt", Value: 0.0}}}},
+		}}}},
+	}
+
+	for _, test := range tests {
+		query, err := Parse(test.expression, testFields)
+		if err != nil || !reflect.DeepEqual(query, test.query) {
+			t.Errorf("Parse(%s) expected %v, got %v (%v)", test.expression, test.query, query, err)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	var tests = []struct {
+		expression string
+		message    string
+	}{
+		{"website==x", "Invalid Filter(website) at 1: unknown field"},
+		{"closed==true;elevation=gt=high", "Invalid Filter(high) at 27: expected a number"},
+		{"closed=like=true", "Invalid Filter(=like=) at 7: not an operator of closed"},
+		{"elevation=over=1", "Invalid Filter(=over=) at 10: unknown operator"},
+		{"elevation 1", "Invalid Filter(1) at 11: expected an operator"},
+		{"airport-type=in=heliport", "Invalid Filter(heliport) at 17: expected a list"},
+		{"(closed==true", "Invalid Filter(end) at 14: expected )"},
+		{"municipality=='Den Haag", "Invalid Filter('Den Haag) at 15: missing the closing quote"},
+		{"closed==true closed==false", "Invalid Filter(closed) at 14: expected ; or ,"},
+		{"closed==", "Invalid Filter(end) at 9: expected a value"},
+	}
+
+	for _, test := range tests {
+		_, err := Parse(test.expression, testFields)
+		if err == nil || !strings.HasPrefix(err.Error(), test.message) ||
+			failures.KindOf(err) != failures.InvalidArgument {
+			t.Errorf("Parse(%s) expected %s, got %v", test.expression, test.message, err)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"../failures"
+)
+
+// The REST interface has versions, the version starts the path like /geography/v2/airports. Version
+// 1 is the interface as it was before there were versions, it is frozen and also answers the paths
+// without a version. Version 2 fixes the shape of the results: the keys are in camel case, every
+// resource has an id and a list without items is empty instead of not found. The handlers are
+// shared, they write their results with the serialiser of the version of the request. The
+// parameters are the same in every version. Version 1 is deprecated, its responses carry a
+// Deprecation header and link to the same path in version 2.
+
+// apiVersion is a version of the REST interface
+type apiVersion struct {
+	name       string    // the start of the paths, like v2
+	deprecated time.Time // since when the version is deprecated, zero when it is not
+	rewrite    bool      // keys in camel case, resources with an id and links within the version
+	emptyLists bool      // a list without items is empty instead of not found
+}
+
+// The versions of the REST interface
+var (
+	apiV1 = &apiVersion{name: "v1", deprecated: time.Date(2026, time.October, 18, 0, 0, 0, 0, time.UTC)}
+	apiV2 = &apiVersion{name: "v2", rewrite: true, emptyLists: true}
+
+	apiVersions = []*apiVersion{apiV1, apiV2}
+	// latestVersion is the successor of the deprecated versions
+	latestVersion = apiV2
+)
+
+// apiVersionKey holds the version in the context of a request
+type apiVersionKey struct{}
+
+// identified is a resource with an id, the id is the code the resource is found by
+type identified interface {
+	resourceID() string
+}
+
+func (view *countryView) resourceID() string   { return view.CountryCode }
+func (view *regionView) resourceID() string    { return regionKey(view.CountryCode, view.RegionCode) }
+func (view *airportView) resourceID() string   { return view.AirportCode }
+func (view *runwayView) resourceID() string    { return view.AirportCode + "/" + view.RunwayCode }
+func (view *frequencyView) resourceID() string { return view.key }
+func (item sparseItem) resourceID() string     { return item.id }
+
+// resourceID of a search result is that of the airport, country or region it found
+func (view *searchView) resourceID() string {
+	switch {
+	case view.Airport != nil:
+		return view.Airport.resourceID()
+	case view.Country != nil:
+		return view.Country.resourceID()
+	case view.Region != nil:
+		return view.Region.resourceID()
+	}
+	return ""
+}
+
+// resourceID is the id of a value, none when it is no resource
+func resourceID(value interface{}) string {
+	if resource, ok := value.(identified); ok {
+		return resource.resourceID()
+	}
+	return ""
+}
+
+// versionOf is the version of a request, version 1 when the path has none
+func versionOf(r *http.Request) *apiVersion {
+	if version, ok := r.Context().Value(apiVersionKey{}).(*apiVersion); ok {
+		return version
+	}
+	return apiV1
+}
+
+// prefix is the start of the paths of the version
+func (version *apiVersion) prefix() string {
+	return "/geography/" + version.name
+}
+
+// path is a path of the interface in the version, like /geography/v2/airports for /geography/airports
+func (version *apiVersion) path(path string) string {
+	return version.prefix() + strings.TrimPrefix(path, "/geography")
+}
+
+// versioned serves a handler in a version, the paths start with the prefix
+func versioned(version *apiVersion, prefix string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !version.deprecated.IsZero() {
+			successor := latestVersion.prefix() + strings.TrimPrefix(r.URL.Path, prefix)
+			if len(r.URL.RawQuery) != 0 {
+				successor += "?" + r.URL.RawQuery
+			}
+			w.Header().Set("Deprecation", fmt.Sprintf("@%d", version.deprecated.Unix()))
+			w.Header().Add("Link", fmt.Sprintf(`<%s>; rel="successor-version"`, successor))
+		}
+		handler(w, r.WithContext(context.WithValue(r.Context(), apiVersionKey{}, version)))
+	}
+}
+
+// emptyList tells whether a list that was not found is empty in the version
+func (version *apiVersion) emptyList(err error) bool {
+	return version.emptyLists && err != nil && failures.KindOf(err) == failures.NotFound
+}
+
+// key is the name of a JSON key in the version
+func (version *apiVersion) key(name string) string {
+	if !version.rewrite {
+		return name
+	}
+	parts := strings.Split(name, "-")
+	for i := 1; i < len(parts); i++ {
+		if len(parts[i]) != 0 {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+// marshal is the serialiser of the version, it writes a value as JSON in the shape of the version
+func (version *apiVersion) marshal(value interface{}) ([]byte, error) {
+	if !version.rewrite {
+		return json.Marshal(value)
+	}
+
+	var result bytes.Buffer
+	// The items of a list are rewritten one by one, so that every resource has its id
+	list := reflect.ValueOf(value)
+	if list.Kind() == reflect.Slice && !list.IsNil() && list.Type().Elem().Kind() != reflect.Uint8 {
+		result.WriteByte('[')
+		for i := 0; i < list.Len(); i++ {
+			if i > 0 {
+				result.WriteByte(',')
+			}
+			encoded, err := version.marshal(list.Index(i).Interface())
+			if err != nil {
+				return nil, err
+			}
+			result.Write(encoded)
+		}
+		result.WriteByte(']')
+		return result.Bytes(), nil
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	err = version.rewriteValue(decoder, &result, "", resourceID(value))
+	return result.Bytes(), err
+}
+
+// rewriteValue rewrites the next JSON value of a decoder in the shape of the version, keeping the
+// order of the fields. The id goes first in an object of a resource.
+func (version *apiVersion) rewriteValue(decoder *json.Decoder, result *bytes.Buffer, key string, id string) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+
+	switch value := token.(type) {
+	case json.Delim:
+		result.WriteRune(rune(value))
+		count := 0
+		if value == '{' && len(id) != 0 {
+			writeJSONString(result, "id")
+			result.WriteByte(':')
+			writeJSONString(result, id)
+			count++
+		}
+		for decoder.More() {
+			if count > 0 {
+				result.WriteByte(',')
+			}
+			count++
+
+			name := key
+			if value == '{' {
+				token, err = decoder.Token()
+				if err != nil {
+					return err
+				}
+				name = token.(string)
+				writeJSONString(result, version.key(name))
+				result.WriteByte(':')
+			}
+			err = version.rewriteValue(decoder, result, name, "")
+			if err != nil {
+				return err
+			}
+		}
+		// The closing delimiter
+		token, err = decoder.Token()
+		if err != nil {
+			return err
+		}
+		result.WriteRune(rune(token.(json.Delim)))
+	case string:
+		// The links stay within the version
+		if key == "href" && strings.HasPrefix(value, "/geography/") {
+			value = version.path(value)
+		}
+		writeJSONString(result, value)
+	case json.Number:
+		result.WriteString(value.String())
+	default:
+		encoded, _ := json.Marshal(value)
+		result.Write(encoded)
+	}
+	return nil
+}
+
+// writeJSONString writes a string the way encoding/json does
+func writeJSONString(result *bytes.Buffer, value string) {
+	encoded, _ := json.Marshal(value)
+	result.Write(encoded)
+}
+
+// writeValue writes a JSON result with the serialiser of the version of the request
+func writeValue(w http.ResponseWriter, r *http.Request, value interface{}) {
+	encoded, err := versionOf(r).marshal(value)
+	if err != nil {
+		failure(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(append(encoded, '\n'))
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"../airports"
+	"../countries"
+	"../failures"
+)
+
+func TestVersionKey(t *testing.T) {
+	var tests = []struct {
+		name string
+		v1   string
+		v2   string
+	}{
+		{"icao-airport-code", "icao-airport-code", "icaoAirportCode"},
+		{"frequency-mhz", "frequency-mhz", "frequencyMhz"},
+		{"latitude", "latitude", "latitude"},
+		{"_links", "_links", "_links"},
+		{"pageInfo", "pageInfo", "pageInfo"},
+	}
+
+	for _, test := range tests {
+		if v1, v2 := apiV1.key(test.name), apiV2.key(test.name); v1 != test.v1 || v2 != test.v2 {
+			t.Errorf("key(%s) expected %s and %s, got %s and %s", test.name, test.v1, test.v2, v1, v2)
+		}
+	}
+}
+
+func TestVersionMarshal(t *testing.T) {
+	country := asCountryView(&countries.Country{CountryCode: "NL", CountryName: "Netherlands", Continent: "EU"})
+	airport := &airports.Airport{AirportCode: "EHAM", Frequencies: []*airports.Frequency{{FrequencyType: "TWR"}}}
+	item, _ := sparse(country, []string{"country-name"})
+
+	var tests = []struct {
+		name     string
+		version  *apiVersion
+		value    interface{}
+		expected string
+	}{
+		{"country", apiV1, country, `{"iso-country-code":"NL","country-name":"Netherlands","continent":"EU",` +
+			`"_links":{"airports":{"href":"/geography/countries/NL/airports"},` +
+			`"regions":{"href":"/geography/countries/NL/regions"},"self":{"href":"/geography/countries/NL"}}}`},
+		{"country", apiV2, country, `{"id":"NL","isoCountryCode":"NL","countryName":"Netherlands","continent":"EU",` +
+			`"_links":{"airports":{"href":"/geography/v2/countries/NL/airports"},` +
+			`"regions":{"href":"/geography/v2/countries/NL/regions"},"self":{"href":"/geography/v2/countries/NL"}}}`},
+		{"sparse", apiV2, item, `{"id":"NL","countryName":"Netherlands",` +
+			`"_links":{"airports":{"href":"/geography/v2/countries/NL/airports"},` +
+			`"regions":{"href":"/geography/v2/countries/NL/regions"},"self":{"href":"/geography/v2/countries/NL"}}}`},
+		{"frequency", apiV2, asFrequencyView(airport, 0), `{"id":"EHAM/000","icaoAirportCode":"EHAM",` +
+			`"frequencyType":"TWR","frequencyMhz":0,"_links":{"airport":{"href":"/geography/v2/airports/EHAM"}}}`},
+		{"runways", apiV2, []*runwayView{{AirportCode: "EHAM", RunwayCode: "18R", Length: 3800}},
+			`[{"id":"EHAM/18R","icaoAirportCode":"EHAM","runwayCode":"18R","length":3800,"width":0,` +
+				`"surface":"","lighted":false,"closed":false,"_links":null}]`},
+		{"region", apiV2, asRegionView(&countries.Country{CountryCode: "NL"}, &countries.Region{RegionCode: "NH"}),
+			`{"id":"NL-NH","isoCountryCode":"NL","isoRegionCode":"NH","regionName":"",` +
+				`"_links":{"airports":{"href":"/geography/v2/countries/NL/regions/NH/airports"},` +
+				`"country":{"href":"/geography/v2/countries/NL"},"self":{"href":"/geography/v2/regions/NL-NH"}}}`},
+		{"no resource", apiV2, map[string][]int{"max-results": {1, 2}}, `{"maxResults":[1,2]}`},
+		{"no list", apiV2, []*runwayView(nil), `null`},
+	}
+
+	for _, test := range tests {
+		encoded, err := test.version.marshal(test.value)
+		if err != nil || string(encoded) != test.expected {
+			t.Errorf("marshal %s in %s expected\n%s\ngot\n%s (%v)", test.name, test.version.name, test.expected,
+				encoded, err)
+		}
+	}
+}
+
+func TestVersionedRouter(t *testing.T) {
+	table := []*route{
+		{method: "GET", path: "/geography/countries/{country-code}", operation: "getCountry",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				writeValue(w, r, map[string]string{"iso-country-code": mux.Vars(r)["country-code"]})
+			}},
+	}
+
+	var tests = []struct {
+		path      string
+		body      string
+		successor string
+	}{
+		{"/geography/countries/NL", `{"iso-country-code":"NL"}`, `</geography/v2/countries/NL>; rel="successor-version"`},
+		{"/geography/v1/countries/NL?format=json", `{"iso-country-code":"NL"}`,
+			`</geography/v2/countries/NL?format=json>; rel="successor-version"`},
+		{"/geography/v2/countries/NL", `{"isoCountryCode":"NL"}`, ""},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		newRouter(table).ServeHTTP(w, httptest.NewRequest("GET", test.path, nil))
+		if w.Code != http.StatusOK || w.Body.String() != test.body+"\n" {
+			t.Errorf("GET %s expected %s, got %d %s", test.path, test.body, w.Code, w.Body.String())
+		}
+		deprecated := len(test.successor) != 0
+		if w.Header().Get("Link") != test.successor || (len(w.Header().Get("Deprecation")) != 0) != deprecated {
+			t.Errorf("GET %s expected deprecated %t with link %s, got %s %s", test.path, deprecated, test.successor,
+				w.Header().Get("Deprecation"), w.Header().Get("Link"))
+		}
+	}
+}
+
+func TestEmptyLists(t *testing.T) {
+	theMaxResults = 500
+
+	if !apiV2.emptyList(failures.New(failures.NotFound, "Not found")) ||
+		apiV1.emptyList(failures.New(failures.NotFound, "Not found")) ||
+		apiV2.emptyList(failures.New(failures.Internal, "Failed")) || apiV2.emptyList(nil) {
+		t.Errorf("emptyList expected a list that was not found to be empty in version 2 only")
+	}
+
+	var tests = []struct {
+		version *apiVersion
+		status  int
+		body    string
+	}{
+		{apiV1, http.StatusNotFound, ""},
+		{apiV2, http.StatusOK, "[]\n"},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		handler := versioned(test.version, test.version.prefix(), func(w http.ResponseWriter, r *http.Request) {
+			writeResources(w, r, "runways", runwayColumns, nil, nil)
+		})
+		handler(w, httptest.NewRequest("GET", test.version.path("/geography/airports/EHAM/runways"), nil))
+		if w.Code != test.status || (len(test.body) != 0 && w.Body.String() != test.body) {
+			t.Errorf("Empty list in %s expected %d %s, got %d %s", test.version.name, test.status, test.body,
+				w.Code, w.Body.String())
+		}
+	}
+}
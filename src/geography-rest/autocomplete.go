@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"../airports"
+	"../failures"
+	"../versions"
+)
+
+// Autocomplete suggests airports while their code, name or municipality is typed (see
+// airports.Completions). The index is built when the server starts and built again in the
+// background when a new version of the airports is published, the old index answers meanwhile so
+// typing never waits for it.
+
+// The number of suggestions when no limit is asked for, and at most
+const (
+	defaultAutocompleteLimit = 10
+	maxAutocompleteLimit     = 20
+)
+
+// theCompletions keeps the autocomplete index of the current airports
+var theCompletions struct {
+	sync.Mutex
+	completions *airports.Completions
+	version     int64
+	building    bool
+}
+
+// currentCompletions is the autocomplete index, it starts building a new one when the airports
+// changed
+func currentCompletions() (*airports.Completions, error) {
+	version, err := theVersionCache.Get(versions.Airports)
+	if err != nil {
+		return nil, err
+	}
+
+	theCompletions.Lock()
+	defer theCompletions.Unlock()
+
+	if (theCompletions.completions == nil || theCompletions.version != version.Version) &&
+		!theCompletions.building {
+		theCompletions.building = true
+		go buildCompletions(version.Version)
+	}
+	if theCompletions.completions == nil {
+		return nil, failures.New(failures.Unavailable, "Autocomplete: the index is being built")
+	}
+	return theCompletions.completions, nil
+}
+
+// buildCompletions loads the airports of a version into a new autocomplete index
+func buildCompletions(version int64) {
+	completions, err := theAirports.LoadCompletions()
+
+	theCompletions.Lock()
+	defer theCompletions.Unlock()
+
+	theCompletions.building = false
+	if err != nil {
+		log.Printf("Autocomplete: %v", err)
+		return
+	}
+	theCompletions.completions = completions
+	theCompletions.version = version
+}
+
+func getAutocomplete(w http.ResponseWriter, r *http.Request) {
+	query := strings.TrimSpace(r.FormValue("q"))
+	if len(query) == 0 {
+		httpError(w, "Autocomplete: Missing query", http.StatusBadRequest)
+		return
+	}
+
+	limit := defaultAutocompleteLimit
+	if len(r.FormValue("limit")) != 0 {
+		var err error
+		limit, err = strconv.Atoi(r.FormValue("limit"))
+		if err != nil || limit < 1 || limit > maxAutocompleteLimit {
+			httpError(w, fmt.Sprintf("Invalid Limit(%s): use 1 to %d", r.FormValue("limit"),
+				maxAutocompleteLimit), http.StatusBadRequest)
+			return
+		}
+	}
+
+	completions, err := currentCompletions()
+	if err != nil {
+		failure(w, err)
+		return
+	}
+
+	writeValue(w, r, completions.Complete(query, limit))
+}
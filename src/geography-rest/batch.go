@@ -0,0 +1,40 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"../airports"
+)
+
+// A batch gets many airports at once by their ICAO and IATA codes (see airports.GetBatch), the
+// items of the result are in the order of the codes.
+
+// maxBatchBody is the largest body of a batch in bytes
+const maxBatchBody = 1 << 20
+
+// batchRequest is the body of a batch
+type batchRequest struct {
+	Codes []string `json:"codes"`
+}
+
+func postAirportsBatch(w http.ResponseWriter, r *http.Request) {
+	var request batchRequest
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBatchBody))
+	err := decoder.Decode(&request)
+	if err != nil {
+		httpError(w, "Invalid Batch: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	items, err := theAirports.GetBatch(request.Codes)
+	if err != nil {
+		failure(w, err)
+		return
+	}
+	if items == nil {
+		items = []*airports.BatchItem{}
+	}
+
+	writeValue(w, r, items)
+}
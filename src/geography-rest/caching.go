@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"../versions"
+)
+
+// Responses are cached by browsers and CDNs on the versions of the datasets they are made of. The
+// ETag and Last-Modified of a response change with an import, a request with a matching
+// If-None-Match or If-Modified-Since is answered with 304 Not Modified without reading the data.
+// Some results also depend on the clock, like today's daylight, their validators change with
+// the period of the route as well.
+
+// defaultCacheControl is the Cache-Control of the routes that have none of their own
+const defaultCacheControl = "public, max-age=300"
+
+// defaultPeriod is how long a result depending on the datasets stays the same at most
+const defaultPeriod = 24 * time.Hour
+
+// theVersionCache holds the versions of the datasets
+var theVersionCache *versions.Cache
+
+// theCacheControl holds the Cache-Control per operation configured in the options
+var theCacheControl map[string]string
+
+// validators are the ETag and Last-Modified of a response
+type validators struct {
+	etag         string
+	lastModified time.Time
+}
+
+// newValidators makes the validators of a request on a route from the versions of its datasets.
+// The request and its Accept header are part of the ETag, as formats are negotiated.
+func newValidators(route *route, r *http.Request, now time.Time) (*validators, error) {
+	period := route.period
+	if period == 0 {
+		period = defaultPeriod
+	}
+	start := now.UTC().Truncate(period)
+
+	hash := fnv.New64a()
+	fmt.Fprintf(hash, "%s %s\n%s\n%d\n", r.Method, r.URL.RequestURI(), r.Header.Get("Accept"), start.Unix())
+
+	result := validators{lastModified: start}
+	for _, dataset := range route.datasets {
+		version, err := theVersionCache.Get(dataset)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(hash, "%s:%d\n", dataset, version.Version)
+		if version.Updated.After(result.lastModified) {
+			result.lastModified = version.Updated.UTC()
+		}
+	}
+	result.etag = fmt.Sprintf(`"%x"`, hash.Sum64())
+	// HTTP dates have no fractions of a second
+	result.lastModified = result.lastModified.Truncate(time.Second)
+
+	return &result, nil
+}
+
+// notModified tells whether the client has the current response. If-None-Match wins over
+// If-Modified-Since, a weak match is good enough for a GET.
+func (current *validators) notModified(r *http.Request) bool {
+	if match := r.Header.Get("If-None-Match"); len(match) != 0 {
+		for _, etag := range strings.Split(match, ",") {
+			etag = strings.TrimPrefix(strings.TrimSpace(etag), "W/")
+			if etag == "*" || etag == current.etag {
+				return true
+			}
+		}
+		return false
+	}
+
+	if since := r.Header.Get("If-Modified-Since"); len(since) != 0 {
+		modified, err := http.ParseTime(since)
+		return err == nil && !current.lastModified.After(modified)
+	}
+
+	return false
+}
+
+// cacheControl is the Cache-Control of a route, the options override the route table
+func cacheControl(route *route) string {
+	if value, ok := theCacheControl[route.operation]; ok {
+		return value
+	}
+	if len(route.cacheControl) != 0 {
+		return route.cacheControl
+	}
+	return defaultCacheControl
+}
+
+// cachedWriter leaves the validators out of error responses, only results are cached
+type cachedWriter struct {
+	http.ResponseWriter
+}
+
+func (w *cachedWriter) WriteHeader(status int) {
+	if status >= http.StatusBadRequest {
+		w.Header().Del("ETag")
+		w.Header().Del("Last-Modified")
+		w.Header().Set("Cache-Control", "no-store")
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// cached adds the caching headers to the GET requests of a route, its handler is not called when
+// the client has the current response. Without the versions the response is not cached.
+func cached(route *route) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			route.handler(w, r)
+			return
+		}
+
+		w.Header().Set("Cache-Control", cacheControl(route))
+		if len(route.formats) != 0 {
+			w.Header().Set("Vary", "Accept")
+		}
+		if len(route.datasets) == 0 && route.period == 0 {
+			route.handler(w, r)
+			return
+		}
+
+		current, err := newValidators(route, r, time.Now())
+		if err != nil {
+			log.Printf("Caching %s: %v", route.operation, err)
+			w.Header().Set("Cache-Control", "no-store")
+			route.handler(w, r)
+			return
+		}
+		w.Header().Set("ETag", current.etag)
+		w.Header().Set("Last-Modified", current.lastModified.Format(http.TimeFormat))
+
+		if current.notModified(r) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		route.handler(&cachedWriter{ResponseWriter: w}, r)
+	}
+}
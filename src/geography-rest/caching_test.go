@@ -0,0 +1,122 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"../versions"
+)
+
+// testVersions sets up the versions of the datasets for the tests
+func testVersions(current map[string]*versions.Version) {
+	theVersionCache = versions.NewCache(func(dataset string) (*versions.Version, error) {
+		if version, ok := current[dataset]; ok {
+			return version, nil
+		}
+		return &versions.Version{Dataset: dataset}, nil
+	}, 0)
+}
+
+func TestNewValidators(t *testing.T) {
+	updated := time.Date(2026, 10, 1, 12, 30, 0, 0, time.UTC)
+	current := map[string]*versions.Version{versions.Airports: {Dataset: versions.Airports, Version: 3, Updated: updated}}
+	testVersions(current)
+
+	route := &route{datasets: []string{versions.Airports}}
+	now := time.Date(2026, 10, 1, 18, 0, 0, 0, time.UTC)
+	request := httptest.NewRequest("GET", "/geography/airports/EHAM", nil)
+
+	first, err := newValidators(route, request, now)
+	if err != nil {
+		t.Fatalf("newValidators failed: %v", err)
+	}
+	if !first.lastModified.Equal(updated) {
+		t.Errorf("newValidators expected the update as Last-Modified, got %v", first.lastModified)
+	}
+	again, _ := newValidators(route, request, now.Add(time.Hour))
+	if again.etag != first.etag {
+		t.Errorf("newValidators expected the same ETag within the day")
+	}
+
+	csv := httptest.NewRequest("GET", "/geography/airports/EHAM", nil)
+	csv.Header.Set("Accept", "text/csv")
+	other, _ := newValidators(route, csv, now)
+	if other.etag == first.etag {
+		t.Errorf("newValidators expected another ETag for another format")
+	}
+
+	current[versions.Airports] = &versions.Version{Dataset: versions.Airports, Version: 4, Updated: now}
+	bumped, _ := newValidators(route, request, now)
+	if bumped.etag == first.etag || !bumped.lastModified.Equal(now) {
+		t.Errorf("newValidators expected a new ETag and Last-Modified after an import")
+	}
+
+	nextDay, _ := newValidators(route, request, now.Add(24*time.Hour))
+	if nextDay.etag == bumped.etag {
+		t.Errorf("newValidators expected a new ETag the next day")
+	}
+}
+
+func TestCached(t *testing.T) {
+	testVersions(map[string]*versions.Version{})
+	theCacheControl = map[string]string{"getConfigured": "public, max-age=10"}
+
+	calls := 0
+	status := http.StatusOK
+	route := &route{operation: "getTest", datasets: []string{versions.Airports},
+		handler: func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.WriteHeader(status)
+		}}
+	handler := cached(route)
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest("GET", "/test", nil))
+	etag := w.Header().Get("ETag")
+	lastModified := w.Header().Get("Last-Modified")
+	if w.Code != http.StatusOK || len(etag) == 0 || len(lastModified) == 0 ||
+		w.Header().Get("Cache-Control") != defaultCacheControl {
+		t.Fatalf("cached expected 200 with the validators, got %d %v", w.Code, w.Header())
+	}
+
+	var tests = []struct {
+		header string
+		value  string
+		status int
+	}{
+		{"If-None-Match", etag, http.StatusNotModified},
+		{"If-None-Match", `"other", W/` + etag, http.StatusNotModified},
+		{"If-None-Match", "*", http.StatusNotModified},
+		{"If-None-Match", `"other"`, http.StatusOK},
+		{"If-Modified-Since", lastModified, http.StatusNotModified},
+		{"If-Modified-Since", "Thu, 01 Jan 1970 00:00:00 GMT", http.StatusOK},
+	}
+	for _, test := range tests {
+		calls = 0
+		request := httptest.NewRequest("GET", "/test", nil)
+		request.Header.Set(test.header, test.value)
+		w := httptest.NewRecorder()
+		handler(w, request)
+		if w.Code != test.status || (test.status == http.StatusNotModified) != (calls == 0) {
+			t.Errorf("cached with %s %s expected %d, got %d after %d calls", test.header, test.value,
+				test.status, w.Code, calls)
+		}
+	}
+
+	status = http.StatusNotFound
+	w = httptest.NewRecorder()
+	handler(w, httptest.NewRequest("GET", "/test", nil))
+	if len(w.Header().Get("ETag")) != 0 || w.Header().Get("Cache-Control") != "no-store" {
+		t.Errorf("cached expected no validators on an error, got %v", w.Header())
+	}
+
+	route.operation = "getConfigured"
+	status = http.StatusOK
+	w = httptest.NewRecorder()
+	handler(w, httptest.NewRequest("GET", "/test", nil))
+	if w.Header().Get("Cache-Control") != "public, max-age=10" {
+		t.Errorf("cached expected the configured Cache-Control, got %s", w.Header().Get("Cache-Control"))
+	}
+}
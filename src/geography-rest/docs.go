@@ -0,0 +1,278 @@
+package main
+
+// docsPage browses the OpenAPI document in the way of Swagger UI, without loading anything but
+// the document itself so it works offline. The operations can be tried from the page.
+const docsPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Geography API</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 0; color: #222; background: #fafafa; }
+header { background: #1b2a3a; color: #fff; padding: 16px 32px; }
+header h1 { margin: 0; font-size: 24px; }
+header p { margin: 4px 0 0; color: #c8d3de; }
+main { max-width: 1100px; margin: 0 auto; padding: 16px 32px; }
+#filter { width: 100%; padding: 8px; font-size: 14px; box-sizing: border-box; margin-bottom: 16px; }
+h2 { text-transform: capitalize; border-bottom: 1px solid #ddd; padding-bottom: 4px; }
+.operation { border: 1px solid #ccc; border-radius: 4px; margin: 8px 0; background: #fff; }
+.operation > .summary { display: flex; align-items: center; cursor: pointer; padding: 6px; }
+.method { display: inline-block; min-width: 60px; text-align: center; font-weight: bold; color: #fff;
+  border-radius: 3px; padding: 4px 0; margin-right: 12px; font-size: 13px; }
+.get { background: #2f80c4; } .post { background: #3a9a5b; }
+.path { font-family: Menlo, Consolas, monospace; font-weight: bold; margin-right: 12px; }
+.details { display: none; padding: 8px 16px 16px; border-top: 1px solid #eee; }
+.open .details { display: block; }
+table { border-collapse: collapse; width: 100%; font-size: 13px; }
+th, td { text-align: left; padding: 4px 8px; border-bottom: 1px solid #eee; vertical-align: top; }
+input[type=text], textarea { width: 100%; box-sizing: border-box; font-family: Menlo, Consolas, monospace; font-size: 12px; }
+pre { background: #1e1e1e; color: #ddd; padding: 8px; overflow: auto; max-height: 400px; font-size: 12px; }
+.schema { font-family: Menlo, Consolas, monospace; font-size: 12px; white-space: pre; background: #f4f4f4; padding: 8px; overflow: auto; }
+.required { color: #c0392b; }
+button { padding: 6px 16px; margin-top: 8px; cursor: pointer; }
+.muted { color: #888; }
+</style>
+</head>
+<body>
+<header><h1 id="title">Geography API</h1><p id="description"></p></header>
+<main>
+<input id="filter" type="text" placeholder="Filter on path or summary">
+<div id="operations">Loading the document..</div>
+</main>
+<script>
+(function () {
+  "use strict";
+
+  var spec;
+
+  function element(name, className, text) {
+    var result = document.createElement(name);
+    if (className) { result.className = className; }
+    if (text !== undefined) { result.textContent = text; }
+    return result;
+  }
+
+  function resolve(schema) {
+    if (schema && schema.$ref) {
+      return spec.components.schemas[schema.$ref.replace("#/components/schemas/", "")];
+    }
+    return schema;
+  }
+
+  // describe writes a schema as an indented outline, a schema seen before is only named
+  function describe(schema, indent, seen) {
+    if (!schema) { return "any"; }
+    if (schema.$ref) {
+      var name = schema.$ref.replace("#/components/schemas/", "");
+      if (seen.indexOf(name) >= 0) { return name; }
+      return name + " " + describe(resolve(schema), indent, seen.concat([name]));
+    }
+    if (schema.type === "array") {
+      return "[" + describe(schema.items, indent, seen) + "]";
+    }
+    if (schema.type === "object" && schema.properties) {
+      var lines = ["{"];
+      Object.keys(schema.properties).sort().forEach(function (property) {
+        var required = (schema.required || []).indexOf(property) >= 0 ? "*" : "";
+        lines.push(indent + "  " + property + required + ": " +
+          describe(schema.properties[property], indent + "  ", seen));
+      });
+      lines.push(indent + "}");
+      return lines.join("\n");
+    }
+    if (schema.type === "object" && schema.additionalProperties) {
+      return "{string: " + describe(schema.additionalProperties, indent, seen) + "}";
+    }
+    var result = schema.type || "any";
+    if (schema.format) { result += " (" + schema.format + ")"; }
+    if (schema.enum) { result += " " + schema.enum.join(" | "); }
+    return result;
+  }
+
+  function parametersTable(operation, inputs) {
+    var table = element("table");
+    var head = element("tr");
+    ["Name", "In", "Type", "Description", "Value"].forEach(function (title) {
+      head.appendChild(element("th", "", title));
+    });
+    table.appendChild(head);
+
+    (operation.parameters || []).forEach(function (parameter) {
+      var row = element("tr");
+      var name = element("td", parameter.required ? "required" : "", parameter.name + (parameter.required ? " *" : ""));
+      row.appendChild(name);
+      row.appendChild(element("td", "muted", parameter.in));
+      row.appendChild(element("td", "", describe(parameter.schema, "", [])));
+      row.appendChild(element("td", "", parameter.description || ""));
+      var cell = element("td");
+      var input;
+      if (parameter.schema && parameter.schema.enum) {
+        input = element("select");
+        input.appendChild(element("option", "", ""));
+        parameter.schema.enum.forEach(function (value) {
+          input.appendChild(element("option", "", value));
+        });
+      } else {
+        input = element("input");
+        input.type = "text";
+      }
+      inputs.push({parameter: parameter, input: input});
+      cell.appendChild(input);
+      row.appendChild(cell);
+      table.appendChild(row);
+    });
+    return table;
+  }
+
+  function tryOut(path, method, inputs, body, output) {
+    var url = path;
+    var query = [];
+    var missing = [];
+    var headers = {};
+    inputs.forEach(function (entry) {
+      var value = entry.input.value;
+      if (entry.parameter.in === "path") {
+        if (!value) { missing.push(entry.parameter.name); }
+        url = url.replace("{" + entry.parameter.name + "}", encodeURIComponent(value));
+      } else if (entry.parameter.in === "header") {
+        if (value) { headers[entry.parameter.name] = value; }
+      } else if (value) {
+        query.push(encodeURIComponent(entry.parameter.name) + "=" + encodeURIComponent(value));
+      }
+    });
+    if (missing.length) {
+      output.textContent = "Missing " + missing.join(", ");
+      return;
+    }
+    if (query.length) { url += "?" + query.join("&"); }
+
+    var options = {method: method.toUpperCase(), headers: headers};
+    if (body) {
+      options.headers["Content-Type"] = "application/json";
+      options.body = body.value;
+    }
+
+    output.textContent = options.method + " " + url + "\n\nLoading..";
+    fetch(url, options).then(function (response) {
+      var headers = [];
+      response.headers.forEach(function (value, name) { headers.push(name + ": " + value); });
+      var contentType = response.headers.get("Content-Type") || "";
+      var binary = !/json|text|xml|csv|ndjson/.test(contentType);
+      return (binary ? response.blob().then(function (blob) { return "(" + blob.size + " bytes)"; }) : response.text())
+        .then(function (text) {
+          if (/json/.test(contentType) && !/ndjson/.test(contentType)) {
+            try { text = JSON.stringify(JSON.parse(text), null, 2); } catch (e) { /* as is */ }
+          }
+          if (text.length > 100000) { text = text.substring(0, 100000) + "\n.."; }
+          output.textContent = options.method + " " + url + "\n\n" + response.status + " " + response.statusText +
+            "\n" + headers.join("\n") + "\n\n" + text;
+        });
+    }).catch(function (error) {
+      output.textContent = options.method + " " + url + "\n\n" + error;
+    });
+  }
+
+  function operationElement(path, method, operation) {
+    var result = element("div", "operation");
+    result.setAttribute("data-search", (path + " " + operation.summary + " " + operation.operationId).toLowerCase());
+
+    var summary = element("div", "summary");
+    summary.appendChild(element("span", "method " + method, method.toUpperCase()));
+    summary.appendChild(element("span", "path", path));
+    summary.appendChild(element("span", "muted", operation.summary));
+    summary.addEventListener("click", function () { result.classList.toggle("open"); });
+    result.appendChild(summary);
+
+    var details = element("div", "details");
+    details.appendChild(element("div", "muted", "operationId: " + operation.operationId));
+
+    var inputs = [];
+    if (operation.parameters && operation.parameters.length) {
+      details.appendChild(element("h4", "", "Parameters"));
+      details.appendChild(parametersTable(operation, inputs));
+    }
+
+    var body;
+    if (operation.requestBody) {
+      details.appendChild(element("h4", "", "Request body"));
+      Object.keys(operation.requestBody.content).forEach(function (contentType) {
+        details.appendChild(element("div", "muted", contentType));
+        details.appendChild(element("div", "schema",
+          describe(operation.requestBody.content[contentType].schema, "", [])));
+      });
+      body = element("textarea");
+      body.rows = 6;
+      body.value = JSON.stringify({query: "{ countries(FromCountryCode: \"NL\", UntilCountryCode: \"NO\", first: 5) " +
+        "{ edges { node { CountryCode CountryName } } } }"}, null, 2);
+      details.appendChild(body);
+    }
+
+    details.appendChild(element("h4", "", "Responses"));
+    Object.keys(operation.responses).sort().forEach(function (status) {
+      var response = operation.responses[status];
+      if (response.$ref) {
+        response = spec.components.responses[response.$ref.replace("#/components/responses/", "")];
+      }
+      details.appendChild(element("div", "", status + ": " + (response.description || "")));
+      Object.keys(response.headers || {}).sort().forEach(function (name) {
+        details.appendChild(element("div", "muted", "Header " + name + ": " + response.headers[name].description));
+      });
+      Object.keys(response.content || {}).forEach(function (contentType) {
+        details.appendChild(element("div", "muted", contentType));
+        details.appendChild(element("div", "schema", describe(response.content[contentType].schema, "", [])));
+      });
+    });
+
+    var send = element("button", "", "Try it out");
+    var output = element("pre");
+    output.textContent = "";
+    send.addEventListener("click", function () { tryOut(path, method, inputs, body, output); });
+    details.appendChild(send);
+    details.appendChild(output);
+
+    result.appendChild(details);
+    return result;
+  }
+
+  function render() {
+    document.getElementById("title").textContent = spec.info.title + " " + spec.info.version;
+    document.getElementById("description").textContent = spec.info.description;
+
+    var groups = {};
+    Object.keys(spec.paths).sort().forEach(function (path) {
+      Object.keys(spec.paths[path]).sort().forEach(function (method) {
+        var operation = spec.paths[path][method];
+        var tag = (operation.tags || ["other"])[0];
+        (groups[tag] = groups[tag] || []).push(operationElement(path, method, operation));
+      });
+    });
+
+    var container = document.getElementById("operations");
+    container.textContent = "";
+    Object.keys(groups).sort().forEach(function (tag) {
+      var section = element("section");
+      section.appendChild(element("h2", "", tag));
+      groups[tag].forEach(function (operation) { section.appendChild(operation); });
+      container.appendChild(section);
+    });
+  }
+
+  document.getElementById("filter").addEventListener("input", function (event) {
+    var text = event.target.value.toLowerCase();
+    Array.prototype.forEach.call(document.querySelectorAll(".operation"), function (operation) {
+      operation.style.display = operation.getAttribute("data-search").indexOf(text) >= 0 ? "" : "none";
+    });
+  });
+
+  fetch("openapi.json").then(function (response) { return response.json(); }).then(function (document) {
+    spec = document;
+    render();
+  }).catch(function (error) {
+    document.getElementById("operations").textContent = "Failed to load openapi.json: " + error;
+  });
+})();
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"../failures"
+)
+
+// problem is the body of every error response, the problem details of RFC 7807. The code
+// tells the kind of failure, the same code GraphQL errors carry.
+type problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	Code   string `json:"code"`
+}
+
+// kindStatuses holds the status of each kind of failure. A result that is too large is a valid
+// request the server will not process, asking for less or paging it helps.
+var kindStatuses = map[failures.Kind]int{
+	failures.NotFound:        http.StatusNotFound,
+	failures.InvalidArgument: http.StatusBadRequest,
+	failures.TooLarge:        http.StatusUnprocessableEntity,
+	failures.Unavailable:     http.StatusServiceUnavailable,
+	failures.Internal:        http.StatusInternalServerError,
+}
+
+// statusCode is the code of an error decided on by a handler, for the statuses of the kinds it
+// is the code of the kind
+func statusCode(status int) string {
+	for kind, kindStatus := range kindStatuses {
+		if kindStatus == status {
+			return string(kind)
+		}
+	}
+	return strings.ToUpper(strings.Replace(http.StatusText(status), " ", "_", -1))
+}
+
+// httpError replies with an error status and a problem explaining it, like http.Error does
+// with plain text
+func httpError(w http.ResponseWriter, message string, status int) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	result := json.NewEncoder(w)
+	result.Encode(problem{Type: "about:blank", Title: http.StatusText(status), Status: status,
+		Detail: message, Code: statusCode(status)})
+}
+
+// failure replies with the problem of an error of the datasets, its kind picks the status. The
+// errors of the server are logged, their detail is generic.
+func failure(w http.ResponseWriter, err error) {
+	kind := failures.KindOf(err)
+	switch kind {
+	case failures.Internal:
+		log.Printf("Internal error: %v", err)
+	case failures.Unavailable:
+		log.Printf("Unavailable: %v", err)
+		w.Header().Set("Retry-After", "30")
+	}
+	httpError(w, failures.Detail(err), kindStatuses[kind])
+}
+
+func notFound(w http.ResponseWriter, r *http.Request) {
+	httpError(w, "No resource at "+r.URL.Path, http.StatusNotFound)
+}
+
+func methodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	httpError(w, r.Method+" is not supported on "+r.URL.Path, http.StatusMethodNotAllowed)
+}
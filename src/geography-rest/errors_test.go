@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"../failures"
+)
+
+func TestHTTPError(t *testing.T) {
+	w := httptest.NewRecorder()
+	httpError(w, "Not found", http.StatusNotFound)
+
+	var body problem
+	err := json.NewDecoder(w.Body).Decode(&body)
+	if err != nil || w.Code != http.StatusNotFound || w.Header().Get("Content-Type") != "application/problem+json" {
+		t.Fatalf("httpError expected a problem 404, got %d %s (%v)", w.Code, w.Header().Get("Content-Type"), err)
+	}
+	if body.Status != 404 || body.Title != "Not Found" || body.Detail != "Not found" || body.Code != "NOT_FOUND" ||
+		body.Type != "about:blank" {
+		t.Errorf("httpError expected status, title, detail and code, got %+v", body)
+	}
+}
+
+func TestFailure(t *testing.T) {
+	var tests = []struct {
+		err    error
+		status int
+		code   string
+		detail string
+	}{
+		{failures.New(failures.NotFound, "Not found"), http.StatusNotFound, "NOT_FOUND", "Not found"},
+		{failures.New(failures.InvalidArgument, "GetList.CountryCode(NLD): Invalid"), http.StatusBadRequest,
+			"INVALID_ARGUMENT", "GetList.CountryCode(NLD): Invalid"},
+		{fmt.Errorf("Airports: %w", failures.New(failures.TooLarge, "Too many results")),
+			http.StatusUnprocessableEntity, "RESULT_TOO_LARGE", "Airports: Too many results"},
+		{failures.Database(fmt.Errorf("no reachable servers")), http.StatusServiceUnavailable, "UPSTREAM_UNAVAILABLE",
+			"The database is not available, try again later"},
+		{fmt.Errorf("Unexpected"), http.StatusInternalServerError, "INTERNAL", "The request could not be processed"},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		failure(w, test.err)
+
+		var body problem
+		json.NewDecoder(w.Body).Decode(&body)
+		if w.Code != test.status || body.Status != test.status || body.Code != test.code ||
+			body.Detail != test.detail {
+			t.Errorf("failure(%v) expected %d %s %s, got %d %+v", test.err, test.status, test.code, test.detail,
+				w.Code, body)
+		}
+	}
+}
+
+func TestStatusCode(t *testing.T) {
+	if statusCode(http.StatusMethodNotAllowed) != "METHOD_NOT_ALLOWED" || statusCode(http.StatusBadRequest) != "INVALID_ARGUMENT" {
+		t.Errorf("statusCode expected METHOD_NOT_ALLOWED and INVALID_ARGUMENT")
+	}
+}
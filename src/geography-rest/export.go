@@ -0,0 +1,281 @@
+package main
+
+import (
+	"compress/gzip"
+	"crypto/subtle"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"../airports"
+	"../countries"
+	"../versions"
+)
+
+// An export streams a whole dataset straight from the database, for clients that keep a copy of
+// it. It is not limited like a list, the records are written as they are read and flushed
+// regularly, so an export takes the same memory whatever its size. Every record has a key and the
+// records come in order of it: an export that broke off resumes after the key of the last record
+// received. The version of the dataset is in the X-Dataset-Version header, passing it back on
+// resume fails when the dataset changed in between. The X-Export-Status trailer tells whether the
+// export completed. Only clients with one of the export keys of the options can export.
+
+// exportFlush is the number of records written between flushes
+const exportFlush = 500
+
+// exportStatus is the trailer telling whether the export completed
+const exportStatus = "X-Export-Status"
+
+// exportFormats are the formats of an export, CSV is gzipped
+var exportFormats = []string{formatNDJSON, formatCSV}
+
+// theExportKeys are the keys of the clients that can export, none disables exports
+var theExportKeys []string
+
+// exportDataset is a dataset that can be exported
+type exportDataset struct {
+	version string   // the dataset of its version
+	columns []string // of the CSV format
+	export  func(after string, each func(key string, item interface{}) error) error
+}
+
+// exportDatasets are the datasets that can be exported by name
+var exportDatasets = map[string]*exportDataset{
+	"countries":   {version: versions.Countries, columns: countryColumns, export: exportCountries},
+	"regions":     {version: versions.Countries, columns: regionColumns, export: exportRegions},
+	"airports":    {version: versions.Airports, columns: airportColumns, export: exportAirports},
+	"runways":     {version: versions.Airports, columns: runwayColumns, export: exportRunways},
+	"frequencies": {version: versions.Airports, columns: frequencyColumns, export: exportFrequencies},
+}
+
+// exportCountries exports the countries without their regions, the key is the country code
+func exportCountries(after string, each func(string, interface{}) error) error {
+	return theCountries.Export(after, func(country *countries.Country) error {
+		country.Regions = nil
+		return each(country.CountryCode, country)
+	})
+}
+
+// exportRegions exports the regions of the countries, the key is the ISO code like NL-NH that
+// starts with the country to resume from
+func exportRegions(after string, each func(string, interface{}) error) error {
+	return theCountries.Export(parentKey(after, "-"), func(country *countries.Country) error {
+		return eachRegion(country, each)
+	})
+}
+
+// eachRegion passes the regions of a country in order of their ISO code
+func eachRegion(country *countries.Country, each func(string, interface{}) error) error {
+	sort.Slice(country.Regions, func(i, j int) bool {
+		return country.Regions[i].RegionCode < country.Regions[j].RegionCode
+	})
+	for _, region := range country.Regions {
+		err := each(regionKey(country.CountryCode, region.RegionCode), asRegionView(country, region))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exportAirports exports the airports with their runways and frequencies, the key is the ICAO code
+func exportAirports(after string, each func(string, interface{}) error) error {
+	return theAirports.Export(after, func(airport *airports.Airport) error {
+		return each(airport.AirportCode, airport)
+	})
+}
+
+// exportRunways exports the runway directions of the airports, the key is like EHAM/18R
+func exportRunways(after string, each func(string, interface{}) error) error {
+	return theAirports.Export(parentKey(after, "/"), func(airport *airports.Airport) error {
+		var views []*runwayView
+		for _, runway := range airport.Runways {
+			views = append(views, asRunwayViews(airport, runway)...)
+		}
+		return eachRunway(airport.AirportCode, views, each)
+	})
+}
+
+// eachRunway passes the runway directions of an airport in order of their code. A code can occur
+// more than once, its repeats get a number to keep the keys unique, like EHAM/18R/001.
+func eachRunway(airportCode string, views []*runwayView, each func(string, interface{}) error) error {
+	sort.SliceStable(views, func(i, j int) bool { return views[i].RunwayCode < views[j].RunwayCode })
+	repeats := map[string]int{}
+	for _, view := range views {
+		key := airportCode + "/" + view.RunwayCode
+		if repeat := repeats[view.RunwayCode]; repeat != 0 {
+			key = fmt.Sprintf("%s/%03d", key, repeat)
+		}
+		repeats[view.RunwayCode]++
+		err := each(key, view)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exportFrequencies exports the frequencies of the airports, the key is like EHAM/003
+func exportFrequencies(after string, each func(string, interface{}) error) error {
+	return theAirports.Export(parentKey(after, "/"), func(airport *airports.Airport) error {
+		for i := range airport.Frequencies {
+			view := asFrequencyView(airport, i)
+			err := each(view.key, view)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// parentKey is the key of the country or airport a key starts with
+func parentKey(key string, separator string) string {
+	if i := strings.Index(key, separator); i >= 0 {
+		return key[:i]
+	}
+	return key
+}
+
+// authorized tells whether a request carries one of the keys as bearer token
+func authorized(r *http.Request, keys []string) bool {
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, "Bearer ") {
+		return false
+	}
+	token := []byte(strings.TrimSpace(strings.TrimPrefix(header, "Bearer ")))
+
+	result := false
+	for _, key := range keys {
+		if len(key) != 0 && subtle.ConstantTimeCompare(token, []byte(key)) == 1 {
+			result = true
+		}
+	}
+	return result
+}
+
+// exportWriter writes the response of an export, gzipped for CSV. The headers of a successful
+// export are only set when it starts, an error before that is a plain problem.
+type exportWriter struct {
+	http.ResponseWriter
+	name string
+	gzip *gzip.Writer
+}
+
+func (w *exportWriter) WriteHeader(status int) {
+	if status == http.StatusOK {
+		w.Header().Set("Trailer", exportStatus)
+		if w.gzip != nil {
+			w.Header().Set("Content-Type", "application/gzip")
+			w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.csv.gz"`, w.name))
+		}
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *exportWriter) Write(data []byte) (int, error) {
+	if w.gzip != nil {
+		return w.gzip.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+// Flush sends what was written so far
+func (w *exportWriter) Flush() {
+	if w.gzip != nil {
+		w.gzip.Flush()
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func getDatasetExport(w http.ResponseWriter, r *http.Request) {
+	if len(theExportKeys) == 0 {
+		httpError(w, "Exports are not enabled", http.StatusForbidden)
+		return
+	}
+	if !authorized(r, theExportKeys) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="export"`)
+		httpError(w, "Missing or invalid export key", http.StatusUnauthorized)
+		return
+	}
+
+	name := mux.Vars(r)["dataset"]
+	dataset, ok := exportDatasets[name]
+	if !ok {
+		httpError(w, fmt.Sprintf("Invalid Dataset(%s)", name), http.StatusNotFound)
+		return
+	}
+
+	format, err := negotiateFormat(r, exportFormats...)
+	if err != nil {
+		formatError(w, err)
+		return
+	}
+
+	version, err := theVersionCache.Get(dataset.version)
+	if err != nil {
+		failure(w, err)
+		return
+	}
+	current := strconv.FormatInt(version.Version, 10)
+	if asked := r.FormValue("version"); len(asked) != 0 && asked != current {
+		httpError(w, fmt.Sprintf("Version(%s): the %s are at version %s now, export them from the start",
+			asked, name, current), http.StatusPreconditionFailed)
+		return
+	}
+	w.Header().Set("X-Dataset-Version", current)
+
+	out := &exportWriter{ResponseWriter: w, name: name}
+	if format == formatCSV {
+		out.gzip = gzip.NewWriter(w)
+	}
+	writer := newListWriter(out, versionOf(r), format, name)
+	err = writer.setColumns(r, dataset.columns)
+	if err != nil {
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	after := strings.ToUpper(strings.TrimSpace(r.FormValue("after")))
+	count := 0
+	err = dataset.export(after, func(key string, item interface{}) error {
+		if len(after) != 0 && key <= after {
+			return nil
+		}
+		err := writer.write(item)
+		if err != nil {
+			return err
+		}
+		count++
+		if count%exportFlush == 0 {
+			return writer.flush()
+		}
+		return nil
+	})
+	if err != nil && !writer.started {
+		failure(w, err)
+		return
+	}
+
+	if err == nil {
+		err = writer.end()
+	}
+	if out.gzip != nil {
+		if closeErr := out.gzip.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		log.Printf("Export %s after %d records: %v", name, count, err)
+		w.Header().Set(exportStatus, "failed")
+		return
+	}
+	w.Header().Set(exportStatus, "complete")
+}
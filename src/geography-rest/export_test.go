@@ -0,0 +1,193 @@
+package main
+
+import (
+	"compress/gzip"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"../countries"
+	"../versions"
+)
+
+// testExport is a dataset of three records, the export fails after a record when failAfter is set
+func testExport(failAfter string) *exportDataset {
+	return &exportDataset{
+		version: versions.Airports,
+		columns: []string{"code", "name"},
+		export: func(after string, each func(string, interface{}) error) error {
+			for _, code := range []string{"A", "B", "C"} {
+				err := each(code, struct {
+					Code string `json:"code"`
+					Name string `json:"name"`
+				}{code, "Name " + code})
+				if err != nil {
+					return err
+				}
+				if code == failAfter {
+					return errors.New("Connection lost")
+				}
+			}
+			return nil
+		}}
+}
+
+func TestDatasetExport(t *testing.T) {
+	testVersions(map[string]*versions.Version{versions.Airports: {Dataset: versions.Airports, Version: 7}})
+	exportDatasets["test"] = testExport("")
+	exportDatasets["failing"] = testExport("B")
+	defer delete(exportDatasets, "test")
+	defer delete(exportDatasets, "failing")
+	defer func() { theExportKeys = nil }()
+
+	var tests = []struct {
+		keys          []string
+		authorization string
+		dataset       string
+		query         string
+		status        int
+		body          string
+		trailer       string
+	}{
+		{nil, "Bearer secret", "test", "", http.StatusForbidden, "", ""},
+		{[]string{"secret"}, "", "test", "", http.StatusUnauthorized, "", ""},
+		{[]string{"secret"}, "Bearer wrong", "test", "", http.StatusUnauthorized, "", ""},
+		{[]string{"other", "secret"}, "Bearer secret", "test", "",
+			http.StatusOK, `{"code":"A","name":"Name A"}` + "\n" + `{"code":"B","name":"Name B"}` + "\n" +
+				`{"code":"C","name":"Name C"}` + "\n", "complete"},
+		{[]string{"secret"}, "Bearer secret", "test", "?after=a&version=7",
+			http.StatusOK, `{"code":"B","name":"Name B"}` + "\n" + `{"code":"C","name":"Name C"}` + "\n", "complete"},
+		{[]string{"secret"}, "Bearer secret", "test", "?after=B&version=6", http.StatusPreconditionFailed, "", ""},
+		{[]string{"secret"}, "Bearer secret", "failing", "",
+			http.StatusOK, `{"code":"A","name":"Name A"}` + "\n" + `{"code":"B","name":"Name B"}` + "\n", "failed"},
+	}
+
+	for _, test := range tests {
+		theExportKeys = test.keys
+		request := httptest.NewRequest("GET", "/geography/export/"+test.dataset+test.query, nil)
+		if len(test.authorization) != 0 {
+			request.Header.Set("Authorization", test.authorization)
+		}
+		request = mux.SetURLVars(request, map[string]string{"dataset": test.dataset})
+		recorder := httptest.NewRecorder()
+		getDatasetExport(recorder, request)
+
+		response := recorder.Result()
+		if response.StatusCode != test.status {
+			t.Errorf("Export(%s%s) expected status %d, got %d", test.dataset, test.query, test.status,
+				response.StatusCode)
+			continue
+		}
+		if test.status != http.StatusOK {
+			continue
+		}
+		body, _ := ioutil.ReadAll(response.Body)
+		if string(body) != test.body {
+			t.Errorf("Export(%s%s) expected %q, got %q", test.dataset, test.query, test.body, body)
+		}
+		if version := response.Header.Get("X-Dataset-Version"); version != "7" {
+			t.Errorf("Export(%s%s) expected version 7, got %s", test.dataset, test.query, version)
+		}
+		if status := response.Trailer.Get(exportStatus); status != test.trailer {
+			t.Errorf("Export(%s%s) expected status %s, got %s", test.dataset, test.query, test.trailer, status)
+		}
+	}
+}
+
+func TestDatasetExportCSV(t *testing.T) {
+	testVersions(map[string]*versions.Version{versions.Airports: {Dataset: versions.Airports, Version: 7}})
+	exportDatasets["test"] = testExport("")
+	defer delete(exportDatasets, "test")
+	theExportKeys = []string{"secret"}
+	defer func() { theExportKeys = nil }()
+
+	request := httptest.NewRequest("GET", "/geography/export/test?format=csv&after=A", nil)
+	request.Header.Set("Authorization", "Bearer secret")
+	request = mux.SetURLVars(request, map[string]string{"dataset": "test"})
+	recorder := httptest.NewRecorder()
+	getDatasetExport(recorder, request)
+
+	response := recorder.Result()
+	if contentType := response.Header.Get("Content-Type"); contentType != "application/gzip" {
+		t.Fatalf("Export expected a gzipped result, got %s", contentType)
+	}
+	reader, err := gzip.NewReader(response.Body)
+	if err != nil {
+		t.Fatalf("Export expected a gzipped result: %v", err)
+	}
+	body, _ := ioutil.ReadAll(reader)
+	if expected := "code,name\nB,Name B\nC,Name C\n"; string(body) != expected {
+		t.Errorf("Export expected %q, got %q", expected, body)
+	}
+}
+
+func TestParentKey(t *testing.T) {
+	var tests = []struct {
+		key       string
+		separator string
+		parent    string
+	}{
+		{"EHAM/18R", "/", "EHAM"},
+		{"EHAM", "/", "EHAM"},
+		{"NL-NH", "-", "NL"},
+		{"", "-", ""},
+	}
+
+	for _, test := range tests {
+		if parent := parentKey(test.key, test.separator); parent != test.parent {
+			t.Errorf("parentKey(%s) expected %s, got %s", test.key, test.parent, parent)
+		}
+	}
+}
+
+// TestEachRegion checks that the keys of the regions are their ISO codes, the local codes of the
+// regions repeat over the countries
+func TestEachRegion(t *testing.T) {
+	var keys []string
+	for _, country := range []*countries.Country{
+		{CountryCode: "NL", Regions: []*countries.Region{{RegionCode: "NH"}, {RegionCode: "DR"}}},
+		{CountryCode: "US", Regions: []*countries.Region{{RegionCode: "AK"}}},
+	} {
+		eachRegion(country, func(key string, item interface{}) error {
+			keys = append(keys, key)
+			return nil
+		})
+	}
+
+	if strings.Join(keys, ",") != "NL-DR,NL-NH,US-AK" {
+		t.Errorf("eachRegion expected the keys NL-DR,NL-NH,US-AK, got %v", keys)
+	}
+	if parentKey(keys[1], "-") != "NL" || keys[2] <= keys[1] {
+		t.Errorf("eachRegion expected keys to resume after in the country they start with")
+	}
+}
+
+// TestEachRunway checks that the keys of the runway directions are unique and in order, also when
+// a code repeats
+func TestEachRunway(t *testing.T) {
+	views := []*runwayView{{RunwayCode: "18R", Length: 3800}, {RunwayCode: "09"}, {RunwayCode: "18R", Length: 1200},
+		{RunwayCode: "18RA"}}
+
+	var keys []string
+	var lengths []int
+	eachRunway("EHAM", views, func(key string, item interface{}) error {
+		keys = append(keys, key)
+		lengths = append(lengths, item.(*runwayView).Length)
+		return nil
+	})
+
+	expected := []string{"EHAM/09", "EHAM/18R", "EHAM/18R/001", "EHAM/18RA"}
+	if !reflect.DeepEqual(keys, expected) || lengths[1] != 3800 || lengths[2] != 1200 {
+		t.Errorf("eachRunway expected %v, got %v %v", expected, keys, lengths)
+	}
+	if !sort.StringsAreSorted(keys) {
+		t.Errorf("eachRunway expected keys to resume after, got %v", keys)
+	}
+}
@@ -0,0 +1,478 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"../geometry"
+)
+
+// Formats are the representations of the REST responses, chosen by the format parameter or the
+// Accept header. Lists are written one item at a time as they are paged, see paging.go.
+
+// The formats
+const (
+	formatJSON    = "json"
+	formatGeoJSON = "geojson"
+	formatCSV     = "csv"
+	formatNDJSON  = "ndjson"
+	formatXML     = "xml"
+)
+
+// contentTypes holds the Content-Type of each format
+var contentTypes = map[string]string{
+	formatJSON:    "application/json",
+	formatGeoJSON: "application/geo+json",
+	formatCSV:     "text/csv",
+	formatNDJSON:  "application/x-ndjson",
+	formatXML:     "application/xml",
+}
+
+// mediaTypes maps the media types in an Accept header to the formats
+var mediaTypes = map[string]string{
+	"application/json":     formatJSON,
+	"application/geo+json": formatGeoJSON,
+	"text/csv":             formatCSV,
+	"application/x-ndjson": formatNDJSON,
+	"application/xml":      formatXML,
+	"text/xml":             formatXML,
+}
+
+// errNotAcceptable is returned when the Accept header holds none of the formats
+var errNotAcceptable = fmt.Errorf("Not Acceptable")
+
+// negotiateFormat picks one of the formats of a resource, the first being the default. The
+// format parameter wins over the Accept header, a geometry parameter implies GeoJSON.
+func negotiateFormat(r *http.Request, formats ...string) (string, error) {
+	offered := func(format string) bool {
+		for _, candidate := range formats {
+			if candidate == format {
+				return true
+			}
+		}
+		return false
+	}
+
+	if format := r.FormValue("format"); len(format) != 0 {
+		if !offered(format) {
+			return "", fmt.Errorf("Invalid Format(%s), use %s", format, strings.Join(formats, ", "))
+		}
+		return format, nil
+	}
+	if len(r.FormValue("geometry")) != 0 && offered(formatGeoJSON) {
+		return formatGeoJSON, nil
+	}
+
+	accept := r.Header.Get("Accept")
+	if len(strings.TrimSpace(accept)) == 0 {
+		return formats[0], nil
+	}
+
+	// The media ranges in order of preference
+	type mediaRange struct {
+		mediaType string
+		quality   float64
+	}
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		fields := strings.Split(part, ";")
+		entry := mediaRange{mediaType: strings.ToLower(strings.TrimSpace(fields[0])), quality: 1}
+		for _, parameter := range fields[1:] {
+			parameter = strings.TrimSpace(parameter)
+			if strings.HasPrefix(parameter, "q=") {
+				quality, err := strconv.ParseFloat(parameter[2:], 64)
+				if err == nil {
+					entry.quality = quality
+				}
+			}
+		}
+		if entry.quality > 0 {
+			ranges = append(ranges, entry)
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].quality > ranges[j].quality
+	})
+
+	for _, entry := range ranges {
+		switch entry.mediaType {
+		case "*/*", "application/*":
+			return formats[0], nil
+		}
+		if format, ok := mediaTypes[entry.mediaType]; ok && offered(format) {
+			return format, nil
+		}
+	}
+
+	return "", errNotAcceptable
+}
+
+// formatError reports a failed negotiation
+func formatError(w http.ResponseWriter, err error) {
+	if err == errNotAcceptable {
+		httpError(w, err.Error(), http.StatusNotAcceptable)
+		return
+	}
+	httpError(w, err.Error(), http.StatusBadRequest)
+}
+
+// listWriter writes a list in one of the formats, the response starts with the first item
+type listWriter struct {
+	w        http.ResponseWriter
+	version  *apiVersion // the serialiser of JSON and XML
+	format   string
+	list     string                                // name of the list, like airports
+	columns  []string                              // of the CSV format
+	features func(interface{}) []*geometry.Feature // of the GeoJSON format
+	fields   []string                              // of the items, all when empty
+	count    int
+	started  bool
+	csv      *csv.Writer
+	xml      *xml.Encoder
+}
+
+// newListWriter sets up the writer of a list in a format of a version
+func newListWriter(w http.ResponseWriter, version *apiVersion, format string, list string) *listWriter {
+	return &listWriter{w: w, version: version, format: format, list: list}
+}
+
+// setColumns chooses the CSV columns from the columns parameter, all available columns by default.
+// With sparse fields only the columns of the fields are available.
+func (writer *listWriter) setColumns(r *http.Request, available []string) error {
+	if len(writer.fields) != 0 {
+		var columns []string
+		for _, column := range available {
+			for _, field := range writer.fields {
+				if column == field || strings.HasPrefix(column, field+".") {
+					columns = append(columns, column)
+					break
+				}
+			}
+		}
+		available = columns
+	}
+
+	if len(r.FormValue("columns")) == 0 {
+		writer.columns = available
+		return nil
+	}
+
+	writer.columns = nil
+	for _, column := range strings.Split(r.FormValue("columns"), ",") {
+		column = strings.TrimSpace(column)
+		found := false
+		for _, candidate := range available {
+			found = found || candidate == column
+		}
+		if !found {
+			return fmt.Errorf("Invalid Column(%s), use %s", column, strings.Join(available, ", "))
+		}
+		writer.columns = append(writer.columns, column)
+	}
+
+	return nil
+}
+
+func (writer *listWriter) begin() error {
+	writer.started = true
+	writer.w.Header().Set("Content-Type", contentTypes[writer.format])
+	writer.w.WriteHeader(http.StatusOK)
+
+	var err error
+	switch writer.format {
+	case formatJSON:
+		_, err = io.WriteString(writer.w, "[")
+	case formatGeoJSON:
+		_, err = io.WriteString(writer.w, `{"type":"FeatureCollection","features":[`)
+	case formatCSV:
+		writer.csv = csv.NewWriter(writer.w)
+		err = writer.csv.Write(writer.columns)
+	case formatXML:
+		_, err = io.WriteString(writer.w, xml.Header)
+		if err == nil {
+			writer.xml = xml.NewEncoder(writer.w)
+			err = writer.xml.EncodeToken(xml.StartElement{Name: xml.Name{Local: writer.list}})
+		}
+	}
+	return err
+}
+
+// write adds an item to the list, the first item starts the response
+func (writer *listWriter) write(item interface{}) error {
+	if !writer.started {
+		err := writer.begin()
+		if err != nil {
+			return err
+		}
+	}
+
+	var err error
+	switch writer.format {
+	case formatJSON, formatNDJSON, formatXML:
+		if len(writer.fields) != 0 {
+			item, err = sparse(item, writer.fields)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	switch writer.format {
+	case formatJSON, formatNDJSON:
+		err = writer.writeJSON(item, writer.version.marshal)
+	case formatGeoJSON:
+		for i, feature := range writer.features(item) {
+			// The first feature is the item itself, the others are its parts like runways
+			if i == 0 && len(writer.fields) != 0 {
+				for name := range feature.Properties {
+					if !containsField(writer.fields, name) && name != "feature-type" {
+						delete(feature.Properties, name)
+					}
+				}
+			}
+			// GeoJSON is the same in every version
+			err = writer.writeJSON(feature, json.Marshal)
+			if err != nil {
+				break
+			}
+		}
+	case formatCSV:
+		err = writer.writeCSV(item)
+	case formatXML:
+		err = writer.writeXML(item)
+	}
+	return err
+}
+
+// end closes the list, an empty list is still a valid document
+func (writer *listWriter) end() error {
+	if !writer.started {
+		err := writer.begin()
+		if err != nil {
+			return err
+		}
+	}
+
+	var err error
+	switch writer.format {
+	case formatJSON:
+		_, err = io.WriteString(writer.w, "]\n")
+	case formatGeoJSON:
+		_, err = io.WriteString(writer.w, "]}\n")
+	case formatCSV:
+		writer.csv.Flush()
+		err = writer.csv.Error()
+	case formatXML:
+		err = writer.xml.EncodeToken(xml.EndElement{Name: xml.Name{Local: writer.list}})
+		if err == nil {
+			err = writer.xml.Flush()
+		}
+	}
+	return err
+}
+
+// flush sends what was written so far, for a long list that is streamed
+func (writer *listWriter) flush() error {
+	if writer.csv != nil {
+		writer.csv.Flush()
+		if err := writer.csv.Error(); err != nil {
+			return err
+		}
+	}
+	if writer.xml != nil {
+		if err := writer.xml.Flush(); err != nil {
+			return err
+		}
+	}
+	if flusher, ok := writer.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+	return nil
+}
+
+func (writer *listWriter) writeJSON(item interface{}, marshal func(interface{}) ([]byte, error)) error {
+	encoded, err := marshal(item)
+	if err != nil {
+		return err
+	}
+
+	if writer.format == formatNDJSON {
+		encoded = append(encoded, '\n')
+	} else if writer.count > 0 {
+		encoded = append([]byte{','}, encoded...)
+	}
+	writer.count++
+
+	_, err = writer.w.Write(encoded)
+	return err
+}
+
+// writeCSV writes the columns of an item, nested fields are named like centroid.latitude
+func (writer *listWriter) writeCSV(item interface{}) error {
+	fields, err := flatten(item)
+	if err != nil {
+		return err
+	}
+
+	record := make([]string, len(writer.columns))
+	for i, column := range writer.columns {
+		record[i] = fields[column]
+	}
+	return writer.csv.Write(record)
+}
+
+// writeXML writes an item as an element named after the list, with an element per JSON field
+func (writer *listWriter) writeXML(item interface{}) error {
+	encoded, err := writer.version.marshal(item)
+	if err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	err = writeXMLValue(writer.xml, decoder, singular(writer.list))
+	if err != nil {
+		return err
+	}
+	return writer.xml.Flush()
+}
+
+// writeXMLValue converts the next JSON value into an element, keeping the order of the fields
+func writeXMLValue(encoder *xml.Encoder, decoder *json.Decoder, name string) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	switch value := token.(type) {
+	case json.Delim:
+		err = encoder.EncodeToken(start)
+		for err == nil && decoder.More() {
+			if value == '{' {
+				var key json.Token
+				key, err = decoder.Token()
+				if err == nil {
+					err = writeXMLValue(encoder, decoder, key.(string))
+				}
+			} else {
+				err = writeXMLValue(encoder, decoder, singular(name))
+			}
+		}
+		if err != nil {
+			return err
+		}
+		// The closing delimiter
+		_, err = decoder.Token()
+		if err != nil {
+			return err
+		}
+		return encoder.EncodeToken(start.End())
+	case nil:
+		return encoder.EncodeElement("", start)
+	default:
+		return encoder.EncodeElement(fmt.Sprint(value), start)
+	}
+}
+
+// sparseItem is an item limited to some of its fields, in the order they were asked for
+type sparseItem struct {
+	id     string // of the resource
+	fields []string
+	values map[string]json.RawMessage
+}
+
+// sparse limits an item to some of its JSON fields, fields that are omitted stay omitted. The
+// links of the item are kept.
+func sparse(item interface{}, fields []string) (interface{}, error) {
+	encoded, err := json.Marshal(item)
+	if err != nil {
+		return nil, err
+	}
+
+	result := sparseItem{id: resourceID(item), fields: withFields(fields, []string{"_links"})}
+	err = json.Unmarshal(encoded, &result.values)
+	return result, err
+}
+
+func (item sparseItem) MarshalJSON() ([]byte, error) {
+	var buffer bytes.Buffer
+	buffer.WriteByte('{')
+	for _, field := range item.fields {
+		value, ok := item.values[field]
+		if !ok {
+			continue
+		}
+		if buffer.Len() > 1 {
+			buffer.WriteByte(',')
+		}
+		name, _ := json.Marshal(field)
+		buffer.Write(name)
+		buffer.WriteByte(':')
+		buffer.Write(value)
+	}
+	buffer.WriteByte('}')
+	return buffer.Bytes(), nil
+}
+
+func containsField(fields []string, name string) bool {
+	for _, field := range fields {
+		if field == name {
+			return true
+		}
+	}
+	return false
+}
+
+// singular names an element of a list, like runway in runways
+func singular(name string) string {
+	switch {
+	case strings.HasSuffix(name, "ies"):
+		return strings.TrimSuffix(name, "ies") + "y"
+	case strings.HasSuffix(name, "s"):
+		return strings.TrimSuffix(name, "s")
+	}
+	return "item"
+}
+
+// flatten turns the JSON fields of an item into text, nested objects are named with dots and
+// lists are left out
+func flatten(item interface{}) (map[string]string, error) {
+	encoded, err := json.Marshal(item)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	err = decoder.Decode(&fields)
+	if err != nil {
+		return nil, err
+	}
+
+	result := map[string]string{}
+	var add func(prefix string, fields map[string]interface{})
+	add = func(prefix string, fields map[string]interface{}) {
+		for key, value := range fields {
+			switch v := value.(type) {
+			case map[string]interface{}:
+				add(prefix+key+".", v)
+			case []interface{}, nil:
+			default:
+				result[prefix+key] = fmt.Sprint(v)
+			}
+		}
+	}
+	add("", fields)
+
+	return result, nil
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"../geometry"
+)
+
+func TestNegotiateFormat(t *testing.T) {
+	var tests = []struct {
+		url     string
+		accept  string
+		formats []string
+		format  string
+		correct bool
+	}{
+		{"/", "", listFormats, formatJSON, true},
+		{"/", "*/*", listFormats, formatJSON, true},
+		{"/?format=csv", "application/json", listFormats, formatCSV, true},
+		{"/?format=kml", "", listFormats, "", false},
+		{"/?format=geojson", "", []string{formatJSON}, "", false},
+		{"/?geometry=full", "", listFormats, formatGeoJSON, true},
+		{"/", "application/geo+json", listFormats, formatGeoJSON, true},
+		{"/", "text/xml", listFormats, formatXML, true},
+		{"/", "text/csv;q=0.5, application/x-ndjson", listFormats, formatNDJSON, true},
+		{"/", "application/x-ndjson;q=0, text/csv", listFormats, formatCSV, true},
+		{"/", "image/png", listFormats, "", false},
+		{"/", "image/png, */*;q=0.1", listFormats, formatJSON, true},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest("GET", test.url, nil)
+		r.Header.Set("Accept", test.accept)
+		format, err := negotiateFormat(r, test.formats...)
+		if (test.correct && err != nil) || (!test.correct && err == nil) || format != test.format {
+			t.Errorf("negotiateFormat(%s, %s) expected %s, got %s (%v)", test.url, test.accept, test.format, format, err)
+		}
+	}
+}
+
+type testItem struct {
+	Code     string             `json:"code"`
+	Name     string             `json:"name"`
+	Centroid *geometry.Position `json:"centroid,omitempty"`
+	Runways  []string           `json:"runways,omitempty"`
+}
+
+var testItems = []*testItem{
+	{Code: "EHAM", Name: "Schiphol & Co", Centroid: &geometry.Position{Latitude: 52.3, Longitude: 4.7},
+		Runways: []string{"09/27"}},
+	{Code: "EHRD", Name: "Rotterdam"},
+}
+
+func writeTestList(format string, columns string) string {
+	w := httptest.NewRecorder()
+	writer := newListWriter(w, apiV1, format, "airports")
+	writer.setColumns(httptest.NewRequest("GET", "/?columns="+columns, nil),
+		[]string{"code", "name", "centroid.latitude"})
+	writer.features = func(item interface{}) []*geometry.Feature {
+		return []*geometry.Feature{geometry.NewFeature(nil, item)}
+	}
+	for _, item := range testItems {
+		writer.write(item)
+	}
+	writer.end()
+	return w.Body.String()
+}
+
+func TestListWriter(t *testing.T) {
+	var tests = []struct {
+		format  string
+		columns string
+		result  string
+	}{
+		{formatJSON, "", `[{"code":"EHAM","name":"Schiphol \u0026 Co","centroid":{"latitude":52.3,"longitude":4.7},` +
+			`"runways":["09/27"]},{"code":"EHRD","name":"Rotterdam"}]` + "\n"},
+		{formatNDJSON, "", `{"code":"EHAM","name":"Schiphol \u0026 Co","centroid":{"latitude":52.3,"longitude":4.7},` +
+			`"runways":["09/27"]}` + "\n" + `{"code":"EHRD","name":"Rotterdam"}` + "\n"},
+		{formatCSV, "", "code,name,centroid.latitude\nEHAM,Schiphol & Co,52.3\nEHRD,Rotterdam,\n"},
+		{formatCSV, "name,code", "name,code\nSchiphol & Co,EHAM\nRotterdam,EHRD\n"},
+		{formatXML, "", `<?xml version="1.0" encoding="UTF-8"?>` + "\n" + `<airports>` +
+			`<airport><code>EHAM</code><name>Schiphol &amp; Co</name>` +
+			`<centroid><latitude>52.3</latitude><longitude>4.7</longitude></centroid>` +
+			`<runways><runway>09/27</runway></runways></airport>` +
+			`<airport><code>EHRD</code><name>Rotterdam</name></airport></airports>`},
+		{formatGeoJSON, "code", `{"type":"FeatureCollection","features":[{"type":"Feature","properties":` +
+			`{"centroid":{"latitude":52.3,"longitude":4.7},"code":"EHAM","name":"Schiphol \u0026 Co",` +
+			`"runways":["09/27"]},"geometry":null},{"type":"Feature","properties":{"code":"EHRD",` +
+			`"name":"Rotterdam"},"geometry":null}]}` + "\n"},
+	}
+
+	for _, test := range tests {
+		if result := writeTestList(test.format, test.columns); result != test.result {
+			t.Errorf("listWriter(%s) expected\n%s\ngot\n%s", test.format, test.result, result)
+		}
+	}
+}
+
+func TestSetColumns(t *testing.T) {
+	writer := newListWriter(httptest.NewRecorder(), apiV1, formatCSV, "airports")
+	err := writer.setColumns(httptest.NewRequest("GET", "/?columns=code,runways", nil), []string{"code", "name"})
+	if err == nil {
+		t.Errorf("setColumns expected an error for an unknown column")
+	}
+}
+
+func TestEmptyList(t *testing.T) {
+	w := httptest.NewRecorder()
+	writer := newListWriter(w, apiV1, formatJSON, "airports")
+	writer.end()
+	if w.Body.String() != "[]\n" || w.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("end expected an empty list, got %s", w.Body.String())
+	}
+}
+
+func TestSparseFields(t *testing.T) {
+	var tests = []struct {
+		format string
+		result string
+	}{
+		{formatJSON, `[{"name":"Schiphol \u0026 Co","code":"EHAM"},{"name":"Rotterdam","code":"EHRD"}]` + "\n"},
+		{formatCSV, "code,name\nEHAM,Schiphol & Co\nEHRD,Rotterdam\n"},
+		{formatGeoJSON, `{"type":"FeatureCollection","features":[{"type":"Feature","properties":` +
+			`{"code":"EHAM","name":"Schiphol \u0026 Co"},"geometry":null},{"type":"Feature","properties":` +
+			`{"code":"EHRD","name":"Rotterdam"},"geometry":null}]}` + "\n"},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		writer := newListWriter(w, apiV1, test.format, "airports")
+		writer.fields = []string{"name", "code"}
+		writer.setColumns(httptest.NewRequest("GET", "/", nil), []string{"code", "name", "centroid.latitude"})
+		writer.features = func(item interface{}) []*geometry.Feature {
+			return []*geometry.Feature{geometry.NewFeature(nil, item)}
+		}
+		for _, item := range testItems {
+			writer.write(item)
+		}
+		writer.end()
+		if w.Body.String() != test.result {
+			t.Errorf("listWriter(%s) with fields expected\n%s\ngot\n%s", test.format, test.result, w.Body.String())
+		}
+	}
+}
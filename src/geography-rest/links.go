@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+
+	"../airports"
+	"../countries"
+)
+
+// Every resource links to the resources related to it in _links, in the way of HAL, so clients
+// can walk from a country to its regions and airports and from an airport to its runways and
+// frequencies without building the paths themselves. The links are paths on this server.
+
+// link is a link to a related resource
+type link struct {
+	Href string `json:"href"`
+}
+
+// links are the links of a resource by relation, self is the resource itself
+type links map[string]*link
+
+// linkFields are the fields of an airport its links are made of, they are read also when the
+// airports are limited to other fields
+var linkFields = []string{"icao-airport-code", "iso-country-code", "iso-region-code"}
+
+// countryView is a country with its links
+type countryView struct {
+	*countries.Country
+	Links links `json:"_links"`
+}
+
+// airportView is an airport with its links
+type airportView struct {
+	*airports.Airport
+	Links links `json:"_links"`
+}
+
+// add adds a link, a path without a code is left out
+func (all links) add(relation string, code string, format string, args ...interface{}) {
+	if len(code) != 0 {
+		all[relation] = &link{Href: fmt.Sprintf(format, args...)}
+	}
+}
+
+func countryLinks(countryCode string) links {
+	result := links{}
+	result.add("self", countryCode, "/geography/countries/%s", countryCode)
+	result.add("regions", countryCode, "/geography/countries/%s/regions", countryCode)
+	result.add("airports", countryCode, "/geography/countries/%s/airports", countryCode)
+	return result
+}
+
+// regionLinks are the links of a region, its code is within the country like NH
+func regionLinks(countryCode string, regionCode string) links {
+	result := links{}
+	result.add("self", regionCode, "/geography/regions/%s", regionKey(countryCode, regionCode))
+	result.add("country", countryCode, "/geography/countries/%s", countryCode)
+	if len(countryCode) != 0 {
+		result.add("airports", regionCode, "/geography/countries/%s/regions/%s/airports", countryCode, regionCode)
+	}
+	return result
+}
+
+func airportLinks(airport *airports.Airport) links {
+	result := links{}
+	code := airport.AirportCode
+	result.add("self", code, "/geography/airports/%s", code)
+	result.add("country", airport.CountryCode, "/geography/countries/%s", airport.CountryCode)
+	result.add("region", airport.RegionCode, "/geography/regions/%s", regionKey(airport.CountryCode,
+		airport.RegionCode))
+	result.add("runways", code, "/geography/airports/%s/runways", code)
+	result.add("frequencies", code, "/geography/airports/%s/frequencies", code)
+	return result
+}
+
+func runwayLinks(airportCode string, runwayCode string) links {
+	result := links{}
+	result.add("self", runwayCode, "/geography/airports/%s/runways/%s", airportCode, runwayCode)
+	result.add("airport", airportCode, "/geography/airports/%s", airportCode)
+	return result
+}
+
+func frequencyLinks(airportCode string) links {
+	result := links{}
+	result.add("airport", airportCode, "/geography/airports/%s", airportCode)
+	return result
+}
+
+func asCountryView(country *countries.Country) *countryView {
+	return &countryView{Country: country, Links: countryLinks(country.CountryCode)}
+}
+
+func asAirportView(airport *airports.Airport) *airportView {
+	return &airportView{Airport: airport, Links: airportLinks(airport)}
+}
+
+// withFields adds the fields a list of fields lacks
+func withFields(fields []string, more []string) []string {
+	result := append([]string{}, fields...)
+	for _, field := range more {
+		if !containsField(result, field) {
+			result = append(result, field)
+		}
+	}
+	return result
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"../airports"
+	"../countries"
+)
+
+func TestLinks(t *testing.T) {
+	hrefs := func(all links) map[string]string {
+		result := map[string]string{}
+		for relation, link := range all {
+			result[relation] = link.Href
+		}
+		return result
+	}
+
+	var tests = []struct {
+		name     string
+		links    links
+		expected map[string]string
+	}{
+		{"country", asCountryView(&countries.Country{CountryCode: "NL"}).Links, map[string]string{
+			"self":     "/geography/countries/NL",
+			"regions":  "/geography/countries/NL/regions",
+			"airports": "/geography/countries/NL/airports"}},
+		{"region", asRegionView(&countries.Country{CountryCode: "NL"},
+			&countries.Region{RegionCode: "NH"}).Links, map[string]string{
+			"self":     "/geography/regions/NL-NH",
+			"country":  "/geography/countries/NL",
+			"airports": "/geography/countries/NL/regions/NH/airports"}},
+		{"airport", asAirportView(&airports.Airport{AirportCode: "EHAM", CountryCode: "NL",
+			RegionCode: "NH"}).Links, map[string]string{
+			"self":        "/geography/airports/EHAM",
+			"country":     "/geography/countries/NL",
+			"region":      "/geography/regions/NL-NH",
+			"runways":     "/geography/airports/EHAM/runways",
+			"frequencies": "/geography/airports/EHAM/frequencies"}},
+		{"airport without region", asAirportView(&airports.Airport{AirportCode: "EHAM",
+			CountryCode: "NL"}).Links, map[string]string{
+			"self":        "/geography/airports/EHAM",
+			"country":     "/geography/countries/NL",
+			"runways":     "/geography/airports/EHAM/runways",
+			"frequencies": "/geography/airports/EHAM/frequencies"}},
+		{"runway", runwayLinks("EHAM", "18R"), map[string]string{
+			"self":    "/geography/airports/EHAM/runways/18R",
+			"airport": "/geography/airports/EHAM"}},
+		{"frequency", asFrequencyView(&airports.Airport{AirportCode: "EHAM",
+			Frequencies: []*airports.Frequency{{}}}, 0).Links,
+			map[string]string{"airport": "/geography/airports/EHAM"}},
+	}
+
+	for _, test := range tests {
+		if result := hrefs(test.links); !reflect.DeepEqual(result, test.expected) {
+			t.Errorf("Links of %s expected %v, got %v", test.name, test.expected, result)
+		}
+	}
+}
+
+func TestAirportViewJSON(t *testing.T) {
+	view := asAirportView(&airports.Airport{AirportCode: "EHAM", AirportName: "Schiphol", CountryCode: "NL"})
+
+	encoded, _ := json.Marshal(view)
+	var fields map[string]interface{}
+	json.Unmarshal(encoded, &fields)
+	if fields["icao-airport-code"] != "EHAM" || fields["_links"] == nil {
+		t.Errorf("airportView expected the fields of the airport and its links, got %s", encoded)
+	}
+
+	item, _ := sparse(view, []string{"airport-name"})
+	encoded, _ = json.Marshal(item)
+	expected := `{"airport-name":"Schiphol","_links":{"country":{"href":"/geography/countries/NL"},` +
+		`"frequencies":{"href":"/geography/airports/EHAM/frequencies"},` +
+		`"runways":{"href":"/geography/airports/EHAM/runways"},"self":{"href":"/geography/airports/EHAM"}}}`
+	if string(encoded) != expected {
+		t.Errorf("sparse expected to keep the links, got %s", encoded)
+	}
+}
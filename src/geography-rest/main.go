@@ -2,89 +2,557 @@ package main
 
 import (
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gorilla/mux"
 
 	"../airports"
 	"../application"
 	"../countries"
+	"../datatypes"
+	"../failures"
+	"../geometry"
 	"../graphql"
+	"../magnetic"
+	"../paging"
+	"../search"
+	"../versions"
+	"../weather"
 )
 
 var theCountries *countries.Countries
 
 //var theRegions *countries.Regions
 var theAirports *airports.Airports
+var theWeather *weather.Reports
+var theMagnetic *magnetic.Model
+var theBoundaries *countries.Boundaries
+var theVersions *versions.Versions
+
+// airportColumns are the CSV columns of airports
+var airportColumns = []string{"icao-airport-code", "iata-airport-code", "airport-name", "airport-type",
+	"latitude", "longitude", "elevation", "iso-country-code", "iso-region-code", "municipality",
+	"time-zone", "website", "wikipedia"}
+
+// countryColumns are the CSV columns of countries
+var countryColumns = []string{"iso-country-code", "country-name", "continent", "wikipedia",
+	"centroid.latitude", "centroid.longitude", "bounds.min-latitude", "bounds.min-longitude",
+	"bounds.max-latitude", "bounds.max-longitude"}
+
+// weatherView is the latest weather of an airport, with the reports of the past hours on request
+type weatherView struct {
+	Metar   *weather.Metar    `json:"metar,omitempty"`
+	Taf     *weather.Taf      `json:"taf,omitempty"`
+	History []*weather.Report `json:"history,omitempty"`
+}
+
+// variationView is the magnetic field at a position, elevation and date
+type variationView struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Elevation int     `json:"elevation"`
+	Date      string  `json:"date"`
+	*magnetic.Field
+}
+
+// listFormats are the formats of the lists and their items
+var listFormats = []string{formatJSON, formatGeoJSON, formatCSV, formatNDJSON, formatXML}
+
+// airportFeatures represents an airport in GeoJSON as a point followed by its runways
+func airportFeatures(item interface{}) []*geometry.Feature {
+	airport := item.(*airportView).Airport
+	return append([]*geometry.Feature{airport.Feature()}, airport.RunwayFeatures()...)
+}
+
+// geometryTolerance reads the detail of country geometries: geometry is simplified (the
+// default) or full, tolerance overrides it in degrees
+func geometryTolerance(r *http.Request) (float64, error) {
+	var tolerance float64
+	switch r.FormValue("geometry") {
+	case "", "simplified":
+		tolerance = countries.SimplifiedTolerance
+	case "full":
+		tolerance = 0
+	default:
+		return 0, fmt.Errorf("Invalid Geometry, use simplified or full")
+	}
+
+	if len(r.FormValue("tolerance")) != 0 {
+		value, err := strconv.ParseFloat(r.FormValue("tolerance"), 64)
+		if err != nil || value < 0 {
+			return 0, fmt.Errorf("Invalid Tolerance")
+		}
+		tolerance = value
+	}
+
+	return tolerance, nil
+}
 
 func getCountries(w http.ResponseWriter, r *http.Request) {
 
 	fromCountry := r.FormValue("from")
 	untilCountry := r.FormValue("until")
 
-	countryList, err := theCountries.GetList(fromCountry, untilCountry)
+	format, err := negotiateFormat(r, listFormats...)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		formatError(w, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	result := json.NewEncoder(w)
-	result.Encode(countryList)
+	tolerance, err := geometryTolerance(r)
+	if err != nil {
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	request, err := pageRequest(r)
+	if err != nil {
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writer := newListWriter(w, versionOf(r), format, "countries")
+	err = pageShape(r, request, writer, countries.Fields, countries.SortFields)
+	if err != nil {
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	err = writer.setColumns(r, countryColumns)
+	if err != nil {
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writer.features = func(item interface{}) []*geometry.Feature {
+		return []*geometry.Feature{theBoundaries.Feature(item.(*countryView).Country, tolerance)}
+	}
+
+	countryList, page, err := theCountries.GetPage(fromCountry, untilCountry, request)
+	if versionOf(r).emptyList(err) {
+		countryList, page, err = nil, &paging.Page{}, nil
+	}
+	if err != nil {
+		failure(w, err)
+		return
+	}
+
+	setPageHeaders(w, r, page)
+	for _, country := range countryList {
+		writer.write(asCountryView(country))
+	}
+	writer.end()
 }
 
 func getCountry(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	countryCode := vars["country-code"]
 
+	format, err := negotiateFormat(r, listFormats...)
+	if err != nil {
+		formatError(w, err)
+		return
+	}
+
 	country, err := theCountries.GetByCountryCode(countryCode)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		failure(w, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	result := json.NewEncoder(w)
-	result.Encode(country)
+	switch format {
+	case formatJSON:
+		writeValue(w, r, asCountryView(country))
+		return
+	case formatGeoJSON:
+		// As GeoJSON the country is a feature with its area
+		tolerance, err := geometryTolerance(r)
+		if err != nil {
+			httpError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		area, err := theBoundaries.GetGeometry(country.CountryCode, "", tolerance)
+		if err != nil {
+			failure(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/geo+json")
+		w.WriteHeader(http.StatusOK)
+		result := json.NewEncoder(w)
+		result.Encode(geometry.NewFeature(geometry.NewGeometry(area), country, "regions"))
+		return
+	}
+
+	// The other formats only know lists
+	writer := newListWriter(w, versionOf(r), format, "countries")
+	err = writer.setColumns(r, countryColumns)
+	if err != nil {
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writer.write(asCountryView(country))
+	writer.end()
 }
 
 func getAirports(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
 	countryCode := r.FormValue("country")
 	regionCode := r.FormValue("region")
+	// The airports of a country or region, like /geography/countries/NL/airports
+	if code, ok := vars["country-code"]; ok {
+		countryCode = code
+	}
+	if code, ok := vars["region-code"]; ok {
+		regionCode = code
+	}
 	fromICAO := r.FormValue("from")
 	untilICAO := r.FormValue("until")
 	fromIATA := r.FormValue("from-iata")
 	untilIATA := r.FormValue("until-iata")
+	filter := r.FormValue("filter")
 
-	airportList, err := theAirports.GetList(countryCode, regionCode, fromICAO, untilICAO, fromIATA, untilIATA)
+	format, err := negotiateFormat(r, listFormats...)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		formatError(w, err)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	result := json.NewEncoder(w)
-	result.Encode(airportList)
+	request, err := pageRequest(r)
+	if err != nil {
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	writer := newListWriter(w, versionOf(r), format, "airports")
+	err = pageShape(r, request, writer, airports.Fields, airports.SortFields)
+	if err != nil {
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	err = writer.setColumns(r, airportColumns)
+	if err != nil {
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writer.features = airportFeatures
+	if format == formatGeoJSON && len(request.Fields) != 0 {
+		// The position of the airports is needed for their features
+		request.Fields = append([]string{"latitude", "longitude"}, request.Fields...)
+	}
+	if len(request.Fields) != 0 {
+		request.Fields = withFields(request.Fields, linkFields)
+	}
+
+	airportList, page, err := theAirports.GetPage(countryCode, regionCode, fromICAO, untilICAO, fromIATA, untilIATA,
+		filter, request)
+	if versionOf(r).emptyList(err) {
+		airportList, page, err = nil, &paging.Page{}, nil
+	}
+	if err != nil {
+		failure(w, err)
+		return
+	}
+
+	setPageHeaders(w, r, page)
+	for _, airport := range airportList {
+		writer.write(asAirportView(airport))
+	}
+	writer.end()
 }
 
 func getAirport(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	airportCode := vars["airport-code"]
 
+	format, err := negotiateFormat(r, listFormats...)
+	if err != nil {
+		formatError(w, err)
+		return
+	}
+
 	region, err := theAirports.GetByAirportCode(airportCode)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusNotFound)
+		failure(w, err)
+		return
+	}
+
+	if format == formatJSON {
+		writeValue(w, r, asAirportView(region))
+		return
+	}
+
+	// The other formats only know lists, in GeoJSON the runways make it a list anyway
+	writer := newListWriter(w, versionOf(r), format, "airports")
+	err = writer.setColumns(r, airportColumns)
+	if err != nil {
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writer.features = airportFeatures
+	writer.write(asAirportView(region))
+	writer.end()
+}
+
+func getRunwayWinds(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	airportCode := vars["airport-code"]
+
+	direction, err := datatypes.WindDirection(r.FormValue("direction"), false)
+	if err != nil {
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	speed, err := datatypes.WindSpeed(r.FormValue("speed"), false)
+	if err != nil {
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	crosswindLimit, err := datatypes.WindSpeed(r.FormValue("crosswind-limit"), true)
+	if err != nil {
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(r.FormValue("crosswind-limit")) == 0 {
+		crosswindLimit = theAirports.CrosswindLimit()
+	}
+
+	airport, err := theAirports.GetByAirportCode(airportCode)
+	if err != nil {
+		failure(w, err)
+		return
+	}
+
+	writeValue(w, r, airport.RunwayWinds(direction, speed, crosswindLimit))
+}
+
+func getWeather(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	airportCode := vars["airport-code"]
+
+	var result weatherView
+
+	// Missing reports are left out, any other failure fails the request
+	metar, err := theWeather.GetLatest(airportCode, weather.METAR)
+	if err == nil {
+		result.Metar = metar.Metar
+	} else if failures.KindOf(err) != failures.NotFound {
+		failure(w, err)
+		return
+	}
+
+	taf, err := theWeather.GetLatest(airportCode, weather.TAF)
+	if err == nil {
+		result.Taf = taf.Taf
+	} else if failures.KindOf(err) != failures.NotFound {
+		failure(w, err)
+		return
+	}
+
+	if len(r.FormValue("history")) != 0 {
+		hours, err := strconv.Atoi(r.FormValue("history"))
+		if err != nil || hours <= 0 {
+			httpError(w, "Invalid History", http.StatusBadRequest)
+			return
+		}
+		result.History, err = theWeather.GetHistory(airportCode, time.Now().UTC().Add(-time.Duration(hours)*time.Hour))
+		if err != nil && failures.KindOf(err) != failures.NotFound {
+			failure(w, err)
+			return
+		}
+	}
+
+	if result.Metar == nil && result.Taf == nil && len(result.History) == 0 {
+		httpError(w, "Not Found", http.StatusNotFound)
+		return
+	}
+
+	writeValue(w, r, result)
+}
+
+func getDaylight(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	airportCode := vars["airport-code"]
+
+	date, err := datatypes.Date(r.FormValue("date"), true)
+	if err != nil {
+		httpError(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
+	airport, err := theAirports.GetByAirportCode(airportCode)
+	if err != nil {
+		failure(w, err)
+		return
+	}
+	if date.IsZero() {
+		date = airport.Today()
+	}
+
+	writeValue(w, r, airport.Daylight(date))
+}
+
+func getDiagram(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	airportCode := vars["airport-code"]
+
+	options := airports.DiagramOptions{Units: r.FormValue("units")}
+	if len(r.FormValue("size")) != 0 {
+		size, err := strconv.Atoi(r.FormValue("size"))
+		if err != nil {
+			httpError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		options.Size = size
+	}
+	if len(r.FormValue("dark")) != 0 {
+		dark, err := strconv.ParseBool(r.FormValue("dark"))
+		if err != nil {
+			httpError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		options.Dark = dark
+	}
+
+	airport, err := theAirports.GetByAirportCode(airportCode)
+	if err != nil {
+		failure(w, err)
+		return
+	}
+
+	diagram, err := airport.Diagram(options)
+	if err != nil {
+		failure(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/svg+xml")
 	w.WriteHeader(http.StatusOK)
-	result := json.NewEncoder(w)
-	result.Encode(region)
+	w.Write(diagram)
+}
+
+func getExport(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	compressed := vars["format"] == "kmz"
+
+	selection, err := airports.NewSelection(r.FormValue("country"), r.FormValue("region"),
+		r.FormValue("bbox"), r.FormValue("airports"))
+	if err != nil {
+		failure(w, err)
+		return
+	}
+
+	// The response starts with the first airport, until then an error can be reported
+	writer := airports.NewKMLWriter(w, "Airports", compressed)
+	err = theAirports.Select(selection, func(airport *airports.Airport) error {
+		if !writer.Started() {
+			if compressed {
+				w.Header().Set("Content-Type", "application/vnd.google-earth.kmz")
+			} else {
+				w.Header().Set("Content-Type", "application/vnd.google-earth.kml+xml")
+			}
+			w.Header().Set("Content-Disposition", "attachment; filename=airports."+vars["format"])
+			w.WriteHeader(http.StatusOK)
+		}
+		return writer.Write(airport)
+	})
+	if err != nil {
+		if !writer.Started() {
+			failure(w, err)
+			return
+		}
+		log.Printf("Export: %v", err)
+	}
+	writer.Close()
+}
+
+func getLocate(w http.ResponseWriter, r *http.Request) {
+	latitude, err := datatypes.Latitude(r.FormValue("lat"), false)
+	if err != nil {
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	longitude, err := datatypes.Longitude(r.FormValue("lon"), false)
+	if err != nil {
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	location, err := theBoundaries.Locate(latitude, longitude)
+	if err != nil {
+		failure(w, err)
+		return
+	}
+
+	writeValue(w, r, location)
+}
+
+func getTile(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var address [3]int
+	for i, name := range []string{"z", "x", "y"} {
+		value, err := strconv.Atoi(vars[name])
+		if err != nil {
+			httpError(w, "Invalid Tile", http.StatusBadRequest)
+			return
+		}
+		address[i] = value
+	}
+
+	tile, err := theAirports.Tile(address[0], address[1], address[2])
+	if err != nil {
+		failure(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.mapbox-vector-tile")
+	w.WriteHeader(http.StatusOK)
+	w.Write(tile)
+}
+
+func getMagneticVariation(w http.ResponseWriter, r *http.Request) {
+	latitude, err := datatypes.Latitude(r.FormValue("lat"), false)
+	if err != nil {
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	longitude, err := datatypes.Longitude(r.FormValue("lon"), false)
+	if err != nil {
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	elevation, err := datatypes.Elevation(r.FormValue("elevation"), true)
+	if err != nil {
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	date, err := datatypes.Date(r.FormValue("date"), true)
+	if err != nil {
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if date.IsZero() {
+		date = time.Now().UTC()
+	}
+
+	var result variationView
+	result.Latitude = latitude
+	result.Longitude = longitude
+	result.Elevation = elevation
+	result.Date = date.Format("2006-01-02")
+	result.Field = theMagnetic.Field(latitude, longitude, float64(elevation)*0.3048/1000.0, date)
+
+	writeValue(w, r, result)
 }
 
 func main() {
@@ -95,17 +563,40 @@ func main() {
 		log.Panic(err)
 	}
 
+	theMaxResults = context.MaxResults
 	theCountries = countries.NewCountries(context)
+	theBoundaries = theCountries.NewBoundaries()
+	theVersions = versions.NewVersions(context)
+	theVersionCache = versions.NewCache(theVersions.Get, 10*time.Second)
+	theCacheControl = context.CacheControl
+	theExportKeys = context.ExportKeys
 	theAirports = airports.NewAirports(context, theCountries)
+	theWeather = weather.NewReports(context)
+	theMagnetic, err = magnetic.LoadModel(context.MagneticModel)
+	if err != nil {
+		log.Panic(err)
+	}
 
-	graphql.Init(theCountries, theAirports)
+	theSearch = search.NewService(searchEntries, searchVersion)
+	// Build the search index while the server starts
+	go theSearch.Index()
+	// and the autocomplete index
+	currentCompletions()
+
+	err = graphql.Init(theCountries, theAirports, theWeather, theMagnetic, theSearch, context.MaxResults)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	table := routes()
+	for _, version := range apiVersions {
+		theOpenAPI[version.name], err = marshalOpenAPI(newOpenAPI(table, version))
+		if err != nil {
+			log.Panic(err)
+		}
+	}
 
-	myRouter := mux.NewRouter()
-	myRouter.HandleFunc("/geography/countries", getCountries).Methods("GET")
-	myRouter.HandleFunc("/geography/countries/{country-code}", getCountry).Methods("GET")
-	myRouter.HandleFunc("/geography/airports", getAirports).Methods("GET")
-	myRouter.HandleFunc("/geography/airports/{airport-code}", getAirport).Methods("GET")
-	myRouter.HandleFunc("/geography/graphql", graphql.Handler).Methods("POST")
+	myRouter := newRouter(table)
 
 	http.ListenAndServe(":8090", myRouter)
 
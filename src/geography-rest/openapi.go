@@ -0,0 +1,404 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// The OpenAPI 3 document of the REST interface is generated from the route table and the JSON
+// tags of the results, so it cannot fall behind the code. Every version of the interface has its
+// document, with the keys of its results. The golden copy of version 1 in openapi.json is compared
+// with it by the tests, regenerate it with: go test -run TestOpenAPI -update
+
+// theOpenAPI are the documents served by version, generated once at start up
+var theOpenAPI = map[string][]byte{}
+
+// openAPI is an OpenAPI 3.0 document, the parts of it that are used
+type openAPI struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       openAPIInfo                      `json:"info"`
+	Paths      map[string]map[string]*operation `json:"paths"`
+	Components openAPIComponents                `json:"components"`
+}
+
+type openAPIInfo struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Version     string `json:"version"`
+}
+
+type openAPIComponents struct {
+	Schemas   map[string]*schema   `json:"schemas"`
+	Responses map[string]*response `json:"responses"`
+}
+
+type operation struct {
+	OperationID string               `json:"operationId"`
+	Summary     string               `json:"summary"`
+	Tags        []string             `json:"tags"`
+	Parameters  []*openAPIParameter  `json:"parameters,omitempty"`
+	RequestBody *requestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*response `json:"responses"`
+	Deprecated  bool                 `json:"deprecated,omitempty"`
+}
+
+type openAPIParameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *schema `json:"schema"`
+}
+
+type requestBody struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*mediaType `json:"content"`
+}
+
+type response struct {
+	Ref         string                `json:"$ref,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Headers     map[string]*header    `json:"headers,omitempty"`
+	Content     map[string]*mediaType `json:"content,omitempty"`
+}
+
+type header struct {
+	Description string  `json:"description"`
+	Schema      *schema `json:"schema"`
+}
+
+type mediaType struct {
+	Schema *schema `json:"schema"`
+}
+
+type schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Items                *schema            `json:"items,omitempty"`
+	Properties           map[string]*schema `json:"properties,omitempty"`
+	AdditionalProperties *schema            `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+}
+
+// schemaNames are the names of the views in the document, the structs of the datasets keep their
+// own names
+var schemaNames = map[reflect.Type]string{
+	reflect.TypeOf(regionView{}):      "RegionView",
+	reflect.TypeOf(runwayView{}):      "RunwayView",
+	reflect.TypeOf(frequencyView{}):   "FrequencyView",
+	reflect.TypeOf(weatherView{}):     "WeatherView",
+	reflect.TypeOf(variationView{}):   "MagneticVariation",
+	reflect.TypeOf(searchView{}):      "SearchResult",
+	reflect.TypeOf(batchRequest{}):    "BatchRequest",
+	reflect.TypeOf(graphqlRequest{}):  "GraphQLRequest",
+	reflect.TypeOf(graphqlResponse{}): "GraphQLResponse",
+	reflect.TypeOf(problem{}):         "Problem",
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawType        = reflect.TypeOf(json.RawMessage{})
+	identifiedType = reflect.TypeOf((*identified)(nil)).Elem()
+	pathPattern    = regexp.MustCompile(`\{([^}:]+)(?::([^}]+))?\}`)
+	enumPattern    = regexp.MustCompile(`^[a-z]+(\|[a-z]+)+$`)
+)
+
+// schemas collects the schemas of the structs, under the components of the document
+type schemas struct {
+	version    *apiVersion // the keys of the results are those of the version
+	components map[string]*schema
+	types      map[string]reflect.Type
+}
+
+// schemaOf makes the schema of a type as encoding/json writes it, structs are referred to
+func (all *schemas) schemaOf(t reflect.Type) *schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return &schema{Type: "string", Format: "date-time"}
+	case t == rawType:
+		return &schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8,
+		reflect.Uint16, reflect.Uint32:
+		return &schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &schema{Type: "array", Items: all.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &schema{Type: "object", AdditionalProperties: all.schemaOf(t.Elem())}
+	case reflect.Struct:
+		return all.refer(t)
+	}
+	// An interface holds anything
+	return &schema{}
+}
+
+// refer adds a struct to the components, the first time it is seen
+func (all *schemas) refer(t reflect.Type) *schema {
+	name, ok := schemaNames[t]
+	if !ok {
+		name = t.Name()
+	}
+	if len(name) == 0 {
+		panic(fmt.Sprintf("OpenAPI: %s has no name, add it to the schemaNames", t))
+	}
+	name = string(unicode.ToUpper(rune(name[0]))) + name[1:]
+
+	if known, ok := all.types[name]; ok {
+		if known != t {
+			panic(fmt.Sprintf("OpenAPI: %s and %s are both named %s", known, t, name))
+		}
+	} else {
+		all.types[name] = t
+		result := &schema{Type: "object", Properties: map[string]*schema{}}
+		all.components[name] = result
+		all.addFields(result, t)
+		// The resources have an id in a version that rewrites them, at the top of a result
+		if all.version.rewrite && reflect.PtrTo(t).Implements(identifiedType) {
+			result.Properties["id"] = &schema{Type: "string"}
+		}
+	}
+
+	return &schema{Ref: "#/components/schemas/" + name}
+}
+
+// addFields adds the fields of a struct to its schema, following the rules of encoding/json:
+// the tag names a field, "-" leaves it out and the fields of untagged embedded structs are
+// promoted. A field is required unless it is omitted when empty or may be null.
+func (all *schemas) addFields(result *schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options := tag, ""
+		if comma := strings.Index(tag, ","); comma >= 0 {
+			name, options = tag[:comma], tag[comma+1:]
+		}
+
+		fieldType := field.Type
+		if field.Anonymous && len(name) == 0 {
+			for fieldType.Kind() == reflect.Ptr {
+				fieldType = fieldType.Elem()
+			}
+			if fieldType.Kind() == reflect.Struct {
+				all.addFields(result, fieldType)
+				continue
+			}
+		}
+		if len(field.PkgPath) != 0 {
+			continue
+		}
+		if len(name) == 0 {
+			name = field.Name
+		}
+		name = all.version.key(name)
+
+		result.Properties[name] = all.schemaOf(field.Type)
+		if !strings.Contains(options, "omitempty") && field.Type.Kind() != reflect.Ptr &&
+			field.Type.Kind() != reflect.Slice && field.Type.Kind() != reflect.Map &&
+			field.Type.Kind() != reflect.Interface {
+			result.Required = append(result.Required, name)
+		}
+	}
+}
+
+// pathParameters are the variables of a mux template, a pattern of digits is an integer and a
+// choice of words an enumeration
+func pathParameters(template string) []*openAPIParameter {
+	var result []*openAPIParameter
+	for _, match := range pathPattern.FindAllStringSubmatch(template, -1) {
+		parameter := openAPIParameter{Name: match[1], In: "path", Required: true,
+			Description: pathDescriptions[match[1]], Schema: &schema{Type: "string"}}
+		switch {
+		case match[2] == "[0-9]+":
+			parameter.Schema = &schema{Type: "integer", Format: "int32"}
+		case enumPattern.MatchString(match[2]):
+			parameter.Schema.Enum = strings.Split(match[2], "|")
+		}
+		result = append(result, &parameter)
+	}
+	return result
+}
+
+// openAPIPath is the path of a mux template, without the patterns of its variables
+func openAPIPath(template string) string {
+	return pathPattern.ReplaceAllString(template, "{$1}")
+}
+
+// openAPITag groups the operations on the resource they start with, like airports, a format or
+// a custom method like :batchGet is not part of it
+func openAPITag(template string) string {
+	tag := strings.Split(strings.TrimPrefix(template, "/geography/"), "/")[0]
+	return strings.Split(strings.Split(tag, ".")[0], ":")[0]
+}
+
+// newOpenAPI generates the document of the routes in a version, the paths of version 1 are those
+// without a version
+func newOpenAPI(table []*route, version *apiVersion) *openAPI {
+	all := schemas{version: version, components: map[string]*schema{}, types: map[string]reflect.Type{}}
+	description := "Countries, regions, airports, runways and frequencies, with the weather, daylight " +
+		"and magnetic variation at airports. The same data is available through GraphQL."
+	if !version.deprecated.IsZero() {
+		description += fmt.Sprintf(" The paths are also served under %s. This version is deprecated, "+
+			"use %s.", version.prefix(), latestVersion.prefix())
+	}
+	document := openAPI{
+		OpenAPI: "3.0.3",
+		Info: openAPIInfo{
+			Title:       "Geography",
+			Description: description,
+			Version:     strings.TrimPrefix(version.name, "v") + ".0.0"},
+		Paths: map[string]map[string]*operation{},
+		Components: openAPIComponents{
+			Schemas: all.components,
+			Responses: map[string]*response{
+				"Problem": {
+					Description: "The request failed, the code tells the kind of failure",
+					Content: map[string]*mediaType{
+						"application/problem+json": {Schema: all.schemaOf(reflect.TypeOf(problem{}))}}},
+			}},
+	}
+
+	for _, route := range table {
+		path := openAPIPath(route.path)
+		if !route.versionless && version != apiV1 {
+			path = version.path(path)
+		}
+		if document.Paths[path] == nil {
+			document.Paths[path] = map[string]*operation{}
+		}
+		described := newOperation(&all, route)
+		described.Deprecated = !route.versionless && !version.deprecated.IsZero()
+		document.Paths[path][strings.ToLower(route.method)] = described
+	}
+
+	return &document
+}
+
+// newOperation describes a route
+func newOperation(all *schemas, route *route) *operation {
+	result := operation{
+		OperationID: route.operation,
+		Summary:     route.summary,
+		Tags:        []string{openAPITag(route.path)},
+		Parameters:  pathParameters(route.path),
+		Responses: map[string]*response{
+			"default": {Ref: "#/components/responses/Problem"}},
+	}
+
+	for _, parameter := range route.parameters {
+		result.Parameters = append(result.Parameters, &openAPIParameter{Name: parameter.name, In: "query",
+			Description: parameter.description,
+			Schema:      &schema{Type: parameter.kind, Format: parameter.format, Enum: parameter.enum}})
+	}
+
+	if route.body != nil {
+		result.RequestBody = &requestBody{Required: true, Content: map[string]*mediaType{
+			"application/json": {Schema: all.schemaOf(reflect.TypeOf(route.body))}}}
+	}
+
+	success := response{Description: route.summary, Content: map[string]*mediaType{}}
+	var item *schema
+	if route.result != nil {
+		item = all.schemaOf(reflect.TypeOf(route.result))
+	}
+	switch {
+	case len(route.formats) != 0:
+		for _, format := range route.formats {
+			var content *schema
+			switch format {
+			case formatJSON:
+				content = item
+				if route.list {
+					content = &schema{Type: "array", Items: item}
+				}
+			case formatGeoJSON:
+				content = all.schemaOf(reflect.TypeOf(route.features))
+			case formatNDJSON:
+				content = item
+			default:
+				content = &schema{Type: "string"}
+			}
+			success.Content[contentTypes[format]] = &mediaType{Schema: content}
+		}
+	case len(route.contentTypes) != 0:
+		for _, contentType := range route.contentTypes {
+			success.Content[contentType] = &mediaType{Schema: &schema{Type: "string", Format: "binary"}}
+		}
+	default:
+		success.Content["application/json"] = &mediaType{Schema: item}
+	}
+	if route.list {
+		success.Headers = map[string]*header{
+			"Link": {Description: "The first, previous and next page",
+				Schema: &schema{Type: "string"}},
+			"X-Total-Count": {Description: "The size of the whole list, when the total is asked for",
+				Schema: &schema{Type: "integer", Format: "int64"}},
+		}
+	}
+	if len(route.datasets) != 0 || route.period != 0 {
+		if success.Headers == nil {
+			success.Headers = map[string]*header{}
+		}
+		success.Headers["ETag"] = &header{Description: "Changes with the datasets of the result",
+			Schema: &schema{Type: "string"}}
+		success.Headers["Last-Modified"] = &header{Description: "When the datasets of the result changed",
+			Schema: &schema{Type: "string"}}
+		result.Responses[fmt.Sprint(http.StatusNotModified)] = &response{
+			Description: "The result of If-None-Match or If-Modified-Since is current"}
+		for _, name := range []string{"If-None-Match", "If-Modified-Since"} {
+			result.Parameters = append(result.Parameters, &openAPIParameter{Name: name, In: "header",
+				Schema: &schema{Type: "string"}})
+		}
+	}
+	result.Responses[fmt.Sprint(http.StatusOK)] = &success
+
+	return &result
+}
+
+// marshalOpenAPI writes the document the way it is kept in openapi.json
+func marshalOpenAPI(document *openAPI) ([]byte, error) {
+	encoded, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(encoded, '\n'), nil
+}
+
+// getOpenAPI serves the document of the version of the request
+func getOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(theOpenAPI[versionOf(r).name])
+}
+
+func getDocs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(docsPage))
+}
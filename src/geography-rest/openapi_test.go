@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+var update = flag.Bool("update", false, "rewrite openapi.json from the routes")
+
+// TestOpenAPI fails when a route or a result changed without the document in openapi.json
+func TestOpenAPI(t *testing.T) {
+	generated, err := marshalOpenAPI(newOpenAPI(routes(), apiV1))
+	if err != nil {
+		t.Fatalf("marshalOpenAPI failed: %v", err)
+	}
+
+	if *update {
+		err = ioutil.WriteFile("openapi.json", generated, 0644)
+		if err != nil {
+			t.Fatalf("Writing openapi.json failed: %v", err)
+		}
+	}
+
+	golden, err := ioutil.ReadFile("openapi.json")
+	if err != nil {
+		t.Fatalf("Reading openapi.json failed: %v", err)
+	}
+	if !bytes.Equal(golden, generated) {
+		t.Errorf("openapi.json differs from the routes, review the change and regenerate it with: " +
+			"go test -run TestOpenAPI -update")
+	}
+}
+
+// TestOpenAPIRoutes checks that every route of the router is in the document of its version, the
+// paths under /geography/v1 are those without a version
+func TestOpenAPIRoutes(t *testing.T) {
+	unique := map[string]bool{}
+	var documented []string
+	for _, version := range apiVersions {
+		for path, methods := range newOpenAPI(routes(), version).Paths {
+			for method := range methods {
+				if name := strings.ToUpper(method) + " " + path; !unique[name] {
+					unique[name] = true
+					documented = append(documented, name)
+				}
+			}
+		}
+	}
+
+	unique = map[string]bool{}
+	var registered []string
+	newRouter(routes()).Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		template, err := route.GetPathTemplate()
+		if err != nil {
+			return err
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return err
+		}
+		template = strings.Replace(template, apiV1.prefix()+"/", "/geography/", 1)
+		for _, method := range methods {
+			if name := method + " " + openAPIPath(template); !unique[name] {
+				unique[name] = true
+				registered = append(registered, name)
+			}
+		}
+		return nil
+	})
+
+	sort.Strings(documented)
+	sort.Strings(registered)
+	if strings.Join(documented, "\n") != strings.Join(registered, "\n") {
+		t.Errorf("Document expected the routes\n%s\ngot\n%s", strings.Join(registered, "\n"),
+			strings.Join(documented, "\n"))
+	}
+
+	for _, version := range apiVersions {
+		operations := map[string]bool{}
+		for _, methods := range newOpenAPI(routes(), version).Paths {
+			for _, operation := range methods {
+				if operations[operation.OperationID] {
+					t.Errorf("Operation %s is not unique in %s", operation.OperationID, version.name)
+				}
+				operations[operation.OperationID] = true
+			}
+		}
+	}
+}
+
+func TestOpenAPISchemas(t *testing.T) {
+	document := newOpenAPI(routes(), apiV1)
+
+	var tests = []struct {
+		schema   string
+		property string
+		kind     string
+		required bool
+	}{
+		{"CountryView", "iso-country-code", "string", true},
+		{"CountryView", "regions", "array", false},
+		{"CountryView", "_links", "object", false},
+		{"AirportView", "icao-airport-code", "string", true},
+		{"AirportView", "_links", "object", false},
+		{"Airport", "latitude", "number", true},
+		{"Airport", "elevation", "number", false},
+		{"Runway", "length", "integer", true},
+		{"RunwaySide", "runway-code", "string", true},
+		{"Frequency", "frequency-mhz", "number", true},
+		{"Metar", "flight-category", "string", false},
+		{"MagneticVariation", "declination", "number", true},
+	}
+
+	for _, test := range tests {
+		schema, ok := document.Components.Schemas[test.schema]
+		if !ok || schema.Properties[test.property] == nil {
+			t.Errorf("Schema %s expected property %s", test.schema, test.property)
+			continue
+		}
+		required := false
+		for _, name := range schema.Required {
+			required = required || name == test.property
+		}
+		if schema.Properties[test.property].Type != test.kind || required != test.required {
+			t.Errorf("Schema %s.%s expected %s %t, got %s %t", test.schema, test.property, test.kind,
+				test.required, schema.Properties[test.property].Type, required)
+		}
+	}
+
+	if _, ok := document.Components.Schemas["Airport"].Properties["Airport"]; ok {
+		t.Errorf("Schema Airport expected no fields tagged -")
+	}
+}
+
+// TestOpenAPIVersions checks the paths, keys and deprecation of the documents of the versions
+func TestOpenAPIVersions(t *testing.T) {
+	v1 := newOpenAPI(routes(), apiV1)
+	v2 := newOpenAPI(routes(), apiV2)
+
+	if v1.Info.Version != "1.0.0" || v2.Info.Version != "2.0.0" {
+		t.Errorf("Documents expected versions 1.0.0 and 2.0.0, got %s and %s", v1.Info.Version, v2.Info.Version)
+	}
+
+	var tests = []struct {
+		document   *openAPI
+		path       string
+		method     string
+		deprecated bool
+	}{
+		{v1, "/geography/airports/{airport-code}", "get", true},
+		{v2, "/geography/v2/airports/{airport-code}", "get", false},
+		{v1, "/geography/graphql", "post", false},
+		{v2, "/geography/graphql", "post", false},
+	}
+
+	for _, test := range tests {
+		operation, ok := test.document.Paths[test.path][test.method]
+		if !ok {
+			t.Errorf("Document %s expected %s %s", test.document.Info.Version, test.method, test.path)
+			continue
+		}
+		if operation.Deprecated != test.deprecated {
+			t.Errorf("Operation %s %s expected deprecated %t", test.method, test.path, test.deprecated)
+		}
+	}
+	if _, ok := v2.Paths["/geography/airports/{airport-code}"]; ok {
+		t.Errorf("Document 2.0.0 expected no paths without a version")
+	}
+
+	airport := v2.Components.Schemas["AirportView"]
+	for _, property := range []string{"id", "icaoAirportCode", "_links"} {
+		if airport.Properties[property] == nil {
+			t.Errorf("Schema AirportView of version 2 expected property %s", property)
+		}
+	}
+	if airport.Properties["icao-airport-code"] != nil {
+		t.Errorf("Schema AirportView of version 2 expected no kebab case keys")
+	}
+	if v1.Components.Schemas["AirportView"].Properties["id"] != nil {
+		t.Errorf("Schema AirportView of version 1 expected no id")
+	}
+}
+
+func TestPathParameters(t *testing.T) {
+	parameters := pathParameters("/geography/tiles/{z:[0-9]+}/{x:[0-9]+}/{y:[0-9]+}.mvt")
+	if len(parameters) != 3 || parameters[0].Name != "z" || parameters[0].Schema.Type != "integer" {
+		t.Errorf("pathParameters expected z, x and y integers")
+	}
+
+	parameters = pathParameters("/geography/export.{format:kml|kmz}")
+	if len(parameters) != 1 || strings.Join(parameters[0].Schema.Enum, ",") != "kml,kmz" {
+		t.Errorf("pathParameters expected the format kml or kmz")
+	}
+
+	if path := openAPIPath("/geography/tiles/{z:[0-9]+}/{x:[0-9]+}/{y:[0-9]+}.mvt"); path != "/geography/tiles/{z}/{x}/{y}.mvt" {
+		t.Errorf("openAPIPath expected the variables without patterns, got %s", path)
+	}
+}
+
+func TestGetOpenAPI(t *testing.T) {
+	var err error
+	for _, version := range apiVersions {
+		theOpenAPI[version.name], err = marshalOpenAPI(newOpenAPI(routes(), version))
+		if err != nil {
+			t.Fatalf("marshalOpenAPI failed: %v", err)
+		}
+	}
+
+	var tests = []struct {
+		path    string
+		version string
+	}{
+		{"/geography/openapi.json", "1.0.0"},
+		{"/geography/v1/openapi.json", "1.0.0"},
+		{"/geography/v2/openapi.json", "2.0.0"},
+	}
+
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		newRouter(routes()).ServeHTTP(w, httptest.NewRequest("GET", test.path, nil))
+
+		var document struct {
+			OpenAPI string      `json:"openapi"`
+			Info    openAPIInfo `json:"info"`
+		}
+		err = json.NewDecoder(w.Body).Decode(&document)
+		if w.Code != http.StatusOK || err != nil || document.OpenAPI != "3.0.3" || document.Info.Version != test.version {
+			t.Errorf("GET %s expected the document %s, got %d %s (%v)", test.path, test.version, w.Code,
+				document.Info.Version, err)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	newRouter(routes()).ServeHTTP(w, httptest.NewRequest("GET", "/geography/docs", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `fetch("openapi.json")`) {
+		t.Errorf("GET docs expected the viewer, got %d", w.Code)
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"../paging"
+)
+
+// Lists are returned a page at a time. The parameters after and before take the cursor of a
+// page, size the number of items and total=true adds the size of the whole list. The Link
+// header leads to the first, previous and next page. The lists of the datasets can be sorted
+// and limited to some fields, fields=icao-airport-code,airport-name&sort=-elevation for one.
+
+// theMaxResults is the largest page
+var theMaxResults int64
+
+// pageRequest reads the paging parameters of a list
+func pageRequest(r *http.Request) (*paging.Request, error) {
+	var size int
+	var err error
+
+	if len(r.FormValue("size")) != 0 {
+		size, err = strconv.Atoi(r.FormValue("size"))
+		if err != nil {
+			return nil, fmt.Errorf("Invalid Size(%s)", r.FormValue("size"))
+		}
+		if size == 0 {
+			return nil, fmt.Errorf("Invalid Size(0), use 1 .. %d", theMaxResults)
+		}
+	}
+
+	request, err := paging.NewRequest(r.FormValue("after"), r.FormValue("before"), size, false, theMaxResults)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(r.FormValue("total")) != 0 {
+		request.Total, err = strconv.ParseBool(r.FormValue("total"))
+		if err != nil {
+			return nil, fmt.Errorf("Invalid Total(%s)", r.FormValue("total"))
+		}
+	}
+
+	return request, nil
+}
+
+// pageShape reads the sort and the fields of a list of a dataset, the writer of the list writes
+// only those fields
+func pageShape(r *http.Request, request *paging.Request, writer *listWriter, fields []string,
+	sortFields []string) error {
+
+	sort, err := paging.ParseSort(r.FormValue("sort"), sortFields)
+	if err != nil {
+		return err
+	}
+	err = request.SetSort(sort)
+	if err != nil {
+		return err
+	}
+
+	request.Fields, err = paging.ParseFields(r.FormValue("fields"), fields)
+	writer.fields = request.Fields
+	return err
+}
+
+// setPageHeaders links to the pages around a page, the links keep the other parameters
+func setPageHeaders(w http.ResponseWriter, r *http.Request, page *paging.Page) {
+	var links []string
+
+	link := func(relation string, parameter string, cursor string) {
+		query := r.URL.Query()
+		query.Del("after")
+		query.Del("before")
+		if len(parameter) != 0 {
+			query.Set(parameter, cursor)
+		}
+		target := *r.URL
+		target.RawQuery = query.Encode()
+		links = append(links, fmt.Sprintf(`<%s>; rel="%s"`, target.RequestURI(), relation))
+	}
+
+	if page.HasPrevious {
+		link("first", "", "")
+		// Past the end the page is empty, then the first page is all there is to go back to
+		if len(page.StartCursor) != 0 {
+			link("prev", "before", page.StartCursor)
+		}
+	}
+	if page.HasNext && len(page.EndCursor) != 0 {
+		link("next", "after", page.EndCursor)
+	}
+
+	if len(links) != 0 {
+		w.Header().Add("Link", strings.Join(links, ", "))
+	}
+	if page.Total != nil {
+		w.Header().Set("X-Total-Count", strconv.FormatInt(*page.Total, 10))
+	}
+}
+
+// keyedItems is a list of views with the keys they are paged on
+type keyedItems struct {
+	items []interface{}
+	keys  []string
+}
+
+func (list keyedItems) Len() int           { return len(list.keys) }
+func (list keyedItems) Less(i, j int) bool { return list.keys[i] < list.keys[j] }
+func (list keyedItems) Swap(i, j int) {
+	list.items[i], list.items[j] = list.items[j], list.items[i]
+	list.keys[i], list.keys[j] = list.keys[j], list.keys[i]
+}
+
+// pageItems picks the page of a list of views held in memory
+func pageItems(request *paging.Request, items []interface{}, keys []string) ([]interface{}, *paging.Page) {
+	sort.Sort(keyedItems{items: items, keys: keys})
+	start, end, page := request.Slice(keys)
+	return items[start:end], page
+}
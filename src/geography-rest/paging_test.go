@@ -0,0 +1,106 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"../paging"
+)
+
+func TestPageRequest(t *testing.T) {
+	theMaxResults = 500
+
+	var tests = []struct {
+		url     string
+		size    int
+		total   bool
+		correct bool
+	}{
+		{"/", paging.DefaultSize, false, true},
+		{"/?size=10&total=true", 10, true, true},
+		{"/?after=" + paging.EncodeCursor("EHAM"), paging.DefaultSize, false, true},
+		{"/?size=0", 0, false, false},
+		{"/?size=501", 0, false, false},
+		{"/?size=ten", 0, false, false},
+		{"/?total=maybe", 0, false, false},
+		{"/?after=EHAM", 0, false, false},
+	}
+
+	for _, test := range tests {
+		request, err := pageRequest(httptest.NewRequest("GET", test.url, nil))
+		if (test.correct && err != nil) || (!test.correct && err == nil) {
+			t.Errorf("pageRequest(%s) expected %t, got %v", test.url, test.correct, err)
+			continue
+		}
+		if err == nil && (request.Size != test.size || request.Total != test.total) {
+			t.Errorf("pageRequest(%s) expected size %d total %t, got %d %t", test.url, test.size, test.total,
+				request.Size, request.Total)
+		}
+	}
+}
+
+func TestSetPageHeaders(t *testing.T) {
+	total := int64(12)
+	page := paging.Page{StartCursor: "b", EndCursor: "c", HasPrevious: true, HasNext: true, Total: &total}
+
+	w := httptest.NewRecorder()
+	setPageHeaders(w, httptest.NewRequest("GET", "/geography/airports?country=NL&after=a&size=2", nil), &page)
+
+	expected := `</geography/airports?country=NL&size=2>; rel="first", ` +
+		`</geography/airports?before=b&country=NL&size=2>; rel="prev", ` +
+		`</geography/airports?after=c&country=NL&size=2>; rel="next"`
+	if w.Header().Get("Link") != expected {
+		t.Errorf("setPageHeaders expected\n%s\ngot\n%s", expected, w.Header().Get("Link"))
+	}
+	if w.Header().Get("X-Total-Count") != "12" {
+		t.Errorf("setPageHeaders expected a total of 12, got %s", w.Header().Get("X-Total-Count"))
+	}
+
+	w = httptest.NewRecorder()
+	setPageHeaders(w, httptest.NewRequest("GET", "/geography/airports", nil), &paging.Page{})
+	if len(w.Header().Get("Link")) != 0 || len(w.Header().Get("X-Total-Count")) != 0 {
+		t.Errorf("setPageHeaders expected no headers for a single page")
+	}
+}
+
+func TestPageItems(t *testing.T) {
+	items := []interface{}{"27", "09", "18"}
+	keys := []string{"27", "09", "18"}
+
+	page, info := pageItems(&paging.Request{Size: 2}, items, keys)
+	if len(page) != 2 || page[0] != "09" || page[1] != "18" || !info.HasNext {
+		t.Errorf("pageItems expected 09 and 18 with a next page, got %v", page)
+	}
+}
+
+func TestPageShape(t *testing.T) {
+	fields := []string{"code", "name", "elevation"}
+	sortFields := []string{"code", "name"}
+
+	var tests = []struct {
+		url     string
+		fields  int
+		sort    int
+		correct bool
+	}{
+		{"/", 0, 0, true},
+		{"/?fields=name,elevation&sort=-name", 2, 1, true},
+		{"/?fields=runways", 0, 0, false},
+		{"/?sort=elevation", 0, 0, false},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest("GET", test.url, nil)
+		request, _ := pageRequest(r)
+		writer := newListWriter(httptest.NewRecorder(), apiV1, formatJSON, "airports")
+		err := pageShape(r, request, writer, fields, sortFields)
+		if (test.correct && err != nil) || (!test.correct && err == nil) {
+			t.Errorf("pageShape(%s) expected %t, got %v", test.url, test.correct, err)
+			continue
+		}
+		if err == nil && (len(writer.fields) != test.fields || len(request.Sort) != test.sort) {
+			t.Errorf("pageShape(%s) expected %d fields and %d sort fields, got %v %v", test.url, test.fields,
+				test.sort, writer.fields, request.Sort)
+		}
+	}
+}
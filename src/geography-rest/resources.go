@@ -0,0 +1,492 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"../airports"
+	"../countries"
+	"../datatypes"
+	"../geometry"
+	"../magnetic"
+	"../paging"
+)
+
+// The regions, runways and frequencies are stored within their country or airport, on the REST
+// interface they are resources of their own with a link back to their parent.
+
+// regionView is a region with the code of its country
+type regionView struct {
+	CountryCode string             `json:"iso-country-code"`
+	RegionCode  string             `json:"iso-region-code"`
+	RegionName  string             `json:"region-name"`
+	Wikipedia   string             `json:"wikipedia,omitempty"`
+	Centroid    *geometry.Position `json:"centroid,omitempty"`
+	Bounds      *geometry.Box      `json:"bounds,omitempty"`
+	Links       links              `json:"_links"`
+}
+
+// runwayView is a runway direction with the code of its airport
+type runwayView struct {
+	AirportCode     string  `json:"icao-airport-code"`
+	RunwayCode      string  `json:"runway-code"`
+	AltRunwayCode   string  `json:"alt-runway-code,omitempty"`
+	Latitude        float64 `json:"latitude,omitempty"`
+	Longitude       float64 `json:"longitude,omitempty"`
+	Elevation       int     `json:"elevation,omitempty"`
+	Heading         int     `json:"heading,omitempty"`
+	MagneticHeading int     `json:"magnetic-heading,omitempty"`
+	Threshold       int     `json:"threshold,omitempty"`
+	Length          int     `json:"length"`
+	Width           int     `json:"width"`
+	Surface         string  `json:"surface"`
+	Lighted         bool    `json:"lighted"`
+	Closed          bool    `json:"closed"`
+	Links           links   `json:"_links"`
+}
+
+// frequencyView is a frequency with the code of its airport
+type frequencyView struct {
+	AirportCode   string  `json:"icao-airport-code"`
+	FrequencyType string  `json:"frequency-type"`
+	Description   string  `json:"description,omitempty"`
+	Frequency     float64 `json:"frequency-mhz"`
+	Links         links   `json:"_links"`
+	key           string  // the key it is paged on, see frequencyKey
+}
+
+// regionColumns are the CSV columns of regions
+var regionColumns = []string{"iso-country-code", "iso-region-code", "region-name", "wikipedia",
+	"centroid.latitude", "centroid.longitude"}
+
+// runwayColumns are the CSV columns of runways
+var runwayColumns = []string{"icao-airport-code", "runway-code", "alt-runway-code", "latitude", "longitude",
+	"elevation", "heading", "magnetic-heading", "threshold", "length", "width", "surface", "lighted", "closed"}
+
+// frequencyColumns are the CSV columns of frequencies
+var frequencyColumns = []string{"icao-airport-code", "frequency-type", "description", "frequency-mhz"}
+
+// resourceFormats are the formats of the regions, runways and frequencies
+var resourceFormats = []string{formatJSON, formatCSV, formatNDJSON, formatXML}
+
+func asRegionView(country *countries.Country, region *countries.Region) *regionView {
+	return &regionView{
+		CountryCode: country.CountryCode,
+		RegionCode:  region.RegionCode,
+		RegionName:  region.RegionName,
+		Wikipedia:   region.Wikipedia,
+		Centroid:    region.Centroid,
+		Bounds:      region.Bounds,
+		Links:       regionLinks(country.CountryCode, region.RegionCode)}
+}
+
+// asRunwayViews splits a runway in its directions, the magnetic headings use today's variation
+func asRunwayViews(airport *airports.Airport, runway *airports.Runway) []*runwayView {
+	var result []*runwayView
+
+	declination := theMagnetic.Declination(airport.Latitude, airport.Longitude, airport.Elevation, time.Now())
+
+	sides := runway.Sides()
+	for i, side := range sides {
+		view := runwayView{
+			AirportCode: airport.AirportCode,
+			RunwayCode:  side.RunwayCode,
+			Latitude:    side.Latitude,
+			Longitude:   side.Longitude,
+			Elevation:   side.Elevation,
+			Heading:     side.Heading,
+			Threshold:   side.Threshold,
+			Length:      runway.Length,
+			Width:       runway.Width,
+			Surface:     runway.Surface,
+			Lighted:     runway.Lighted,
+			Closed:      runway.Closed}
+		if len(sides) == 2 {
+			view.AltRunwayCode = sides[1-i].RunwayCode
+		}
+		if view.Heading != 0 {
+			view.MagneticHeading = magnetic.MagneticHeading(view.Heading, declination)
+		}
+		view.Links = runwayLinks(airport.AirportCode, view.RunwayCode)
+		result = append(result, &view)
+	}
+
+	return result
+}
+
+func asFrequencyView(airport *airports.Airport, index int) *frequencyView {
+	frequency := airport.Frequencies[index]
+	return &frequencyView{
+		key:           frequencyKey(airport, index),
+		AirportCode:   airport.AirportCode,
+		FrequencyType: frequency.FrequencyType,
+		Description:   frequency.Description,
+		Frequency:     frequency.Frequency,
+		Links:         frequencyLinks(airport.AirportCode)}
+}
+
+// regionKey is the ISO 3166-2 code of a region like NL-NH, the regions hold their code within
+// the country like NH
+func regionKey(countryCode string, regionCode string) string {
+	return countryCode + "-" + regionCode
+}
+
+// frequencyKey is the key a frequency is paged on
+func frequencyKey(airport *airports.Airport, index int) string {
+	return fmt.Sprintf("%s/%03d", airport.AirportCode, index)
+}
+
+// writeResources writes a page of a list of views held in memory, the list is paged on the unique
+// keys of the views
+func writeResources(w http.ResponseWriter, r *http.Request, list string, columns []string,
+	items []interface{}, keys []string) {
+
+	request, err := pageRequest(r)
+	if err != nil {
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	items, page := pageItems(request, items, keys)
+	writePage(w, r, list, columns, items, page)
+}
+
+// writePage writes a page of a list of views in the negotiated format. An empty list is not
+// found, unless the version has empty lists.
+func writePage(w http.ResponseWriter, r *http.Request, list string, columns []string,
+	items []interface{}, page *paging.Page) {
+
+	format, err := negotiateFormat(r, resourceFormats...)
+	if err != nil {
+		formatError(w, err)
+		return
+	}
+
+	// Past the end of a list the page is empty, the list is empty without pages around it
+	if len(items) == 0 && !page.HasPrevious && !page.HasNext && !versionOf(r).emptyLists {
+		httpError(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	writer := newListWriter(w, versionOf(r), format, list)
+	err = writer.setColumns(r, columns)
+	if err != nil {
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	setPageHeaders(w, r, page)
+	for _, item := range items {
+		writer.write(item)
+	}
+	writer.end()
+}
+
+// writeResource writes a single view, in JSON as an object and in the other formats as a list
+func writeResource(w http.ResponseWriter, r *http.Request, list string, columns []string, item interface{}) {
+	format, err := negotiateFormat(r, resourceFormats...)
+	if err != nil {
+		formatError(w, err)
+		return
+	}
+
+	if format == formatJSON {
+		writeValue(w, r, item)
+		return
+	}
+	writeResources(w, r, list, columns, []interface{}{item}, []string{""})
+}
+
+func getRegions(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	// Within a country, or over a range of countries like the regions query
+	fromCountry, untilCountry := r.FormValue("from-country"), r.FormValue("until-country")
+	if countryCode, ok := vars["country-code"]; ok {
+		fromCountry, untilCountry = countryCode, countryCode
+	}
+	if len(fromCountry) == 0 && len(untilCountry) == 0 {
+		httpError(w, "Missing from-country or until-country", http.StatusBadRequest)
+		return
+	}
+
+	fromRegion, err := datatypes.ISORegionCode(r.FormValue("from"), true, true)
+	if err != nil {
+		httpError(w, fmt.Sprintf("From(%s): %v", r.FormValue("from"), err), http.StatusBadRequest)
+		return
+	}
+	untilRegion, err := datatypes.ISORegionCode(r.FormValue("until"), true, true)
+	if err != nil {
+		httpError(w, fmt.Sprintf("Until(%s): %v", r.FormValue("until"), err), http.StatusBadRequest)
+		return
+	}
+
+	// The codes within a country repeat over the countries, the regions are paged on their ISO code
+	inRange := func(key string) bool {
+		return (len(fromRegion) == 0 || key >= fromRegion) && (len(untilRegion) == 0 || key <= untilRegion)
+	}
+
+	// A missing country of the path is not found in every version
+	if countryCode, ok := vars["country-code"]; ok {
+		country, err := theCountries.GetByCountryCode(countryCode)
+		if err != nil {
+			failure(w, err)
+			return
+		}
+
+		var result []interface{}
+		var keys []string
+		for _, region := range country.Regions {
+			key := regionKey(country.CountryCode, region.RegionCode)
+			if inRange(key) {
+				result = append(result, asRegionView(country, region))
+				keys = append(keys, key)
+			}
+		}
+		writeResources(w, r, "regions", regionColumns, result, keys)
+		return
+	}
+
+	request, err := pageRequest(r)
+	if err != nil {
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Over a range the countries are read a page at a time, until the page of regions is full
+	regions := make(map[string]interface{})
+	keys, page, err := paging.Nested(request, "-", func(documents *paging.Request) ([]string, *paging.Page, error) {
+		countryList, page, err := theCountries.GetPage(fromCountry, untilCountry, documents)
+		var keys []string
+		for _, country := range countryList {
+			for _, region := range country.Regions {
+				key := regionKey(country.CountryCode, region.RegionCode)
+				if inRange(key) {
+					regions[key] = asRegionView(country, region)
+					keys = append(keys, key)
+				}
+			}
+		}
+		return keys, page, err
+	})
+	if versionOf(r).emptyList(err) {
+		keys, page, err = nil, &paging.Page{}, nil
+	}
+	if err != nil {
+		failure(w, err)
+		return
+	}
+
+	var result []interface{}
+	for _, key := range keys {
+		result = append(result, regions[key])
+	}
+	writePage(w, r, "regions", regionColumns, result, page)
+}
+
+func getRegion(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	// A region code starts with the code of its country, like NL-NH, the region holds the rest
+	regionCode, err := datatypes.ISORegionCode(vars["region-code"], false, false)
+	if err != nil || !strings.Contains(regionCode, "-") {
+		httpError(w, fmt.Sprintf("RegionCode(%s): Invalid ISO Region Code", vars["region-code"]), http.StatusBadRequest)
+		return
+	}
+	countryCode := regionCode[:strings.Index(regionCode, "-")]
+	regionCode = regionCode[strings.Index(regionCode, "-")+1:]
+
+	country, err := theCountries.GetByCountryCode(countryCode)
+	if err != nil {
+		failure(w, err)
+		return
+	}
+
+	for _, region := range country.Regions {
+		if region.RegionCode == regionCode {
+			writeResource(w, r, "regions", regionColumns, asRegionView(country, region))
+			return
+		}
+	}
+
+	httpError(w, "Not found", http.StatusNotFound)
+}
+
+// runwayFilter reads the filters of the runways query from the parameters
+func runwayFilter(r *http.Request) (*airports.RunwayFilter, error) {
+	var filter airports.RunwayFilter
+	var err error
+
+	filter.FromRunwayCode, err = datatypes.RunwayCode(r.FormValue("from"), true, true)
+	if err != nil {
+		return nil, fmt.Errorf("From(%s): %v", r.FormValue("from"), err)
+	}
+	filter.UntilRunwayCode, err = datatypes.RunwayCode(r.FormValue("until"), true, true)
+	if err != nil {
+		return nil, fmt.Errorf("Until(%s): %v", r.FormValue("until"), err)
+	}
+
+	bounds := []struct {
+		name     string
+		validate func(string, bool) (int, error)
+		field    **int
+	}{
+		{"from-heading", datatypes.RunwayHeading, &filter.FromHeading},
+		{"until-heading", datatypes.RunwayHeading, &filter.UntilHeading},
+		{"from-length", datatypes.RunwayLength, &filter.FromLength},
+		{"until-length", datatypes.RunwayLength, &filter.UntilLength},
+	}
+	for _, bound := range bounds {
+		if len(r.FormValue(bound.name)) == 0 {
+			continue
+		}
+		value, err := bound.validate(r.FormValue(bound.name), false)
+		if err != nil {
+			return nil, fmt.Errorf("%s(%s): %v", bound.name, r.FormValue(bound.name), err)
+		}
+		*bound.field = &value
+	}
+
+	if len(r.FormValue("closed")) != 0 {
+		closed, err := strconv.ParseBool(r.FormValue("closed"))
+		if err != nil {
+			return nil, fmt.Errorf("Closed(%s): Invalid Runway Closed", r.FormValue("closed"))
+		}
+		filter.Closed = &closed
+	}
+
+	return &filter, nil
+}
+
+func getRunways(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	filter, err := runwayFilter(r)
+	if err != nil {
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	airport, err := theAirports.GetByAirportCode(vars["airport-code"])
+	if err != nil {
+		failure(w, err)
+		return
+	}
+
+	var result []interface{}
+	var keys []string
+	for _, runway := range airport.Runways {
+		for _, view := range asRunwayViews(airport, runway) {
+			if filter.Matches(runway, view.RunwayCode, view.Heading) {
+				result = append(result, view)
+				keys = append(keys, view.RunwayCode)
+			}
+		}
+	}
+
+	writeResources(w, r, "runways", runwayColumns, result, keys)
+}
+
+func getRunway(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	runwayCode, err := datatypes.RunwayCode(vars["runway-code"], false, false)
+	if err != nil {
+		httpError(w, fmt.Sprintf("RunwayCode(%s): %v", vars["runway-code"], err), http.StatusBadRequest)
+		return
+	}
+
+	airport, err := theAirports.GetByAirportCode(vars["airport-code"])
+	if err != nil {
+		failure(w, err)
+		return
+	}
+
+	for _, runway := range airport.Runways {
+		for _, view := range asRunwayViews(airport, runway) {
+			if view.RunwayCode == runwayCode {
+				writeResource(w, r, "runways", runwayColumns, view)
+				return
+			}
+		}
+	}
+
+	httpError(w, "Not found", http.StatusNotFound)
+}
+
+func getFrequencies(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	filter := airports.FrequencyFilter{
+		FromFrequencyType:  strings.ToUpper(strings.TrimSpace(r.FormValue("from-type"))),
+		UntilFrequencyType: strings.ToUpper(strings.TrimSpace(r.FormValue("until-type")))}
+
+	// The frequencies of an airport, or over a range of airports like the frequencies query
+	if airportCode, ok := vars["airport-code"]; ok {
+		airport, err := theAirports.GetByAirportCode(airportCode)
+		if err != nil {
+			failure(w, err)
+			return
+		}
+
+		// A frequency is known by its airport and its place in the list of the airport
+		var result []interface{}
+		var keys []string
+		for i, frequency := range airport.Frequencies {
+			if filter.Matches(frequency) {
+				result = append(result, asFrequencyView(airport, i))
+				keys = append(keys, frequencyKey(airport, i))
+			}
+		}
+		writeResources(w, r, "frequencies", frequencyColumns, result, keys)
+		return
+	}
+
+	fromICAO, untilICAO := r.FormValue("from"), r.FormValue("until")
+	fromIATA, untilIATA := r.FormValue("from-iata"), r.FormValue("until-iata")
+	if len(fromICAO) == 0 && len(untilICAO) == 0 && len(fromIATA) == 0 && len(untilIATA) == 0 {
+		httpError(w, "Missing from, until, from-iata or until-iata", http.StatusBadRequest)
+		return
+	}
+
+	request, err := pageRequest(r)
+	if err != nil {
+		httpError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Over a range the airports are read a page at a time, until the page of frequencies is full
+	frequencies := make(map[string]interface{})
+	keys, page, err := paging.Nested(request, "/", func(documents *paging.Request) ([]string, *paging.Page, error) {
+		airportList, page, err := theAirports.GetPage("", "", fromICAO, untilICAO, fromIATA, untilIATA, "",
+			documents)
+		var keys []string
+		for _, airport := range airportList {
+			for i, frequency := range airport.Frequencies {
+				if filter.Matches(frequency) {
+					frequencies[frequencyKey(airport, i)] = asFrequencyView(airport, i)
+					keys = append(keys, frequencyKey(airport, i))
+				}
+			}
+		}
+		return keys, page, err
+	})
+	if versionOf(r).emptyList(err) {
+		keys, page, err = nil, &paging.Page{}, nil
+	}
+	if err != nil {
+		failure(w, err)
+		return
+	}
+
+	var result []interface{}
+	for _, key := range keys {
+		result = append(result, frequencies[key])
+	}
+	writePage(w, r, "frequencies", frequencyColumns, result, page)
+}
@@ -0,0 +1,395 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"../airports"
+	"../countries"
+	"../filters"
+	"../geometry"
+	"../graphql"
+	"../search"
+	"../solar"
+	"../versions"
+)
+
+// The routes of the REST interface are registered from a single table, the OpenAPI document is
+// generated from the same table so it describes every route there is (see openapi.go).
+
+// route is a resource of the REST interface
+type route struct {
+	method       string
+	path         string // the mux template, its variables are the path parameters
+	operation    string // the unique name of the operation
+	summary      string
+	handler      http.HandlerFunc
+	parameters   []parameter
+	formats      []string      // the negotiated formats, see formats.go
+	result       interface{}   // a value of the type of the JSON result
+	features     interface{}   // a value of the type of the GeoJSON result
+	list         bool          // the result is a list returned a page at a time, see paging.go
+	contentTypes []string      // the types of a result that is no JSON, like an image
+	body         interface{}   // a value of the type of the JSON request body
+	datasets     []string      // the datasets the result is made of, see caching.go
+	period       time.Duration // how long the result stays the same at most, a day by default
+	cacheControl string        // the Cache-Control, unless the options override it
+	versionless  bool          // the same in every version of the interface, see apiversions.go
+}
+
+// parameter is a query parameter of a route
+type parameter struct {
+	name        string
+	kind        string // the JSON schema type
+	format      string
+	enum        []string
+	description string
+}
+
+// pathDescriptions describe the path parameters of the routes
+var pathDescriptions = map[string]string{
+	"country-code": "ISO 3166-1 alpha-2 code of the country, like NL",
+	"region-code":  "ISO 3166-2 code of the region, like NL-NH",
+	"airport-code": "ICAO code of the airport, like EHAM",
+	"runway-code":  "Code of the runway direction, like 18R",
+	"format":       "Format of the export",
+	"dataset":      "Dataset to export",
+	"z":            "Zoom level of the tile",
+	"x":            "Column of the tile",
+	"y":            "Row of the tile",
+}
+
+// The parameters shared by the routes
+var (
+	pageParameters = []parameter{
+		{name: "after", kind: "string", description: "Cursor of the page before, from the Link header"},
+		{name: "before", kind: "string", description: "Cursor of the page after, from the Link header"},
+		{name: "size", kind: "integer", description: "Number of items of the page"},
+		{name: "total", kind: "boolean", description: "Return the size of the whole list in X-Total-Count"},
+	}
+	formatParameters = []parameter{
+		{name: "format", kind: "string", enum: listFormats,
+			description: "Format of the result, overrides the Accept header"},
+		{name: "columns", kind: "string", description: "Comma separated columns of the CSV format"},
+	}
+	resourceParameters = []parameter{
+		{name: "format", kind: "string", enum: resourceFormats,
+			description: "Format of the result, overrides the Accept header"},
+		{name: "columns", kind: "string", description: "Comma separated columns of the CSV format"},
+	}
+	geometryParameters = []parameter{
+		{name: "geometry", kind: "string", enum: []string{"simplified", "full"},
+			description: "Detail of the GeoJSON area, simplified by default"},
+		{name: "tolerance", kind: "number", description: "Tolerance of the simplified area in degrees"},
+	}
+	positionParameters = []parameter{
+		{name: "lat", kind: "number", description: "Latitude in degrees"},
+		{name: "lon", kind: "number", description: "Longitude in degrees"},
+	}
+	airportRangeParameters = []parameter{
+		{name: "from", kind: "string", description: "First ICAO airport code"},
+		{name: "until", kind: "string", description: "Last ICAO airport code"},
+		{name: "from-iata", kind: "string", description: "First IATA airport code"},
+		{name: "until-iata", kind: "string", description: "Last IATA airport code"},
+	}
+	regionParameters = []parameter{
+		{name: "from", kind: "string", description: "First ISO region code, like NL-NH"},
+		{name: "until", kind: "string", description: "Last ISO region code, like NL-NH"},
+	}
+	runwayParameters = []parameter{
+		{name: "from", kind: "string", description: "First runway code"},
+		{name: "until", kind: "string", description: "Last runway code"},
+		{name: "from-heading", kind: "integer", description: "Lowest heading in degrees"},
+		{name: "until-heading", kind: "integer", description: "Highest heading in degrees"},
+		{name: "from-length", kind: "integer", description: "Shortest length in feet"},
+		{name: "until-length", kind: "integer", description: "Longest length in feet"},
+		{name: "closed", kind: "boolean", description: "Only the closed or the open runways"},
+	}
+	frequencyParameters = []parameter{
+		{name: "from-type", kind: "string", description: "First frequency type, like APP"},
+		{name: "until-type", kind: "string", description: "Last frequency type, like TWR"},
+	}
+	selectionParameters = []parameter{
+		{name: "country", kind: "string", description: "ISO country code of the airports"},
+		{name: "region", kind: "string", description: "ISO region code of the airports"},
+		{name: "bbox", kind: "string", description: "Bounding box min-lon,min-lat,max-lon,max-lat"},
+		{name: "airports", kind: "string", description: "Comma separated ICAO airport codes"},
+	}
+)
+
+// parameters joins sets of parameters
+func parameters(sets ...[]parameter) []parameter {
+	var result []parameter
+	for _, set := range sets {
+		result = append(result, set...)
+	}
+	return result
+}
+
+// shapeParameters are the parameters that sort a list of a dataset and limit it to some fields
+func shapeParameters(fields []string, sortFields []string) []parameter {
+	return []parameter{
+		{name: "fields", kind: "string",
+			description: "Comma separated fields of the items, all by default. Use " + strings.Join(fields, ", ")},
+		{name: "sort", kind: "string",
+			description: "The field to sort on, descending with a leading minus. Use " +
+				strings.Join(sortFields, ", ")},
+	}
+}
+
+// airportListParameters are the parameters of a list of airports, after the parameters that
+// choose the airports of the route
+func airportListParameters(first []parameter) []parameter {
+	return parameters(first, airportRangeParameters, filterParameters(airports.FilterFields), pageParameters,
+		shapeParameters(airports.Fields, airports.SortFields), formatParameters)
+}
+
+// filterParameters are the parameters that filter a list of a dataset with an expression
+func filterParameters(fields filters.Fields) []parameter {
+	var names []string
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return []parameter{
+		{name: "filter", kind: "string",
+			description: "Filter expression like airport-type==large_airport;elevation=gt=5000, constraints " +
+				"combined with ; (and) and , (or), with the operators == != =lt= =le= =gt= =ge= =in= =out= " +
+				"=like= and * as wildcard. Use " + strings.Join(names, ", ")},
+	}
+}
+
+// graphqlRequest is the body of a GraphQL query
+type graphqlRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+// graphqlResponse is the result of a GraphQL query
+type graphqlResponse struct {
+	Data   map[string]interface{}   `json:"data,omitempty"`
+	Errors []map[string]interface{} `json:"errors,omitempty"`
+}
+
+// routes is the table of the routes, a function rather than a variable as the documentation
+// routes serve the document generated from it
+func routes() []*route {
+	return []*route{
+		{method: "GET", path: "/geography/countries", operation: "getCountries",
+			summary: "List the countries", handler: getCountries,
+			parameters: parameters([]parameter{
+				{name: "from", kind: "string", description: "First ISO country code"},
+				{name: "until", kind: "string", description: "Last ISO country code"},
+			}, geometryParameters, pageParameters, shapeParameters(countries.Fields, countries.SortFields),
+				formatParameters),
+			formats: listFormats, result: countryView{}, features: geometry.FeatureCollection{}, list: true,
+			datasets: []string{versions.Countries, versions.Boundaries}},
+		{method: "GET", path: "/geography/countries/{country-code}", operation: "getCountry",
+			summary: "Get a country", handler: getCountry,
+			parameters: parameters(geometryParameters, formatParameters),
+			formats:    listFormats, result: countryView{}, features: geometry.Feature{},
+			datasets: []string{versions.Countries, versions.Boundaries}},
+		{method: "GET", path: "/geography/countries/{country-code}/airports", operation: "getCountryAirports",
+			summary: "List the airports of a country", handler: getAirports,
+			parameters: airportListParameters([]parameter{
+				{name: "region", kind: "string", description: "ISO region code of the airports"},
+			}),
+			formats: listFormats, result: airportView{}, features: geometry.FeatureCollection{}, list: true,
+			datasets: []string{versions.Airports}},
+		{method: "GET", path: "/geography/countries/{country-code}/regions", operation: "getCountryRegions",
+			summary: "List the regions of a country", handler: getRegions,
+			parameters: parameters(regionParameters, pageParameters, resourceParameters),
+			formats:    resourceFormats, result: regionView{}, list: true,
+			datasets: []string{versions.Countries}},
+		{method: "GET", path: "/geography/countries/{country-code}/regions/{region-code}/airports",
+			operation: "getRegionAirports", summary: "List the airports of a region", handler: getAirports,
+			parameters: airportListParameters(nil),
+			formats:    listFormats, result: airportView{}, features: geometry.FeatureCollection{}, list: true,
+			datasets: []string{versions.Airports}},
+		{method: "GET", path: "/geography/regions", operation: "getRegions",
+			summary: "List the regions of a range of countries", handler: getRegions,
+			parameters: parameters([]parameter{
+				{name: "from-country", kind: "string", description: "First ISO country code"},
+				{name: "until-country", kind: "string", description: "Last ISO country code"},
+			}, regionParameters, pageParameters, resourceParameters),
+			formats: resourceFormats, result: regionView{}, list: true,
+			datasets: []string{versions.Countries}},
+		{method: "GET", path: "/geography/regions/{region-code}", operation: "getRegion",
+			summary: "Get a region", handler: getRegion,
+			parameters: resourceParameters,
+			formats:    resourceFormats, result: regionView{},
+			datasets: []string{versions.Countries}},
+		{method: "GET", path: "/geography/airports", operation: "getAirports",
+			summary: "List the airports", handler: getAirports,
+			parameters: airportListParameters([]parameter{
+				{name: "country", kind: "string", description: "ISO country code of the airports"},
+				{name: "region", kind: "string", description: "ISO region code of the airports"},
+			}),
+			formats: listFormats, result: airportView{}, features: geometry.FeatureCollection{}, list: true,
+			datasets: []string{versions.Airports}},
+		{method: "POST", path: "/geography/airports:batchGet", operation: "batchGetAirports",
+			summary: "Get many airports by their ICAO or IATA codes, in the order of the codes",
+			handler: postAirportsBatch, body: batchRequest{}, result: []airports.BatchItem{}},
+		{method: "GET", path: "/geography/airports/{airport-code}", operation: "getAirport",
+			summary: "Get an airport", handler: getAirport,
+			parameters: formatParameters,
+			formats:    listFormats, result: airportView{}, features: geometry.FeatureCollection{},
+			datasets: []string{versions.Airports}},
+		{method: "GET", path: "/geography/airports/{airport-code}/runway-winds", operation: "getRunwayWinds",
+			summary: "Rank the runways of an airport for a wind", handler: getRunwayWinds,
+			parameters: []parameter{
+				{name: "direction", kind: "integer", description: "Direction the wind blows from in degrees"},
+				{name: "speed", kind: "integer", description: "Speed of the wind in knots"},
+				{name: "crosswind-limit", kind: "integer", description: "Largest usable crosswind in knots"},
+			},
+			result:   []*airports.RunwayWind{},
+			datasets: []string{versions.Airports}},
+		{method: "GET", path: "/geography/airports/{airport-code}/weather", operation: "getWeather",
+			summary: "Get the weather at an airport", handler: getWeather,
+			parameters: []parameter{
+				{name: "history", kind: "integer", description: "Hours of reports to return"},
+			},
+			result:   weatherView{},
+			datasets: []string{versions.Weather}, period: time.Hour, cacheControl: "public, max-age=60"},
+		{method: "GET", path: "/geography/airports/{airport-code}/daylight", operation: "getDaylight",
+			summary: "Get the daylight at an airport", handler: getDaylight,
+			parameters: []parameter{
+				{name: "date", kind: "string", format: "date", description: "Date, today by default"},
+			},
+			result:   solar.Daylight{},
+			datasets: []string{versions.Airports}, period: 15 * time.Minute},
+		{method: "GET", path: "/geography/airports/{airport-code}/runways", operation: "getRunways",
+			summary: "List the runways of an airport", handler: getRunways,
+			parameters: parameters(runwayParameters, pageParameters, resourceParameters),
+			formats:    resourceFormats, result: runwayView{}, list: true,
+			datasets: []string{versions.Airports}},
+		{method: "GET", path: "/geography/airports/{airport-code}/runways/{runway-code}", operation: "getRunway",
+			summary: "Get a runway direction of an airport", handler: getRunway,
+			parameters: resourceParameters,
+			formats:    resourceFormats, result: runwayView{},
+			datasets: []string{versions.Airports}},
+		{method: "GET", path: "/geography/airports/{airport-code}/frequencies", operation: "getAirportFrequencies",
+			summary: "List the frequencies of an airport", handler: getFrequencies,
+			parameters: parameters(frequencyParameters, pageParameters, resourceParameters),
+			formats:    resourceFormats, result: frequencyView{}, list: true,
+			datasets: []string{versions.Airports}},
+		{method: "GET", path: "/geography/frequencies", operation: "getFrequencies",
+			summary: "List the frequencies of a range of airports", handler: getFrequencies,
+			parameters: parameters(airportRangeParameters, frequencyParameters, pageParameters, resourceParameters),
+			formats:    resourceFormats, result: frequencyView{}, list: true,
+			datasets: []string{versions.Airports}},
+		{method: "GET", path: "/geography/airports/{airport-code}/diagram.svg", operation: "getDiagram",
+			summary: "Draw the runways of an airport", handler: getDiagram,
+			parameters: []parameter{
+				{name: "size", kind: "integer", description: "Width and height in pixels"},
+				{name: "dark", kind: "boolean", description: "Light lines on a dark background"},
+				{name: "units", kind: "string", enum: []string{"feet", "meters"},
+					description: "Units of the scale bar, feet by default"},
+			},
+			contentTypes: []string{"image/svg+xml"},
+			datasets:     []string{versions.Airports}},
+		{method: "GET", path: "/geography/export.{format:kml|kmz}", operation: "getExport",
+			summary: "Export a selection of airports for Google Earth", handler: getExport,
+			parameters:   selectionParameters,
+			contentTypes: []string{"application/vnd.google-earth.kml+xml", "application/vnd.google-earth.kmz"},
+			datasets:     []string{versions.Airports}},
+		{method: "GET", path: "/geography/export/{dataset:countries|regions|airports|runways|frequencies}",
+			operation: "getDatasetExport", handler: getDatasetExport,
+			summary: "Stream a whole dataset, with an export key as bearer token",
+			parameters: []parameter{
+				{name: "format", kind: "string", enum: exportFormats, description: "Format of the records, " +
+					"overrides the Accept header, CSV is gzipped"},
+				{name: "after", kind: "string", description: "Key of the last record received to resume, " +
+					"like NL for countries, NL-NH for regions, EHAM for airports, EHAM/18R for runways " +
+					"(EHAM/18R/001 for a repeated runway code) and EHAM/003 for frequencies"},
+				{name: "version", kind: "string", description: "Version of the dataset from the " +
+					"X-Dataset-Version header, to resume only when it did not change"},
+				{name: "columns", kind: "string", description: "Comma separated columns of the CSV format"},
+			},
+			contentTypes: []string{contentTypes[formatNDJSON], "application/gzip"},
+			cacheControl: "private, no-store"},
+		{method: "GET", path: "/geography/locate", operation: "getLocate",
+			summary: "Find the country and region of a position", handler: getLocate,
+			parameters: positionParameters,
+			result:     countries.Location{},
+			datasets:   []string{versions.Boundaries}, cacheControl: "public, max-age=3600"},
+		{method: "GET", path: "/geography/tiles/{z:[0-9]+}/{x:[0-9]+}/{y:[0-9]+}.mvt", operation: "getTile",
+			summary: "Get a vector tile of the airports", handler: getTile,
+			contentTypes: []string{"application/vnd.mapbox-vector-tile"},
+			datasets:     []string{versions.Airports}, cacheControl: "public, max-age=3600"},
+		{method: "GET", path: "/geography/search", operation: "getSearch",
+			summary: "Search airports, countries and regions by name", handler: getSearch,
+			parameters: []parameter{
+				{name: "q", kind: "string", description: "Words of the name, place or code, like sao paulo"},
+				{name: "kind", kind: "string", description: "Comma separated kinds of results, all by default. " +
+					"Use " + strings.Join(search.Kinds, ", ")},
+				{name: "limit", kind: "integer", description: fmt.Sprintf("Number of results, %d by default, "+
+					"%d at most", defaultSearchLimit, search.MaxLimit)},
+			},
+			result:   []searchView{},
+			datasets: []string{versions.Countries, versions.Airports}},
+		{method: "GET", path: "/geography/autocomplete", operation: "getAutocomplete",
+			summary: "Suggest airports while their code, name or municipality is typed", handler: getAutocomplete,
+			parameters: []parameter{
+				{name: "q", kind: "string", description: "What was typed, like schip or EHA"},
+				{name: "limit", kind: "integer", description: fmt.Sprintf("Number of suggestions, %d by default, "+
+					"%d at most", defaultAutocompleteLimit, maxAutocompleteLimit)},
+			},
+			result:   []airports.Suggestion{},
+			datasets: []string{versions.Airports}},
+		{method: "GET", path: "/geography/magnetic-variation", operation: "getMagneticVariation",
+			summary: "Calculate the magnetic field at a position", handler: getMagneticVariation,
+			parameters: parameters(positionParameters, []parameter{
+				{name: "elevation", kind: "integer", description: "Elevation in feet"},
+				{name: "date", kind: "string", format: "date", description: "Date, today by default"},
+			}),
+			result: variationView{},
+			period: defaultPeriod, cacheControl: "public, max-age=3600"},
+		{method: "GET", path: "/geography/graphql", operation: "getGraphQL",
+			summary: "Query the datasets with GraphQL, cacheable", handler: graphql.Handler,
+			parameters: []parameter{
+				{name: "query", kind: "string", description: "The GraphQL query"},
+				{name: "operationName", kind: "string", description: "The operation of the query to run"},
+				{name: "variables", kind: "string", description: "The variables of the query as a JSON object"},
+			},
+			result:   graphqlResponse{},
+			datasets: []string{versions.Countries, versions.Boundaries, versions.Airports, versions.Weather},
+			period:   15 * time.Minute, cacheControl: "public, max-age=60", versionless: true},
+		{method: "POST", path: "/geography/graphql", operation: "postGraphQL",
+			summary: "Query the datasets with GraphQL", handler: graphql.Handler,
+			body: graphqlRequest{}, result: graphqlResponse{}, versionless: true},
+		{method: "GET", path: "/geography/openapi.json", operation: "getOpenAPI",
+			summary: "Get this OpenAPI document", handler: getOpenAPI,
+			contentTypes: []string{"application/json"},
+			cacheControl: "public, max-age=3600"},
+		{method: "GET", path: "/geography/docs", operation: "getDocs",
+			summary: "Browse this OpenAPI document", handler: getDocs,
+			contentTypes: []string{"text/html"},
+			cacheControl: "public, max-age=3600"},
+	}
+}
+
+// newRouter registers the routes in every version, the paths without a version are version 1
+func newRouter(table []*route) *mux.Router {
+	router := mux.NewRouter()
+	for _, route := range table {
+		if route.versionless {
+			router.HandleFunc(route.path, cached(route)).Methods(route.method)
+			continue
+		}
+		router.HandleFunc(route.path, versioned(apiV1, "/geography", cached(route))).Methods(route.method)
+		for _, version := range apiVersions {
+			router.HandleFunc(version.path(route.path), versioned(version, version.prefix(), cached(route))).
+				Methods(route.method)
+		}
+	}
+	router.NotFoundHandler = http.HandlerFunc(notFound)
+	router.MethodNotAllowedHandler = http.HandlerFunc(methodNotAllowed)
+	return router
+}
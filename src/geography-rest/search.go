@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"../search"
+	"../versions"
+)
+
+// Search finds airports, countries and regions by their names, the places they are in and their
+// codes (see the search package). A result comes with the airport, country or region it found.
+
+// defaultSearchLimit is the number of results of a search when no limit is asked for
+const defaultSearchLimit = 10
+
+// theSearch keeps the search index of the current datasets
+var theSearch *search.Service
+
+// searchView is a result of a search, with the airport, country or region it found
+type searchView struct {
+	Kind    string       `json:"kind"`
+	Score   float64      `json:"score"`
+	Airport *airportView `json:"airport,omitempty"`
+	Country *countryView `json:"country,omitempty"`
+	Region  *regionView  `json:"region,omitempty"`
+}
+
+// searchEntries loads the entries of the search index from the datasets
+func searchEntries() ([]*search.Entry, error) {
+	result, err := theCountries.SearchEntries()
+	if err != nil {
+		return nil, err
+	}
+
+	countryNames := map[string]string{}
+	for _, entry := range result {
+		if entry.Kind == search.Country {
+			countryNames[entry.Code] = entry.Name
+		}
+	}
+
+	airportEntries, err := theAirports.SearchEntries(countryNames)
+	if err != nil {
+		return nil, err
+	}
+	return append(result, airportEntries...), nil
+}
+
+// searchVersion is the version of the datasets of the search index
+func searchVersion() (string, error) {
+	var result []string
+	for _, dataset := range []string{versions.Countries, versions.Airports} {
+		version, err := theVersionCache.Get(dataset)
+		if err != nil {
+			return "", err
+		}
+		result = append(result, fmt.Sprintf("%s:%d", dataset, version.Version))
+	}
+	return strings.Join(result, ","), nil
+}
+
+// asSearchView retrieves what a result found, a country comes without its regions
+func asSearchView(result *search.Result) (*searchView, error) {
+	view := searchView{Kind: result.Kind, Score: result.Score}
+
+	switch result.Kind {
+	case search.Airport:
+		airport, err := theAirports.GetByAirportCode(result.Code)
+		if err != nil {
+			return nil, err
+		}
+		view.Airport = asAirportView(airport)
+	case search.Country, search.Region:
+		country, err := theCountries.GetByCountryCode(result.CountryCode)
+		if err != nil {
+			return nil, err
+		}
+		for _, region := range country.Regions {
+			if result.Kind == search.Region && region.RegionCode == result.Code {
+				view.Region = asRegionView(country, region)
+			}
+		}
+		if result.Kind == search.Country {
+			country.Regions = nil
+			view.Country = asCountryView(country)
+		}
+	}
+	return &view, nil
+}
+
+func getSearch(w http.ResponseWriter, r *http.Request) {
+	var kinds []string
+	if len(r.FormValue("kind")) != 0 {
+		for _, kind := range strings.Split(r.FormValue("kind"), ",") {
+			kinds = append(kinds, strings.TrimSpace(kind))
+		}
+	}
+
+	limit := defaultSearchLimit
+	if len(r.FormValue("limit")) != 0 {
+		var err error
+		limit, err = strconv.Atoi(r.FormValue("limit"))
+		if err != nil {
+			httpError(w, fmt.Sprintf("Invalid Limit(%s)", r.FormValue("limit")), http.StatusBadRequest)
+			return
+		}
+	}
+
+	results, err := theSearch.Search(r.FormValue("q"), kinds, limit)
+	if err != nil {
+		failure(w, err)
+		return
+	}
+
+	views := []*searchView{}
+	for _, result := range results {
+		view, err := asSearchView(result)
+		if err != nil {
+			failure(w, err)
+			return
+		}
+		views = append(views, view)
+	}
+
+	writeValue(w, r, views)
+}
@@ -0,0 +1,441 @@
+package geometry
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Geometry implements the (Multi)Polygons used for boundaries, read from GeoJSON as published
+// by most boundary sources. Positions follow GeoJSON: longitude first, then latitude.
+
+// EarthRadius is the mean radius of the earth in km
+const EarthRadius = 6371.0
+
+// Point is a single position as [longitude, latitude]
+type Point [2]float64
+
+// Ring is a closed line, the last point equals the first
+type Ring []Point
+
+// Polygon is an outer ring followed by its holes
+type Polygon []Ring
+
+// MultiPolygon is a set of polygons that together form one area
+type MultiPolygon []Polygon
+
+// Position is a position in the external representation
+type Position struct {
+	Latitude  float64 `bson:"latitude" json:"latitude"`
+	Longitude float64 `bson:"longitude" json:"longitude"`
+}
+
+// Box is the bounding box of a shape
+type Box struct {
+	MinLongitude float64 `bson:"min-longitude" json:"min-longitude"`
+	MinLatitude  float64 `bson:"min-latitude" json:"min-latitude"`
+	MaxLongitude float64 `bson:"max-longitude" json:"max-longitude"`
+	MaxLatitude  float64 `bson:"max-latitude" json:"max-latitude"`
+}
+
+// Geometry is a GeoJSON geometry, the coordinates are decoded on request
+type Geometry struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+}
+
+// Feature is a GeoJSON feature
+type Feature struct {
+	Type       string                 `json:"type"`
+	Properties map[string]interface{} `json:"properties"`
+	Geometry   *Geometry              `json:"geometry"`
+}
+
+// FeatureCollection is a GeoJSON feature collection
+type FeatureCollection struct {
+	Type     string     `json:"type"`
+	Features []*Feature `json:"features"`
+}
+
+// MultiPolygon decodes a Polygon or MultiPolygon geometry, other geometries have no area
+func (geometry *Geometry) MultiPolygon() (MultiPolygon, error) {
+	if geometry == nil {
+		return nil, fmt.Errorf("Missing geometry")
+	}
+
+	switch geometry.Type {
+	case "Polygon":
+		var polygon Polygon
+		if err := json.Unmarshal(geometry.Coordinates, &polygon); err != nil {
+			return nil, fmt.Errorf("Polygon: %v", err)
+		}
+		return MultiPolygon{polygon}, nil
+	case "MultiPolygon":
+		var multiPolygon MultiPolygon
+		if err := json.Unmarshal(geometry.Coordinates, &multiPolygon); err != nil {
+			return nil, fmt.Errorf("MultiPolygon: %v", err)
+		}
+		return multiPolygon, nil
+	}
+
+	return nil, fmt.Errorf("Geometry(%s): Not a (Multi)Polygon", geometry.Type)
+}
+
+// NewGeometry encodes a multipolygon as a GeoJSON geometry
+func NewGeometry(multiPolygon MultiPolygon) *Geometry {
+	coordinates, _ := json.Marshal(multiPolygon)
+	return &Geometry{Type: "MultiPolygon", Coordinates: coordinates}
+}
+
+// NewPoint creates a GeoJSON point geometry
+func NewPoint(position Position) *Geometry {
+	coordinates, _ := json.Marshal(Point{position.Longitude, position.Latitude})
+	return &Geometry{Type: "Point", Coordinates: coordinates}
+}
+
+// NewLineString creates a GeoJSON line geometry through the positions
+func NewLineString(positions []Position) *Geometry {
+	line := make([]Point, len(positions))
+	for i, position := range positions {
+		line[i] = Point{position.Longitude, position.Latitude}
+	}
+	coordinates, _ := json.Marshal(line)
+	return &Geometry{Type: "LineString", Coordinates: coordinates}
+}
+
+// NewFeature creates a feature whose properties are the JSON fields of value, except for the
+// fields named in omit
+func NewFeature(geometry *Geometry, value interface{}, omit ...string) *Feature {
+	feature := Feature{Type: "Feature", Properties: map[string]interface{}{}, Geometry: geometry}
+
+	encoded, err := json.Marshal(value)
+	if err == nil {
+		json.Unmarshal(encoded, &feature.Properties)
+	}
+	for _, name := range omit {
+		delete(feature.Properties, name)
+	}
+
+	return &feature
+}
+
+// NewFeatureCollection collects features, an empty collection still has a list of features
+func NewFeatureCollection(features []*Feature) *FeatureCollection {
+	if features == nil {
+		features = []*Feature{}
+	}
+	return &FeatureCollection{Type: "FeatureCollection", Features: features}
+}
+
+// Property returns a property of a feature as a string, empty when absent
+func (feature *Feature) Property(name string) string {
+	value, ok := feature.Properties[name]
+	if !ok || value == nil {
+		return ""
+	}
+	if text, ok := value.(string); ok {
+		return text
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+// Bounds calculates the bounding box of a multipolygon
+func (multiPolygon MultiPolygon) Bounds() Box {
+	box := Box{
+		MinLongitude: math.Inf(1),
+		MinLatitude:  math.Inf(1),
+		MaxLongitude: math.Inf(-1),
+		MaxLatitude:  math.Inf(-1)}
+
+	for _, polygon := range multiPolygon {
+		if len(polygon) == 0 {
+			continue
+		}
+		for _, point := range polygon[0] {
+			box.MinLongitude = math.Min(box.MinLongitude, point[0])
+			box.MinLatitude = math.Min(box.MinLatitude, point[1])
+			box.MaxLongitude = math.Max(box.MaxLongitude, point[0])
+			box.MaxLatitude = math.Max(box.MaxLatitude, point[1])
+		}
+	}
+
+	return box
+}
+
+// ParseBox reads a box written as min-longitude,min-latitude,max-longitude,max-latitude, the
+// order of GeoJSON and most map libraries. A box across the date line is not supported.
+func ParseBox(s string) (Box, error) {
+	var values [4]float64
+
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return Box{}, fmt.Errorf("Invalid Box(%s)", s)
+	}
+	for i, part := range parts {
+		value, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return Box{}, fmt.Errorf("Invalid Box(%s)", s)
+		}
+		values[i] = value
+	}
+
+	box := Box{MinLongitude: values[0], MinLatitude: values[1], MaxLongitude: values[2], MaxLatitude: values[3]}
+	if box.MinLongitude < -180 || box.MaxLongitude > 180 || box.MinLongitude > box.MaxLongitude ||
+		box.MinLatitude < -90 || box.MaxLatitude > 90 || box.MinLatitude > box.MaxLatitude {
+		return Box{}, fmt.Errorf("Invalid Box(%s)", s)
+	}
+
+	return box, nil
+}
+
+// Contains checks whether a position lies within the box
+func (box Box) Contains(latitude float64, longitude float64) bool {
+	return latitude >= box.MinLatitude && latitude <= box.MaxLatitude &&
+		longitude >= box.MinLongitude && longitude <= box.MaxLongitude
+}
+
+// Distance is a lower bound for the distance in km from a position to anything in the box
+func (box Box) Distance(latitude float64, longitude float64) float64 {
+	nearestLatitude := math.Max(box.MinLatitude, math.Min(latitude, box.MaxLatitude))
+	nearestLongitude := math.Max(box.MinLongitude, math.Min(longitude, box.MaxLongitude))
+	return Distance(latitude, longitude, nearestLatitude, nearestLongitude)
+}
+
+// Contains checks whether a position lies within the area: inside an outer ring and not in one
+// of its holes
+func (multiPolygon MultiPolygon) Contains(latitude float64, longitude float64) bool {
+	for _, polygon := range multiPolygon {
+		if len(polygon) == 0 || !polygon[0].contains(latitude, longitude) {
+			continue
+		}
+		inHole := false
+		for _, hole := range polygon[1:] {
+			if hole.contains(latitude, longitude) {
+				inHole = true
+				break
+			}
+		}
+		if !inHole {
+			return true
+		}
+	}
+	return false
+}
+
+// contains is the even-odd ray casting test
+func (ring Ring) contains(latitude float64, longitude float64) bool {
+	result := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		a, b := ring[i], ring[j]
+		if (a[1] > latitude) != (b[1] > latitude) &&
+			longitude < (b[0]-a[0])*(latitude-a[1])/(b[1]-a[1])+a[0] {
+			result = !result
+		}
+	}
+	return result
+}
+
+// Distance calculates the distance in km from a position to the edge of the area, zero when
+// the position lies inside
+func (multiPolygon MultiPolygon) Distance(latitude float64, longitude float64) float64 {
+	if multiPolygon.Contains(latitude, longitude) {
+		return 0
+	}
+
+	result := math.Inf(1)
+	for _, polygon := range multiPolygon {
+		for _, ring := range polygon {
+			result = math.Min(result, ring.distance(latitude, longitude))
+		}
+	}
+	return result
+}
+
+// distance projects the ring around the position (equirectangular), which is accurate enough
+// for the short distances it is used for
+func (ring Ring) distance(latitude float64, longitude float64) float64 {
+	scale := math.Cos(latitude * math.Pi / 180.0)
+	wrap := func(dx float64) float64 {
+		if dx > 180 {
+			return dx - 360
+		} else if dx < -180 {
+			return dx + 360
+		}
+		return dx
+	}
+
+	result := math.Inf(1)
+	for i := 1; i < len(ring); i++ {
+		// The segment is kept whole, only its start is wrapped around the date line
+		start := wrap(ring[i-1][0] - longitude)
+		ax, ay := start*scale, ring[i-1][1]-latitude
+		bx, by := (start+wrap(ring[i][0]-ring[i-1][0]))*scale, ring[i][1]-latitude
+
+		// Nearest point on the segment to the origin
+		dx, dy := bx-ax, by-ay
+		t := 0.0
+		if length := dx*dx + dy*dy; length > 0 {
+			t = math.Max(0, math.Min(1, -(ax*dx+ay*dy)/length))
+		}
+		x, y := ax+t*dx, ay+t*dy
+		result = math.Min(result, math.Sqrt(x*x+y*y))
+	}
+
+	return result * math.Pi / 180.0 * EarthRadius
+}
+
+// Distance calculates the great circle distance in km between two positions
+func Distance(latitude1 float64, longitude1 float64, latitude2 float64, longitude2 float64) float64 {
+	phi1 := latitude1 * math.Pi / 180.0
+	phi2 := latitude2 * math.Pi / 180.0
+	dPhi := phi2 - phi1
+	dLambda := (longitude2 - longitude1) * math.Pi / 180.0
+
+	a := math.Sin(dPhi/2)*math.Sin(dPhi/2) +
+		math.Cos(phi1)*math.Cos(phi2)*math.Sin(dLambda/2)*math.Sin(dLambda/2)
+	return 2 * EarthRadius * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// Centroid calculates the center of mass of the area, treating the coordinates as planar which
+// is fine for the purpose of placing a label or a marker
+func (multiPolygon MultiPolygon) Centroid() Position {
+	var area, x, y float64
+
+	for _, polygon := range multiPolygon {
+		for i, ring := range polygon {
+			ringArea, ringX, ringY := ring.moments()
+			// Holes take away, whatever their orientation
+			if (i == 0) != (ringArea > 0) {
+				ringArea, ringX, ringY = -ringArea, -ringX, -ringY
+			}
+			area += ringArea
+			x += ringX
+			y += ringY
+		}
+	}
+
+	if area == 0 {
+		box := multiPolygon.Bounds()
+		return Position{
+			Latitude:  (box.MinLatitude + box.MaxLatitude) / 2,
+			Longitude: (box.MinLongitude + box.MaxLongitude) / 2}
+	}
+
+	return Position{Latitude: y / (3 * area), Longitude: x / (3 * area)}
+}
+
+// moments calculates the signed area (shoelace formula) and the first moments of a ring
+func (ring Ring) moments() (float64, float64, float64) {
+	var area, x, y float64
+	for i := 1; i < len(ring); i++ {
+		a, b := ring[i-1], ring[i]
+		cross := a[0]*b[1] - b[0]*a[1]
+		area += cross
+		x += (a[0] + b[0]) * cross
+		y += (a[1] + b[1]) * cross
+	}
+	return area / 2, x / 2, y / 2
+}
+
+// Simplify reduces the number of points with the Douglas-Peucker algorithm; the tolerance is
+// in degrees. Rings that collapse are dropped, but the largest polygon always remains.
+func (multiPolygon MultiPolygon) Simplify(tolerance float64) MultiPolygon {
+	var result MultiPolygon
+	var largest Polygon
+	var largestArea float64
+
+	for _, polygon := range multiPolygon {
+		if len(polygon) == 0 {
+			continue
+		}
+		if area, _, _ := polygon[0].moments(); math.Abs(area) > largestArea {
+			largest = polygon
+			largestArea = math.Abs(area)
+		}
+
+		outer := polygon[0].simplify(tolerance)
+		if outer == nil {
+			continue
+		}
+		simplified := Polygon{outer}
+		for _, hole := range polygon[1:] {
+			if ring := hole.simplify(tolerance); ring != nil {
+				simplified = append(simplified, ring)
+			}
+		}
+		result = append(result, simplified)
+	}
+
+	if len(result) == 0 && largest != nil {
+		result = MultiPolygon{Polygon{largest[0]}}
+	}
+
+	return result
+}
+
+// simplify reduces a ring, nil when less than a triangle remains. The ring is split at the
+// point farthest from its start as Douglas-Peucker needs distinct end points.
+func (ring Ring) simplify(tolerance float64) Ring {
+	if len(ring) < 4 {
+		return nil
+	}
+
+	farthest, distance := 0, 0.0
+	for i := range ring {
+		dx, dy := ring[i][0]-ring[0][0], ring[i][1]-ring[0][1]
+		if d := dx*dx + dy*dy; d > distance {
+			farthest, distance = i, d
+		}
+	}
+	if farthest == 0 {
+		return nil
+	}
+
+	keep := make([]bool, len(ring))
+	keep[0], keep[farthest], keep[len(ring)-1] = true, true, true
+	douglasPeucker(ring, 0, farthest, tolerance, keep)
+	douglasPeucker(ring, farthest, len(ring)-1, tolerance, keep)
+
+	var result Ring
+	for i := range ring {
+		if keep[i] {
+			result = append(result, ring[i])
+		}
+	}
+	if len(result) < 4 {
+		return nil
+	}
+	return result
+}
+
+// douglasPeucker marks the points between first and last that are needed within the tolerance
+func douglasPeucker(ring Ring, first int, last int, tolerance float64, keep []bool) {
+	if last-first < 2 {
+		return
+	}
+
+	ax, ay := ring[first][0], ring[first][1]
+	dx, dy := ring[last][0]-ax, ring[last][1]-ay
+	length := math.Sqrt(dx*dx + dy*dy)
+
+	farthest, distance := 0, 0.0
+	for i := first + 1; i < last; i++ {
+		var d float64
+		if length == 0 {
+			d = math.Hypot(ring[i][0]-ax, ring[i][1]-ay)
+		} else {
+			d = math.Abs(dy*(ring[i][0]-ax)-dx*(ring[i][1]-ay)) / length
+		}
+		if d > distance {
+			farthest, distance = i, d
+		}
+	}
+
+	if distance > tolerance {
+		keep[farthest] = true
+		douglasPeucker(ring, first, farthest, tolerance, keep)
+		douglasPeucker(ring, farthest, last, tolerance, keep)
+	}
+}
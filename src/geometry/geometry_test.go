@@ -0,0 +1,176 @@
+package geometry
+
+import (
+	"math"
+	"testing"
+)
+
+// A square of 2x2 degrees around 0,0 with a hole of 1x1 degree in the north-east corner
+var square = MultiPolygon{Polygon{
+	Ring{{-1, -1}, {1, -1}, {1, 1}, {-1, 1}, {-1, -1}},
+	Ring{{0, 0}, {1, 0}, {1, 1}, {0, 1}, {0, 0}},
+}}
+
+func TestContains(t *testing.T) {
+	var tests = []struct {
+		latitude  float64
+		longitude float64
+		result    bool
+	}{
+		{-0.5, -0.5, true},  // inside
+		{0.5, -0.5, true},   // inside, next to the hole
+		{0.5, 0.5, false},   // in the hole
+		{2.0, 0.0, false},   // north of the square
+		{0.0, -1.5, false},  // west of the square
+		{-0.99, 0.99, true}, // near a corner
+	}
+
+	for _, test := range tests {
+		result := square.Contains(test.latitude, test.longitude)
+		if result != test.result {
+			t.Errorf("Contains(%f, %f) expected %t, got %t", test.latitude, test.longitude, test.result, result)
+		}
+	}
+}
+
+func TestDistance(t *testing.T) {
+	degree := math.Pi / 180.0 * EarthRadius
+
+	var tests = []struct {
+		latitude  float64
+		longitude float64
+		result    float64
+	}{
+		{-0.5, -0.5, 0},              // inside
+		{2.0, 0.0, degree},           // one degree north of the edge
+		{0.0, -1.5, 0.5 * degree},    // half a degree west of the edge
+		{0.5, 0.5, 0.5 * degree},     // in the middle of the hole
+		{0.0, 179.5, 178.5 * degree}, // far away, across the date line it is no closer
+	}
+
+	for _, test := range tests {
+		result := square.Distance(test.latitude, test.longitude)
+		if math.Abs(result-test.result) > 0.01*test.result+0.1 {
+			t.Errorf("Distance(%f, %f) expected %.1f, got %.1f", test.latitude, test.longitude, test.result, result)
+		}
+	}
+}
+
+func TestBounds(t *testing.T) {
+	box := square.Bounds()
+	if box.MinLongitude != -1 || box.MinLatitude != -1 || box.MaxLongitude != 1 || box.MaxLatitude != 1 {
+		t.Errorf("Bounds expected -1,-1 1,1, got %+v", box)
+	}
+	if !box.Contains(0.5, 0.5) || box.Contains(1.5, 0) {
+		t.Errorf("Box.Contains failed for %+v", box)
+	}
+}
+
+func TestGreatCircle(t *testing.T) {
+	// EHAM to KJFK is about 5850 km
+	result := Distance(52.3086, 4.7639, 40.6398, -73.7789)
+	if math.Abs(result-5850) > 10 {
+		t.Errorf("Distance(EHAM, KJFK) expected 5850, got %.0f", result)
+	}
+}
+
+func TestCentroid(t *testing.T) {
+	var tests = []struct {
+		area      MultiPolygon
+		latitude  float64
+		longitude float64
+	}{
+		{MultiPolygon{Polygon{square[0][0]}}, 0, 0}, // the square without its hole
+		{square, -1.0 / 6, -1.0 / 6},                // the hole pulls the center away
+		{MultiPolygon{square[0], {{{9, 0}, {11, 0}, {11, 2}, {9, 2}, {9, 0}}}}, 0.5, 39.5 / 7}, // (3 x (-1/6,-1/6) + 4 x (1,10)) / 7
+	}
+
+	for _, test := range tests {
+		result := test.area.Centroid()
+		if math.Abs(result.Latitude-test.latitude) > 1e-9 || math.Abs(result.Longitude-test.longitude) > 1e-9 {
+			t.Errorf("Centroid expected %f,%f, got %f,%f", test.latitude, test.longitude, result.Latitude, result.Longitude)
+		}
+	}
+}
+
+func TestSimplify(t *testing.T) {
+	// A square with a small dent in every side
+	dented := MultiPolygon{Polygon{Ring{
+		{0, 0}, {1, 0.01}, {2, 0}, {1.99, 1}, {2, 2}, {1, 1.99}, {0, 2}, {0.01, 1}, {0, 0}}}}
+
+	var tests = []struct {
+		tolerance float64
+		points    int
+	}{
+		{0.001, 9}, // everything stays
+		{0.1, 5},   // the dents go
+	}
+
+	for _, test := range tests {
+		result := dented.Simplify(test.tolerance)
+		if len(result) != 1 || len(result[0]) != 1 || len(result[0][0]) != test.points {
+			t.Errorf("Simplify(%f) expected %d points, got %v", test.tolerance, test.points, result)
+		}
+	}
+
+	// A tiny island disappears, but the largest polygon always remains
+	islands := MultiPolygon{{square[0][0]}, {{{5, 5}, {5.01, 5}, {5.01, 5.01}, {5, 5}}}}
+	if result := islands.Simplify(0.1); len(result) != 1 {
+		t.Errorf("Simplify expected the island to disappear, got %v", result)
+	}
+	if result := islands[1:].Simplify(0.1); len(result) != 1 {
+		t.Errorf("Simplify expected the island to remain on its own, got %v", result)
+	}
+}
+
+func TestNewGeometry(t *testing.T) {
+	result, err := NewGeometry(square).MultiPolygon()
+	if err != nil || len(result) != 1 || len(result[0]) != 2 || result[0][1][2] != square[0][1][2] {
+		t.Errorf("NewGeometry expected the square back, got %v (%v)", result, err)
+	}
+}
+
+func TestNewFeature(t *testing.T) {
+	value := struct {
+		Code    string   `json:"code"`
+		Skipped []string `json:"skipped"`
+	}{"EHAM", []string{"x"}}
+
+	feature := NewFeature(NewPoint(Position{Latitude: 52.3, Longitude: 4.7}), &value, "skipped")
+	if feature.Property("code") != "EHAM" || feature.Properties["skipped"] != nil {
+		t.Errorf("NewFeature expected only the code, got %v", feature.Properties)
+	}
+	if string(feature.Geometry.Coordinates) != "[4.7,52.3]" {
+		t.Errorf("NewPoint expected longitude first, got %s", feature.Geometry.Coordinates)
+	}
+
+	line := NewLineString([]Position{{Latitude: 1, Longitude: 2}, {Latitude: 3, Longitude: 4}})
+	if line.Type != "LineString" || string(line.Coordinates) != "[[2,1],[4,3]]" {
+		t.Errorf("NewLineString expected [[2,1],[4,3]], got %s", line.Coordinates)
+	}
+
+	if collection := NewFeatureCollection(nil); collection.Features == nil {
+		t.Errorf("NewFeatureCollection expected an empty list of features")
+	}
+}
+
+func TestParseBox(t *testing.T) {
+	var tests = []struct {
+		s       string
+		correct bool
+	}{
+		{"3.3,50.7,7.2,53.6", true},
+		{" -180, -90, 180, 90 ", true},
+		{"3.3,50.7,7.2", false},
+		{"3.3,50.7,7.2,x", false},
+		{"7.2,50.7,3.3,53.6", false}, // across the date line
+		{"3.3,50.7,7.2,93.6", false},
+	}
+
+	for _, test := range tests {
+		_, err := ParseBox(test.s)
+		if (test.correct && err != nil) || (!test.correct && err == nil) {
+			t.Errorf("ParseBox(%s) expected %t, got %v", test.s, test.correct, err)
+		}
+	}
+}
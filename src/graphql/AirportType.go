@@ -2,8 +2,12 @@ package graphql
 
 import (
 	"fmt"
+	"time"
 
 	"../airports"
+	"../datatypes"
+	"../failures"
+	"../timezones"
 	"github.com/graphql-go/graphql"
 )
 
@@ -33,25 +37,92 @@ var airportType = graphql.NewObject(
 			"Elevation": &graphql.Field{
 				Type: graphql.Int,
 			},
+			"MagneticVariation": &graphql.Field{
+				Type:        graphql.Float,
+				Description: "Declination in degrees, MagneticField tells whether the date is outside the model epoch",
+				Args: graphql.FieldConfigArgument{
+					"Date": &graphql.ArgumentConfig{
+						Type: graphql.String,
+					},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					airport := p.Source.(*airports.Airport)
+
+					date := time.Now()
+					text, ok := p.Args["Date"]
+					if ok {
+						var err error
+						date, err = datatypes.Date(text.(string), false)
+						if err != nil {
+							return nil, failures.New(failures.InvalidArgument, "Airport.MagneticVariation(%s): %v", text.(string), err)
+						}
+					}
+
+					return theMagnetic.Declination(airport.Latitude, airport.Longitude, airport.Elevation, date), nil
+				},
+			},
 			"Region": &graphql.Field{
 				Type: regionType,
 				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
 					airport := p.Source.(*airports.Airport)
 					country, err := theCountries.GetByCountryCode(airport.CountryCode)
 					if err != nil {
-						return nil, fmt.Errorf("Airport.Region: %v", err)
+						return nil, fmt.Errorf("Airport.Region: %w", err)
 					}
 					for _, region := range country.Regions {
 						if region.RegionCode == airport.RegionCode {
 							return region, nil
 						}
 					}
-					return nil, fmt.Errorf("Airport.Region: Not Found")
+					return nil, failures.New(failures.NotFound, "Airport.Region: Not Found")
 				},
 			},
 			"Municipality": &graphql.Field{
 				Type: graphql.String,
 			},
+			"TimeZone": &graphql.Field{
+				Type: graphql.String,
+			},
+			"UtcOffset": &graphql.Field{
+				Type: graphql.String,
+				Args: graphql.FieldConfigArgument{
+					"At": &graphql.ArgumentConfig{
+						Type: graphql.String,
+					},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					airport := p.Source.(*airports.Airport)
+
+					location, err := airport.Location()
+					if err != nil {
+						return nil, fmt.Errorf("Airport.UtcOffset: %w", err)
+					}
+
+					at := time.Now()
+					text, ok := p.Args["At"]
+					if ok {
+						at, err = datatypes.DateTime(text.(string), false)
+						if err != nil {
+							return nil, failures.New(failures.InvalidArgument, "Airport.UtcOffset(%s): %v", text.(string), err)
+						}
+					}
+
+					return timezones.UtcOffset(at.In(location)), nil
+				},
+			},
+			"LocalTime": &graphql.Field{
+				Type: graphql.DateTime,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					airport := p.Source.(*airports.Airport)
+
+					location, err := airport.Location()
+					if err != nil {
+						return nil, fmt.Errorf("Airport.LocalTime: %w", err)
+					}
+
+					return time.Now().In(location), nil
+				},
+			},
 			"IATACode": &graphql.Field{
 				Type: graphql.String,
 				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
@@ -65,6 +136,16 @@ var airportType = graphql.NewObject(
 			"Wikipedia": &graphql.Field{
 				Type: graphql.String,
 			},
+			"ScheduledService": &graphql.Field{
+				Type: graphql.Boolean,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					airport := p.Source.(*airports.Airport)
+					return airport.Scheduled, nil
+				},
+			},
+			"Keywords": &graphql.Field{
+				Type: graphql.NewList(graphql.String),
+			},
 		},
 	})
 
@@ -75,7 +156,7 @@ func addAirportToCountry() {
 			airport := p.Source.(*airports.Airport)
 			country, err := theCountries.GetByCountryCode(airport.CountryCode)
 			if err != nil {
-				return nil, fmt.Errorf("Airport.Country: %v", err)
+				return nil, fmt.Errorf("Airport.Country: %w", err)
 			}
 			return country, nil
 		},
@@ -89,14 +170,14 @@ func addAirportToRegion() {
 			airport := p.Source.(*airports.Airport)
 			country, err := theCountries.GetByCountryCode(airport.CountryCode)
 			if err != nil {
-				return nil, fmt.Errorf("Airport.Region: %v", err)
+				return nil, fmt.Errorf("Airport.Region: %w", err)
 			}
 			for _, region := range country.Regions {
 				if region.RegionCode == airport.RegionCode {
 					return asRegionView(country, region), nil
 				}
 			}
-			return nil, fmt.Errorf("Airport.Region: Not Found")
+			return nil, failures.New(failures.NotFound, "Airport.Region: Not Found")
 		},
 	})
 
@@ -201,7 +282,7 @@ var airportQuery = &graphql.Field{
 		if ok {
 			airport, err := theAirports.GetByAirportCode(airportCode.(string))
 			if err != nil {
-				return nil, fmt.Errorf("Airport(%s): %v", airportCode.(string), err)
+				return nil, fmt.Errorf("Airport(%s): %w", airportCode.(string), err)
 			}
 			return airport, nil
 		}
@@ -209,16 +290,18 @@ var airportQuery = &graphql.Field{
 		if ok {
 			airport, err := theAirports.GetByIATACode(iataCode.(string))
 			if err != nil {
-				return nil, fmt.Errorf("Airport(%s): %v", iataCode.(string), err)
+				return nil, fmt.Errorf("Airport(%s): %w", iataCode.(string), err)
 			}
 			return airport, nil
 		}
-		return nil, fmt.Errorf("Airport: Missing AirportCode or IATACode parameter")
+		return nil, failures.New(failures.InvalidArgument, "Airport: Missing AirportCode or IATACode parameter")
 	}}
 
+var airportConnectionType = connectionType(airportType)
+
 var airportsQuery = &graphql.Field{
-	Type: graphql.NewList(airportType),
-	Args: graphql.FieldConfigArgument{
+	Type: airportConnectionType,
+	Args: connectionArgs(graphql.FieldConfigArgument{
 		"CountryCode": &graphql.ArgumentConfig{
 			Type: graphql.String,
 		},
@@ -237,7 +320,11 @@ var airportsQuery = &graphql.Field{
 		"UntilIATACode": &graphql.ArgumentConfig{
 			Type: graphql.String,
 		},
-	},
+		"filter": &graphql.ArgumentConfig{
+			Type:        graphql.String,
+			Description: "Filter expression like airport-type==large_airport;elevation=gt=5000",
+		},
+	}),
 	Resolve: func(p graphql.ResolveParams) (interface{}, error) {
 		countryCode, ok := p.Args["CountryCode"]
 		if !ok {
@@ -269,17 +356,78 @@ var airportsQuery = &graphql.Field{
 			untilIATACode = ""
 		}
 
-		result, err := theAirports.GetList(
+		filter, ok := p.Args["filter"]
+		if !ok {
+			filter = ""
+		}
+
+		request, err := pageRequest(p)
+		if err != nil {
+			return nil, fmt.Errorf("Airports: %w", err)
+		}
+
+		result, page, err := theAirports.GetPage(
 			countryCode.(string),
 			regionCode.(string),
 			fromICAOCode.(string),
 			untilICAOCode.(string),
 			fromIATACode.(string),
-			untilIATACode.(string))
+			untilIATACode.(string),
+			filter.(string),
+			request)
 
 		if err != nil {
-			return nil, fmt.Errorf("Airports: %v", err)
+			return nil, fmt.Errorf("Airports: %w", err)
 		}
 
-		return result, nil
+		var nodes []interface{}
+		var keys []string
+		for _, airport := range result {
+			nodes = append(nodes, airport)
+			keys = append(keys, airport.AirportCode)
+		}
+		return newConnection(nodes, keys, page), nil
+	}}
+
+// airportBatchItemType is the airport of a code of airportsByCodes, with whether it was found
+var airportBatchItemType = graphql.NewObject(
+	graphql.ObjectConfig{
+		Name: "AirportBatchItem",
+		Fields: graphql.Fields{
+			"Code": &graphql.Field{
+				Type: graphql.String,
+			},
+			"Status": &graphql.Field{
+				Type: graphql.String,
+				Description: "One of " + airports.BatchFound + ", " + airports.BatchNotFound + ", " +
+					airports.BatchInvalid,
+			},
+			"Error": &graphql.Field{
+				Type: graphql.String,
+			},
+			"Airport": &graphql.Field{
+				Type: airportType,
+			},
+		},
+	})
+
+// airportsByCodesQuery gets many airports by their ICAO or IATA codes, in the order of the codes
+var airportsByCodesQuery = &graphql.Field{
+	Type: graphql.NewList(airportBatchItemType),
+	Args: graphql.FieldConfigArgument{
+		"Codes": &graphql.ArgumentConfig{
+			Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String))),
+		},
+	},
+	Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+		var codes []string
+		for _, code := range p.Args["Codes"].([]interface{}) {
+			codes = append(codes, code.(string))
+		}
+
+		items, err := theAirports.GetBatch(codes)
+		if err != nil {
+			return nil, fmt.Errorf("AirportsByCodes: %w", err)
+		}
+		return items, nil
 	}}
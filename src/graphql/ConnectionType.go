@@ -0,0 +1,205 @@
+package graphql
+
+import (
+	"sort"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+
+	"../failures"
+	"../paging"
+)
+
+// The lists are paged as Relay connections: first items after a cursor, or last items before one.
+// The names follow the Relay specification so its clients page them without configuration.
+
+// connection is a page of a list with an edge per node
+type connection struct {
+	Edges      []*edge   `json:"edges"`
+	PageInfo   *pageInfo `json:"pageInfo"`
+	TotalCount *int64    `json:"totalCount"`
+}
+
+// edge is a node in a page with the cursor to continue from
+type edge struct {
+	Node   interface{} `json:"node"`
+	Cursor string      `json:"cursor"`
+}
+
+// pageInfo tells where a page is in the list
+type pageInfo struct {
+	HasNextPage     bool   `json:"hasNextPage"`
+	HasPreviousPage bool   `json:"hasPreviousPage"`
+	StartCursor     string `json:"startCursor"`
+	EndCursor       string `json:"endCursor"`
+}
+
+var pageInfoType = graphql.NewObject(
+	graphql.ObjectConfig{
+		Name: "PageInfo",
+		Fields: graphql.Fields{
+			"hasNextPage": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Boolean),
+			},
+			"hasPreviousPage": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Boolean),
+			},
+			"startCursor": &graphql.Field{
+				Type: graphql.String,
+			},
+			"endCursor": &graphql.Field{
+				Type: graphql.String,
+			},
+		},
+	})
+
+// connectionType creates the connection of a node type, with its edge type
+func connectionType(nodeType *graphql.Object) *graphql.Object {
+	edgeType := graphql.NewObject(
+		graphql.ObjectConfig{
+			Name: nodeType.Name() + "Edge",
+			Fields: graphql.Fields{
+				"node": &graphql.Field{
+					Type: nodeType,
+				},
+				"cursor": &graphql.Field{
+					Type: graphql.NewNonNull(graphql.String),
+				},
+			},
+		})
+
+	return graphql.NewObject(
+		graphql.ObjectConfig{
+			Name: nodeType.Name() + "Connection",
+			Fields: graphql.Fields{
+				"edges": &graphql.Field{
+					Type: graphql.NewList(edgeType),
+				},
+				"pageInfo": &graphql.Field{
+					Type: graphql.NewNonNull(pageInfoType),
+				},
+				"totalCount": &graphql.Field{
+					Type: graphql.Int,
+				},
+			},
+		})
+}
+
+// connectionArgs adds the paging arguments to the arguments of a list query
+func connectionArgs(args graphql.FieldConfigArgument) graphql.FieldConfigArgument {
+	args["first"] = &graphql.ArgumentConfig{
+		Type: graphql.Int,
+	}
+	args["after"] = &graphql.ArgumentConfig{
+		Type: graphql.String,
+	}
+	args["last"] = &graphql.ArgumentConfig{
+		Type: graphql.Int,
+	}
+	args["before"] = &graphql.ArgumentConfig{
+		Type: graphql.String,
+	}
+	return args
+}
+
+// pageRequest reads the paging arguments, the total is only counted when it is selected
+func pageRequest(p graphql.ResolveParams) (*paging.Request, error) {
+	first, hasFirst := p.Args["first"]
+	last, hasLast := p.Args["last"]
+	after, _ := p.Args["after"].(string)
+	before, _ := p.Args["before"].(string)
+
+	var size int
+	switch {
+	case hasFirst && hasLast:
+		return nil, failures.New(failures.InvalidArgument, "Use either first or last")
+	case hasFirst:
+		size = first.(int)
+	case hasLast:
+		size = last.(int)
+	}
+	if (hasFirst || hasLast) && size < 1 {
+		return nil, failures.New(failures.InvalidArgument, "Invalid Size(%d), use 1 .. %d", size, theMaxResults)
+	}
+
+	request, err := paging.NewRequest(after, before, size, hasLast, theMaxResults)
+	if err != nil {
+		return nil, err
+	}
+	request.Total = selected(p, "totalCount")
+
+	return request, nil
+}
+
+// selected tells whether a field is selected from the result of the query
+func selected(p graphql.ResolveParams, name string) bool {
+	var search func(selectionSet *ast.SelectionSet) bool
+	search = func(selectionSet *ast.SelectionSet) bool {
+		if selectionSet == nil {
+			return false
+		}
+		for _, selection := range selectionSet.Selections {
+			switch s := selection.(type) {
+			case *ast.Field:
+				if s.Name != nil && s.Name.Value == name {
+					return true
+				}
+			case *ast.InlineFragment:
+				if search(s.SelectionSet) {
+					return true
+				}
+			case *ast.FragmentSpread:
+				if fragment, ok := p.Info.Fragments[s.Name.Value].(*ast.FragmentDefinition); ok &&
+					search(fragment.SelectionSet) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	for _, field := range p.Info.FieldASTs {
+		if search(field.SelectionSet) {
+			return true
+		}
+	}
+	return false
+}
+
+// newConnection makes the connection of a page, the keys of the nodes are their cursors
+func newConnection(nodes []interface{}, keys []string, page *paging.Page) *connection {
+	result := connection{
+		Edges: []*edge{},
+		PageInfo: &pageInfo{
+			HasNextPage:     page.HasNext,
+			HasPreviousPage: page.HasPrevious,
+			StartCursor:     page.StartCursor,
+			EndCursor:       page.EndCursor},
+		TotalCount: page.Total}
+
+	for i, node := range nodes {
+		result.Edges = append(result.Edges, &edge{Node: node, Cursor: paging.EncodeCursor(keys[i])})
+	}
+
+	return &result
+}
+
+// keyedNodes is a list of nodes with the keys they are paged on
+type keyedNodes struct {
+	nodes []interface{}
+	keys  []string
+}
+
+func (list keyedNodes) Len() int           { return len(list.keys) }
+func (list keyedNodes) Less(i, j int) bool { return list.keys[i] < list.keys[j] }
+func (list keyedNodes) Swap(i, j int) {
+	list.nodes[i], list.nodes[j] = list.nodes[j], list.nodes[i]
+	list.keys[i], list.keys[j] = list.keys[j], list.keys[i]
+}
+
+// sliceConnection pages a list of nodes held in memory
+func sliceConnection(request *paging.Request, nodes []interface{}, keys []string) *connection {
+	sort.Sort(keyedNodes{nodes: nodes, keys: keys})
+	start, end, page := request.Slice(keys)
+	return newConnection(nodes[start:end], keys[start:end], page)
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"../countries"
+	"../failures"
 	"github.com/graphql-go/graphql"
 )
 
@@ -36,7 +37,7 @@ var countryQuery = &graphql.Field{
 	Resolve: func(p graphql.ResolveParams) (interface{}, error) {
 		countryCode, ok := p.Args["CountryCode"]
 		if !ok {
-			return nil, fmt.Errorf("Missing CountryCode parameter")
+			return nil, failures.New(failures.InvalidArgument, "Missing CountryCode parameter")
 		}
 		country, err := theCountries.GetByCountryCode(countryCode.(string))
 		if err != nil {
@@ -45,30 +46,43 @@ var countryQuery = &graphql.Field{
 		return country, nil
 	}}
 
+var countryConnectionType = connectionType(countryType)
+
 var countriesQuery = &graphql.Field{
-	Type: graphql.NewList(countryType),
-	Args: graphql.FieldConfigArgument{
+	Type: countryConnectionType,
+	Args: connectionArgs(graphql.FieldConfigArgument{
 		"FromCountryCode": &graphql.ArgumentConfig{
 			Type: graphql.String,
 		},
 		"UntilCountryCode": &graphql.ArgumentConfig{
 			Type: graphql.String,
 		},
-	},
+	}),
 	Resolve: func(p graphql.ResolveParams) (interface{}, error) {
 		fromCountryCode, ok := p.Args["FromCountryCode"]
 		if !ok {
-			return nil, fmt.Errorf("Missing FromCountryCode parameter")
+			return nil, failures.New(failures.InvalidArgument, "Missing FromCountryCode parameter")
 		}
 		untilCountryCode, ok := p.Args["UntilCountryCode"]
 		if !ok {
-			return nil, fmt.Errorf("Missing UntilCountryCode parameter")
+			return nil, failures.New(failures.InvalidArgument, "Missing UntilCountryCode parameter")
 		}
-		countries, err := theCountries.GetList(fromCountryCode.(string), untilCountryCode.(string))
+		request, err := pageRequest(p)
+		if err != nil {
+			return nil, fmt.Errorf("Countries: %w", err)
+		}
+		countries, page, err := theCountries.GetPage(fromCountryCode.(string), untilCountryCode.(string), request)
 		if err != nil {
 			return nil, err
 		}
-		return countries, nil
+
+		var nodes []interface{}
+		var keys []string
+		for _, country := range countries {
+			nodes = append(nodes, country)
+			keys = append(keys, country.CountryCode)
+		}
+		return newConnection(nodes, keys, page), nil
 	}}
 	
 func addCountryToRegion() {
@@ -95,15 +109,15 @@ func addCountryToRegion() {
 				untilRegionCode = "ZZ"
 			}
 
-			var result []*countries.Region
+			var result []*regionView
 			for _, region := range country.Regions {
 				if region.RegionCode >= fromRegionCode.(string) && region.RegionCode <= untilRegionCode.(string) {
-					result = append(result, region)
+					result = append(result, asRegionView(country, region))
 				}
 			}
 
 			if len(result) == 0 {
-				return nil, fmt.Errorf("Not found")
+				return nil, failures.New(failures.NotFound, "Not found")
 			}
 
 			return result, nil
@@ -113,8 +127,8 @@ func addCountryToRegion() {
 
 func addCountryToAirport() {
 	countryType.AddFieldConfig("Airports", &graphql.Field{
-		Type: graphql.NewList(airportType),
-		Args: graphql.FieldConfigArgument{
+		Type: airportConnectionType,
+		Args: connectionArgs(graphql.FieldConfigArgument{
 			"FromICAOCode": &graphql.ArgumentConfig{
 				Type: graphql.String,
 			},
@@ -127,7 +141,7 @@ func addCountryToAirport() {
 			"UntilIATACode": &graphql.ArgumentConfig{
 				Type: graphql.String,
 			},
-		},
+		}),
 		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
 			country := p.Source.(*countries.Country)
 
@@ -151,15 +165,24 @@ func addCountryToAirport() {
 				untilIATACode = ""
 			}
 
-			result, err := theAirports.GetList(country.CountryCode, "", fromICAOCode.(string), untilICAOCode.(string), fromIATACode.(string), untilIATACode.(string))
+			request, err := pageRequest(p)
 			if err != nil {
-				return nil, fmt.Errorf("Country.Airports(): Not Found")
+				return nil, fmt.Errorf("Country.Airports: %w", err)
 			}
-			if len(result) == 0 {
-				return nil, fmt.Errorf("Country.Airports(): Not found")
+
+			result, page, err := theAirports.GetPage(country.CountryCode, "", fromICAOCode.(string),
+				untilICAOCode.(string), fromIATACode.(string), untilIATACode.(string), "", request)
+			if err != nil {
+				return nil, fmt.Errorf("Country.Airports: %w", err)
 			}
 
-			return result, nil
+			var nodes []interface{}
+			var keys []string
+			for _, airport := range result {
+				nodes = append(nodes, airport)
+				keys = append(keys, airport.AirportCode)
+			}
+			return newConnection(nodes, keys, page), nil
 		},
 	})
 }
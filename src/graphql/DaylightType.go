@@ -0,0 +1,93 @@
+package graphql
+
+import (
+	"github.com/graphql-go/graphql"
+
+	"../airports"
+	"../datatypes"
+	"../failures"
+)
+
+// daylightTimesType is the GraphQL representation of the daylight events in one time zone
+var daylightTimesType = graphql.NewObject(
+	graphql.ObjectConfig{
+		Name: "DaylightTimes",
+		Fields: graphql.Fields{
+			"NauticalDawn": &graphql.Field{
+				Type: graphql.DateTime,
+			},
+			"CivilDawn": &graphql.Field{
+				Type: graphql.DateTime,
+			},
+			"Sunrise": &graphql.Field{
+				Type: graphql.DateTime,
+			},
+			"SolarNoon": &graphql.Field{
+				Type: graphql.DateTime,
+			},
+			"Sunset": &graphql.Field{
+				Type: graphql.DateTime,
+			},
+			"CivilDusk": &graphql.Field{
+				Type: graphql.DateTime,
+			},
+			"NauticalDusk": &graphql.Field{
+				Type: graphql.DateTime,
+			},
+		},
+	})
+
+// daylightType is the GraphQL representation of the daylight on a date
+var daylightType = graphql.NewObject(
+	graphql.ObjectConfig{
+		Name: "Daylight",
+		Fields: graphql.Fields{
+			"Date": &graphql.Field{
+				Type: graphql.String,
+			},
+			"TimeZone": &graphql.Field{
+				Type: graphql.String,
+			},
+			"DayLength": &graphql.Field{
+				Type: graphql.Int,
+			},
+			"PolarDay": &graphql.Field{
+				Type: graphql.Boolean,
+			},
+			"PolarNight": &graphql.Field{
+				Type: graphql.Boolean,
+			},
+			"UTC": &graphql.Field{
+				Type: daylightTimesType,
+			},
+			"Local": &graphql.Field{
+				Type: daylightTimesType,
+			},
+		},
+	})
+
+func addDaylightToAirport() {
+	airportType.AddFieldConfig("Daylight", &graphql.Field{
+		Type: daylightType,
+		Args: graphql.FieldConfigArgument{
+			"Date": &graphql.ArgumentConfig{
+				Type: graphql.String,
+			},
+		},
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			airport := p.Source.(*airports.Airport)
+
+			date := airport.Today()
+			text, ok := p.Args["Date"]
+			if ok {
+				var err error
+				date, err = datatypes.Date(text.(string), false)
+				if err != nil {
+					return nil, failures.New(failures.InvalidArgument, "Airport.Daylight(%s): %v", text.(string), err)
+				}
+			}
+
+			return airport.Daylight(date), nil
+		},
+	})
+}
@@ -6,6 +6,8 @@ import (
 	"github.com/graphql-go/graphql"
 
 	"../airports"
+	"../failures"
+	"../paging"
 )
 
 // frequencyView is a representation to help in graphql by adding a back-link to the airport
@@ -52,7 +54,7 @@ func addFrequencyToAirport() {
 
 			result, err := theAirports.GetByAirportCode(frequency.AirportCode)
 			if err != nil {
-				return nil, fmt.Errorf("Frequency.Airport: %v", err)
+				return nil, fmt.Errorf("Frequency.Airport: %w", err)
 			}
 
 			return result, nil
@@ -60,8 +62,11 @@ func addFrequencyToAirport() {
 	})
 }
 
+// frequencyQuery finds the first frequency of a type at an airport, an airport can have more
 var frequencyQuery = &graphql.Field{
 	Type: frequencyType,
+	DeprecationReason: "An airport can have more frequencies of a type, use frequencies with " +
+		"FromFrequencyType and UntilFrequencyType",
 	Args: graphql.FieldConfigArgument{
 		"ICAOCode": &graphql.ArgumentConfig{
 			Type: graphql.String,
@@ -81,7 +86,7 @@ var frequencyQuery = &graphql.Field{
 		if ok {
 			airport, err = theAirports.GetByAirportCode(icaoCode.(string))
 			if err != nil {
-				return nil, fmt.Errorf("Frequency(%s): %v", icaoCode.(string), err)
+				return nil, fmt.Errorf("Frequency(%s): %w", icaoCode.(string), err)
 			}
 		}
 
@@ -89,13 +94,13 @@ var frequencyQuery = &graphql.Field{
 		if ok {
 			airport, err = theAirports.GetByIATACode(iataCode.(string))
 			if err != nil {
-				return nil, fmt.Errorf("Frequency(%s): %v", iataCode.(string), err)
+				return nil, fmt.Errorf("Frequency(%s): %w", iataCode.(string), err)
 			}
 		}
 
 		frequencyType, ok := p.Args["FrequencyType"]
 		if !ok {
-			return nil, fmt.Errorf("Frequency: missing FrequencyType parameter")
+			return nil, failures.New(failures.InvalidArgument, "Frequency: missing FrequencyType parameter")
 		}
 
 		for _, frequency := range airport.Frequencies {
@@ -104,12 +109,14 @@ var frequencyQuery = &graphql.Field{
 			}
 		}
 
-		return nil, fmt.Errorf("Frequency: not found")
+		return nil, failures.New(failures.NotFound, "Frequency: not found")
 	}}
 
+var frequencyConnectionType = connectionType(frequencyType)
+
 var frequenciesQuery = &graphql.Field{
-	Type: graphql.NewList(frequencyType),
-	Args: graphql.FieldConfigArgument{
+	Type: frequencyConnectionType,
+	Args: connectionArgs(graphql.FieldConfigArgument{
 		"FromICAOCode": &graphql.ArgumentConfig{
 			Type: graphql.String,
 		},
@@ -128,7 +135,7 @@ var frequenciesQuery = &graphql.Field{
 		"UntilFrequencyType": &graphql.ArgumentConfig{
 			Type: graphql.String,
 		},
-	},
+	}),
 	Resolve: func(p graphql.ResolveParams) (interface{}, error) {
 
 		fromICAOCode, hasFromICAOCode := p.Args["FromICAOCode"]
@@ -136,7 +143,7 @@ var frequenciesQuery = &graphql.Field{
 		fromIATACode, hasFromIATACode := p.Args["FromIATACode"]
 		untilIATACode, hasUntilIATACode := p.Args["UntilIATACode"]
 		if !hasFromICAOCode && !hasUntilICAOCode && !hasFromIATACode && !hasUntilIATACode {
-			return nil, fmt.Errorf("Frequencies: Missing From/Until airport selection")
+			return nil, failures.New(failures.InvalidArgument, "Frequencies: Missing From/Until airport selection")
 		}
 		if !hasFromICAOCode {
 			fromICAOCode = ""
@@ -151,43 +158,48 @@ var frequenciesQuery = &graphql.Field{
 			untilIATACode = ""
 		}
 
-		fromFrequencyType, hasFromFrequencyType := p.Args["FromFrequencyType"]
-		untilFrequencyType, hasUntilFrequencyType := p.Args["UntilFrequencyType"]
-		if !hasFromFrequencyType {
-			fromFrequencyType = ""
+		var filter airports.FrequencyFilter
+		if fromFrequencyType, ok := p.Args["FromFrequencyType"]; ok {
+			filter.FromFrequencyType = fromFrequencyType.(string)
 		}
-		if !hasUntilFrequencyType {
-			untilFrequencyType = ""
+		if untilFrequencyType, ok := p.Args["UntilFrequencyType"]; ok {
+			filter.UntilFrequencyType = untilFrequencyType.(string)
 		}
 
-		airportList, err := theAirports.GetList("", "",
-			fromICAOCode.(string),
-			untilICAOCode.(string),
-			fromIATACode.(string),
-			untilIATACode.(string))
+		request, err := pageRequest(p)
 		if err != nil {
-			return nil, fmt.Errorf("Frequencies: %v", err)
+			return nil, fmt.Errorf("Frequencies: %w", err)
 		}
 
-		var result []*frequencyView
-		for _, airport := range airportList {
-			for _, frequency := range airport.Frequencies {
-				addView := true
-				if hasFromFrequencyType && frequency.FrequencyType < fromFrequencyType.(string) {
-					addView = false
-				}
-				if hasUntilFrequencyType && frequency.FrequencyType > untilFrequencyType.(string) {
-					addView = false
-				}
-				if addView {
-					result = append(result, asFrequencyView(airport, frequency))
+		// A frequency is known by its airport and its place in the list of the airport. The airports
+		// are read a page at a time, until the page of frequencies is full.
+		frequencies := make(map[string]interface{})
+		keys, page, err := paging.Nested(request, "/", func(documents *paging.Request) ([]string, *paging.Page, error) {
+			airportList, page, err := theAirports.GetPage("", "",
+				fromICAOCode.(string),
+				untilICAOCode.(string),
+				fromIATACode.(string),
+				untilIATACode.(string),
+				"", documents)
+			var keys []string
+			for _, airport := range airportList {
+				for i, frequency := range airport.Frequencies {
+					if filter.Matches(frequency) {
+						key := fmt.Sprintf("%s/%03d", airport.AirportCode, i)
+						frequencies[key] = asFrequencyView(airport, frequency)
+						keys = append(keys, key)
+					}
 				}
 			}
+			return keys, page, err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("Frequencies: %w", err)
 		}
 
-		if len(result) == 0 {
-			return nil, fmt.Errorf("Frequencies: not found")
+		var nodes []interface{}
+		for _, key := range keys {
+			nodes = append(nodes, frequencies[key])
 		}
-
-		return result, nil
+		return newConnection(nodes, keys, page), nil
 	}}
@@ -0,0 +1,111 @@
+package graphql
+
+import (
+	"github.com/graphql-go/graphql"
+
+	"../countries"
+	"../failures"
+	"../geometry"
+)
+
+// positionType is the GraphQL representation of a position, like a centroid
+var positionType = graphql.NewObject(
+	graphql.ObjectConfig{
+		Name: "Position",
+		Fields: graphql.Fields{
+			"Latitude": &graphql.Field{
+				Type: graphql.Float,
+			},
+			"Longitude": &graphql.Field{
+				Type: graphql.Float,
+			},
+		},
+	})
+
+// boxType is the GraphQL representation of a bounding box
+var boxType = graphql.NewObject(
+	graphql.ObjectConfig{
+		Name: "BoundingBox",
+		Fields: graphql.Fields{
+			"MinLatitude": &graphql.Field{
+				Type: graphql.Float,
+			},
+			"MinLongitude": &graphql.Field{
+				Type: graphql.Float,
+			},
+			"MaxLatitude": &graphql.Field{
+				Type: graphql.Float,
+			},
+			"MaxLongitude": &graphql.Field{
+				Type: graphql.Float,
+			},
+		},
+	})
+
+// geoJSONType passes a GeoJSON geometry as is, there is no sensible way to express the nested
+// coordinates in GraphQL types
+var geoJSONType = graphql.NewScalar(
+	graphql.ScalarConfig{
+		Name:        "GeoJSON",
+		Description: "A GeoJSON geometry object",
+		Serialize: func(value interface{}) interface{} {
+			return value
+		},
+	})
+
+// geometryArgs are the arguments of the Geometry fields; the tolerance is in degrees, zero is
+// the full geometry
+var geometryArgs = graphql.FieldConfigArgument{
+	"Tolerance": &graphql.ArgumentConfig{
+		Type:         graphql.Float,
+		DefaultValue: countries.SimplifiedTolerance,
+	},
+}
+
+func addGeometryToCountry() {
+	countryType.AddFieldConfig("Centroid", &graphql.Field{
+		Type: positionType,
+	})
+	countryType.AddFieldConfig("Bounds", &graphql.Field{
+		Type: boxType,
+	})
+	countryType.AddFieldConfig("Geometry", &graphql.Field{
+		Type: geoJSONType,
+		Args: geometryArgs,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			country := p.Source.(*countries.Country)
+			return resolveGeometry(country.CountryCode, "", p.Args["Tolerance"].(float64))
+		},
+	})
+}
+
+func addGeometryToRegion() {
+	regionType.AddFieldConfig("Centroid", &graphql.Field{
+		Type: positionType,
+	})
+	regionType.AddFieldConfig("Bounds", &graphql.Field{
+		Type: boxType,
+	})
+	regionType.AddFieldConfig("Geometry", &graphql.Field{
+		Type: geoJSONType,
+		Args: geometryArgs,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			region := p.Source.(*regionView)
+			return resolveGeometry(region.CountryCode, region.RegionCode, p.Args["Tolerance"].(float64))
+		},
+	})
+}
+
+// resolveGeometry retrieves a geometry; an area without a boundary simply has no geometry
+func resolveGeometry(countryCode string, regionCode string, tolerance float64) (interface{}, error) {
+	if tolerance < 0 {
+		return nil, failures.New(failures.InvalidArgument, "Geometry(%f): Invalid tolerance", tolerance)
+	}
+
+	area, err := theBoundaries.GetGeometry(countryCode, regionCode, tolerance)
+	if err != nil {
+		return nil, nil
+	}
+
+	return geometry.NewGeometry(area), nil
+}
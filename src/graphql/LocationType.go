@@ -0,0 +1,84 @@
+package graphql
+
+import (
+	"fmt"
+
+	"github.com/graphql-go/graphql"
+
+	"../countries"
+	"../failures"
+)
+
+// locationType is the GraphQL representation of the country and region containing a position
+var locationType = graphql.NewObject(
+	graphql.ObjectConfig{
+		Name: "Location",
+		Fields: graphql.Fields{
+			"Latitude": &graphql.Field{
+				Type: graphql.Float,
+			},
+			"Longitude": &graphql.Field{
+				Type: graphql.Float,
+			},
+			"CountryCode": &graphql.Field{
+				Type: graphql.String,
+			},
+			"RegionCode": &graphql.Field{
+				Type: graphql.String,
+			},
+			"Country": &graphql.Field{
+				Type: countryType,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					location := p.Source.(*countries.Location)
+					country, err := theCountries.GetByCountryCode(location.CountryCode)
+					if err != nil {
+						return nil, fmt.Errorf("Location.Country: %w", err)
+					}
+					return country, nil
+				},
+			},
+			"Region": &graphql.Field{
+				Type: regionType,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					location := p.Source.(*countries.Location)
+					if len(location.RegionCode) == 0 {
+						return nil, nil
+					}
+					country, err := theCountries.GetByCountryCode(location.CountryCode)
+					if err != nil {
+						return nil, fmt.Errorf("Location.Region: %w", err)
+					}
+					for _, region := range country.Regions {
+						if region.RegionCode == location.RegionCode {
+							return asRegionView(country, region), nil
+						}
+					}
+					return nil, failures.New(failures.NotFound, "Location.Region: Not Found")
+				},
+			},
+		},
+	})
+
+var locateQuery = &graphql.Field{
+	Type: locationType,
+	Args: graphql.FieldConfigArgument{
+		"Latitude": &graphql.ArgumentConfig{
+			Type: graphql.NewNonNull(graphql.Float),
+		},
+		"Longitude": &graphql.ArgumentConfig{
+			Type: graphql.NewNonNull(graphql.Float),
+		},
+	},
+	Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+		latitude := p.Args["Latitude"].(float64)
+		longitude := p.Args["Longitude"].(float64)
+		if latitude < -90 || latitude > 90 || longitude < -180 || longitude > 180 {
+			return nil, failures.New(failures.InvalidArgument, "Locate(%f, %f): Invalid position", latitude, longitude)
+		}
+
+		location, err := theBoundaries.Locate(latitude, longitude)
+		if err != nil {
+			return nil, fmt.Errorf("Locate(%f, %f): %w", latitude, longitude, err)
+		}
+		return location, nil
+	}}
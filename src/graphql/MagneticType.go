@@ -0,0 +1,76 @@
+package graphql
+
+import (
+	"time"
+
+	"github.com/graphql-go/graphql"
+
+	"../airports"
+	"../datatypes"
+	"../failures"
+)
+
+// magneticFieldType is the GraphQL representation of the magnetic field at a position, the model
+// is outside its epoch when the date is before or more than five years after its release
+var magneticFieldType = graphql.NewObject(
+	graphql.ObjectConfig{
+		Name: "MagneticField",
+		Fields: graphql.Fields{
+			"Model": &graphql.Field{
+				Type: graphql.String,
+			},
+			"Declination": &graphql.Field{
+				Type: graphql.Float,
+			},
+			"Inclination": &graphql.Field{
+				Type: graphql.Float,
+			},
+			"North": &graphql.Field{
+				Type: graphql.Float,
+			},
+			"East": &graphql.Field{
+				Type: graphql.Float,
+			},
+			"Down": &graphql.Field{
+				Type: graphql.Float,
+			},
+			"Horizontal": &graphql.Field{
+				Type: graphql.Float,
+			},
+			"Total": &graphql.Field{
+				Type: graphql.Float,
+			},
+			"DecimalYear": &graphql.Field{
+				Type: graphql.Float,
+			},
+			"OutsideEpoch": &graphql.Field{
+				Type: graphql.Boolean,
+			},
+		},
+	})
+
+func addMagneticFieldToAirport() {
+	airportType.AddFieldConfig("MagneticField", &graphql.Field{
+		Type: magneticFieldType,
+		Args: graphql.FieldConfigArgument{
+			"Date": &graphql.ArgumentConfig{
+				Type: graphql.String,
+			},
+		},
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			airport := p.Source.(*airports.Airport)
+
+			date := time.Now()
+			text, ok := p.Args["Date"]
+			if ok {
+				var err error
+				date, err = datatypes.Date(text.(string), false)
+				if err != nil {
+					return nil, failures.New(failures.InvalidArgument, "Airport.MagneticField(%s): %v", text.(string), err)
+				}
+			}
+
+			return theMagnetic.Field(airport.Latitude, airport.Longitude, airport.Elevation*0.3048/1000.0, date), nil
+		},
+	})
+}
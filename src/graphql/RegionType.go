@@ -6,15 +6,20 @@ import (
 	"github.com/graphql-go/graphql"
 
 	"../countries"
+	"../failures"
+	"../geometry"
+	"../paging"
 )
 
 // regionView is the external representation 'flattened' so it is easier to handle in
 // graphql, for instance for back-linking in the graph
 type regionView struct {
-	CountryCode string `json:"iso-country-code"`
-	RegionCode  string `json:"iso-region-code"`
-	RegionName  string `json:"region-name"`
-	Wikipedia   string `json:"wikipedia,omitempty"`
+	CountryCode string             `json:"iso-country-code"`
+	RegionCode  string             `json:"iso-region-code"`
+	RegionName  string             `json:"region-name"`
+	Wikipedia   string             `json:"wikipedia,omitempty"`
+	Centroid    *geometry.Position `json:"centroid,omitempty"`
+	Bounds      *geometry.Box      `json:"bounds,omitempty"`
 }
 
 // asRegionView translates the internal view to the view more suitable for graphql:
@@ -27,6 +32,8 @@ func asRegionView(country *countries.Country, region *countries.Region) *regionV
 	result.RegionCode = region.RegionCode
 	result.RegionName = region.RegionName
 	result.Wikipedia = region.Wikipedia
+	result.Centroid = region.Centroid
+	result.Bounds = region.Bounds
 
 	return &result
 }
@@ -58,7 +65,7 @@ func addRegionToCountry() {
 
 			result, err := theCountries.GetByCountryCode(region.CountryCode)
 			if err != nil {
-				return nil, fmt.Errorf("Region.Country: %v", err)
+				return nil, fmt.Errorf("Region.Country: %w", err)
 			}
 
 			return result, nil
@@ -79,11 +86,11 @@ var regionQuery = &graphql.Field{
 	Resolve: func(p graphql.ResolveParams) (interface{}, error) {
 		countryCode, ok := p.Args["CountryCode"]
 		if !ok {
-			return nil, fmt.Errorf("Missing CountryCode parameter")
+			return nil, failures.New(failures.InvalidArgument, "Missing CountryCode parameter")
 		}
 		regionCode, ok := p.Args["RegionCode"]
 		if !ok {
-			return nil, fmt.Errorf("Missing RegionCode parameter")
+			return nil, failures.New(failures.InvalidArgument, "Missing RegionCode parameter")
 		}
 		country, err := theCountries.GetByCountryCode(countryCode.(string))
 		if err != nil {
@@ -94,12 +101,14 @@ var regionQuery = &graphql.Field{
 				return asRegionView(country, region), nil
 			}
 		}
-		return nil, fmt.Errorf("Region:Not found")
+		return nil, failures.New(failures.NotFound, "Region:Not found")
 	}}
 
+var regionConnectionType = connectionType(regionType)
+
 var regionsQuery = &graphql.Field{
-	Type: graphql.NewList(regionType),
-	Args: graphql.FieldConfigArgument{
+	Type: regionConnectionType,
+	Args: connectionArgs(graphql.FieldConfigArgument{
 		"FromCountryCode": &graphql.ArgumentConfig{
 			Type: graphql.String,
 		},
@@ -112,13 +121,13 @@ var regionsQuery = &graphql.Field{
 		"UntilRegionCode": &graphql.ArgumentConfig{
 			Type: graphql.String,
 		},
-	},
+	}),
 	Resolve: func(p graphql.ResolveParams) (interface{}, error) {
 
 		fromCountryCode, hasFromCountryCode := p.Args["FromCountryCode"]
 		untilCountryCode, hasUntilCountryCode := p.Args["UntilCountryCode"]
 		if !hasFromCountryCode && !hasUntilCountryCode {
-			return nil, fmt.Errorf("Missing From/Until CountryCode parameter")
+			return nil, failures.New(failures.InvalidArgument, "Missing From/Until CountryCode parameter")
 		}
 		if !hasFromCountryCode {
 			fromCountryCode = ""
@@ -136,21 +145,37 @@ var regionsQuery = &graphql.Field{
 			untilRegionCode = ""
 		}
 
-		var result []*regionView
-		countryList, err := theCountries.GetList(fromCountryCode.(string), untilCountryCode.(string))
+		request, err := pageRequest(p)
 		if err != nil {
-			return nil, fmt.Errorf("Regions: %v", err)
+			return nil, fmt.Errorf("Regions: %w", err)
 		}
-		for _, country := range countryList {
-			for _, region := range country.Regions {
-				if (!hasFromRegionCode || region.RegionCode >= fromRegionCode.(string)) && (!hasUntilRegionCode || region.RegionCode <= untilRegionCode.(string)) {
-					result = append(result, asRegionView(country, region))
+
+		// The codes within a country repeat over the countries, the regions are paged on their ISO
+		// code. The countries are read a page at a time, until the page of regions is full.
+		regions := make(map[string]interface{})
+		keys, page, err := paging.Nested(request, "-", func(documents *paging.Request) ([]string, *paging.Page, error) {
+			countryList, page, err := theCountries.GetPage(fromCountryCode.(string), untilCountryCode.(string),
+				documents)
+			var keys []string
+			for _, country := range countryList {
+				for _, region := range country.Regions {
+					if (!hasFromRegionCode || region.RegionCode >= fromRegionCode.(string)) &&
+						(!hasUntilRegionCode || region.RegionCode <= untilRegionCode.(string)) {
+						key := country.CountryCode + "-" + region.RegionCode
+						regions[key] = asRegionView(country, region)
+						keys = append(keys, key)
+					}
 				}
 			}
-		}
-		if len(result) == 0 {
-			return nil, fmt.Errorf("Regions: Not found")
+			return keys, page, err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("Regions: %w", err)
 		}
 
-		return result, nil
+		var nodes []interface{}
+		for _, key := range keys {
+			nodes = append(nodes, regions[key])
+		}
+		return newConnection(nodes, keys, page), nil
 	}}
@@ -2,43 +2,56 @@ package graphql
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/graphql-go/graphql"
 
 	"../airports"
+	"../failures"
+	"../magnetic"
 )
 
-// runwayView expresses a model where the runway is flattened and a back-link to 
+// runwayView expresses a model where the runway is flattened and a back-link to
 // the airport added to be more suitable for graphql.
 type runwayView struct {
-	AirportCode   string  `json:"icao-airport-code"`
-	RunwayCode    string  `json:"runway-code"`
-	AltRunwayCode string  `json:"alt-runway-code"`
-	Latitude      float64 `json:"latitude,omitempty"`
-	Longitude     float64 `json:"longitude,omitempty"`
-	Elevation     int     `json:"elevation,omitempty"`
-	Heading       int     `json:"heading,omitempty"`
-	Threshold     int     `json:"threshold,omitempty"`
-	Length        int     `json:"length"`
-	Width         int     `json:"width"`
-	Surface       string  `json:"surface"`
-	Lighted       bool    `json:"lighted"`
-	Closed        bool    `json:"closed"`
+	AirportCode     string  `json:"icao-airport-code"`
+	RunwayCode      string  `json:"runway-code"`
+	AltRunwayCode   string  `json:"alt-runway-code"`
+	Latitude        float64 `json:"latitude,omitempty"`
+	Longitude       float64 `json:"longitude,omitempty"`
+	Elevation       int     `json:"elevation,omitempty"`
+	Heading         int     `json:"heading,omitempty"`
+	MagneticHeading int     `json:"magnetic-heading,omitempty"`
+	Threshold       int     `json:"threshold,omitempty"`
+	Length          int     `json:"length"`
+	Width           int     `json:"width"`
+	Surface         string  `json:"surface"`
+	Lighted         bool    `json:"lighted"`
+	Closed          bool    `json:"closed"`
 }
 
+// asRunwayView splits a runway in its directions. The stored headings are true headings, the
+// magnetic headings are calculated with today's variation at the airport.
 func asRunwayView(airport *airports.Airport, runway *airports.Runway) []*runwayView {
 	var result []*runwayView
 
+	declination := theMagnetic.Declination(airport.Latitude, airport.Longitude, airport.Elevation, time.Now())
+
 	if len(runway.LowEnd.RunwayCode) > 0 {
 		var runwayView runwayView
 
 		runwayView.AirportCode = airport.AirportCode
 		runwayView.RunwayCode = runway.LowEnd.RunwayCode
-		runwayView.AltRunwayCode = runway.HighEnd.RunwayCode
+		if runway.HighEnd != nil {
+			runwayView.AltRunwayCode = runway.HighEnd.RunwayCode
+		}
 		runwayView.Latitude = runway.LowEnd.Latitude
 		runwayView.Longitude = runway.LowEnd.Longitude
 		runwayView.Elevation = runway.LowEnd.Elevation
 		runwayView.Heading = runway.LowEnd.Heading
+		if runwayView.Heading != 0 {
+			runwayView.MagneticHeading = magnetic.MagneticHeading(runwayView.Heading, declination)
+		}
 		runwayView.Threshold = runway.LowEnd.Threshold
 		runwayView.Length = runway.Length
 		runwayView.Width = runway.Width
@@ -49,7 +62,7 @@ func asRunwayView(airport *airports.Airport, runway *airports.Runway) []*runwayV
 		result = append(result, &runwayView)
 	}
 
-	if len(runway.HighEnd.RunwayCode) > 0 {
+	if runway.HighEnd != nil && len(runway.HighEnd.RunwayCode) > 0 {
 		var runwayView runwayView
 
 		runwayView.AirportCode = airport.AirportCode
@@ -59,6 +72,9 @@ func asRunwayView(airport *airports.Airport, runway *airports.Runway) []*runwayV
 		runwayView.Longitude = runway.HighEnd.Longitude
 		runwayView.Elevation = runway.HighEnd.Elevation
 		runwayView.Heading = runway.HighEnd.Heading
+		if runwayView.Heading != 0 {
+			runwayView.MagneticHeading = magnetic.MagneticHeading(runwayView.Heading, declination)
+		}
 		runwayView.Threshold = runway.HighEnd.Threshold
 		runwayView.Length = runway.Length
 		runwayView.Width = runway.Width
@@ -95,6 +111,9 @@ var runwayType = graphql.NewObject(
 			"Heading": &graphql.Field{
 				Type: graphql.Int,
 			},
+			"MagneticHeading": &graphql.Field{
+				Type: graphql.Int,
+			},
 			"Threshold": &graphql.Field{
 				Type: graphql.Int,
 			},
@@ -124,7 +143,7 @@ func addRunwayToAirport() {
 
 			result, err := theAirports.GetByAirportCode(runway.AirportCode)
 			if err != nil {
-				return nil, fmt.Errorf("Runway.Airport: %v", err)
+				return nil, fmt.Errorf("Runway.Airport: %w", err)
 			}
 
 			return result, nil
@@ -153,7 +172,7 @@ var runwayQuery = &graphql.Field{
 		if ok {
 			airport, err = theAirports.GetByAirportCode(icaoCode.(string))
 			if err != nil {
-				return nil, fmt.Errorf("Runway(%s): %v", icaoCode.(string), err)
+				return nil, fmt.Errorf("Runway(%s): %w", icaoCode.(string), err)
 			}
 		}
 
@@ -161,17 +180,17 @@ var runwayQuery = &graphql.Field{
 		if ok {
 			airport, err = theAirports.GetByIATACode(iataCode.(string))
 			if err != nil {
-				return nil, fmt.Errorf("Runway(%s): %v", iataCode.(string), err)
+				return nil, fmt.Errorf("Runway(%s): %w", iataCode.(string), err)
 			}
 		}
 
 		if airport == nil {
-			return nil, fmt.Errorf("Runway: Missing AirportCode or IATACode parameter")
+			return nil, failures.New(failures.InvalidArgument, "Runway: Missing AirportCode or IATACode parameter")
 		}
 
 		runwayCode, ok := p.Args["RunwayCode"]
 		if !ok {
-			return nil, fmt.Errorf("Runway: Missing RunwayCode parameter")
+			return nil, failures.New(failures.InvalidArgument, "Runway: Missing RunwayCode parameter")
 		}
 
 		for _, runway := range airport.Runways {
@@ -183,13 +202,15 @@ var runwayQuery = &graphql.Field{
 			}
 		}
 
-		return nil, fmt.Errorf("Not Found")
+		return nil, failures.New(failures.NotFound, "Not Found")
 	},
 }
 
+var runwayConnectionType = connectionType(runwayType)
+
 var runwaysQuery = &graphql.Field{
-	Type: graphql.NewList(runwayType),
-	Args: graphql.FieldConfigArgument{
+	Type: runwayConnectionType,
+	Args: connectionArgs(graphql.FieldConfigArgument{
 		"ICAOCode": &graphql.ArgumentConfig{
 			Type: graphql.String,
 		},
@@ -217,7 +238,7 @@ var runwaysQuery = &graphql.Field{
 		"Closed": &graphql.ArgumentConfig{
 			Type: graphql.Boolean,
 		},
-	},
+	}),
 	Resolve: func(p graphql.ResolveParams) (interface{}, error) {
 		var airport *airports.Airport
 		var err error
@@ -226,7 +247,7 @@ var runwaysQuery = &graphql.Field{
 		if hasICAOCode {
 			airport, err = theAirports.GetByAirportCode(icaoCode.(string))
 			if err != nil {
-				return nil, fmt.Errorf("Runways(%s): %v", icaoCode.(string), err)
+				return nil, fmt.Errorf("Runways(%s): %w", icaoCode.(string), err)
 			}
 		}
 
@@ -234,58 +255,63 @@ var runwaysQuery = &graphql.Field{
 		if hasIATACode {
 			airport, err = theAirports.GetByIATACode(iataCode.(string))
 			if err != nil {
-				return nil, fmt.Errorf("Runways(%s): %v", iataCode.(string), err)
+				return nil, fmt.Errorf("Runways(%s): %w", iataCode.(string), err)
 			}
 		}
 
 		if airport == nil {
-			return nil, fmt.Errorf("Runways: Missing AirportCode or IATACode parameter")
+			return nil, failures.New(failures.InvalidArgument, "Runways: Missing AirportCode or IATACode parameter")
 		}
 
-		fromRunwayCode, hasFromRunwayCode := p.Args["FromRunwayCode"]
-		untilRunwayCode, hasUntilRunwayCode := p.Args["UntilRunwayCode"]
-		fromHeading, hasFromHeading := p.Args["FromHeading"]
-		untilHeading, hasUntilHeading := p.Args["UntilHeading"]
-		fromLength, hasFromLength := p.Args["FromLength"]
-		untilLength, hasUntilLength := p.Args["UntilLength"]
-		closed, hasClosed := p.Args["Closed"]
+		var filter airports.RunwayFilter
+		if fromRunwayCode, ok := p.Args["FromRunwayCode"]; ok {
+			filter.FromRunwayCode = fromRunwayCode.(string)
+		}
+		if untilRunwayCode, ok := p.Args["UntilRunwayCode"]; ok {
+			filter.UntilRunwayCode = untilRunwayCode.(string)
+		}
+		if fromHeading, ok := p.Args["FromHeading"]; ok {
+			value := fromHeading.(int)
+			filter.FromHeading = &value
+		}
+		if untilHeading, ok := p.Args["UntilHeading"]; ok {
+			value := untilHeading.(int)
+			filter.UntilHeading = &value
+		}
+		if fromLength, ok := p.Args["FromLength"]; ok {
+			value := fromLength.(int)
+			filter.FromLength = &value
+		}
+		if untilLength, ok := p.Args["UntilLength"]; ok {
+			value := untilLength.(int)
+			filter.UntilLength = &value
+		}
+		if closed, ok := p.Args["Closed"]; ok {
+			value := closed.(bool)
+			filter.Closed = &value
+		}
 
-		var result []*runwayView
+		request, err := pageRequest(p)
+		if err != nil {
+			return nil, fmt.Errorf("Runways: %w", err)
+		}
+
+		var result []interface{}
+		var keys []string
 		for _, runway := range airport.Runways {
 			runwaySides := asRunwayView(airport, runway)
 			for _, runwayView := range runwaySides {
-				var addView = true
-				if hasFromRunwayCode && runwayView.RunwayCode < fromRunwayCode.(string) {
-					addView = false
-				}
-				if hasUntilRunwayCode && runwayView.RunwayCode > untilRunwayCode.(string) {
-					addView = false
-				}
-				if hasFromHeading && runwayView.Heading < fromHeading.(int) {
-					addView = false
-				}
-				if hasUntilHeading && runwayView.Heading > untilHeading.(int) {
-					addView = false
-				}
-				if hasFromLength && runwayView.Length < fromLength.(int) {
-					addView = false
-				}
-				if hasUntilLength && runwayView.Length > untilLength.(int) {
-					addView = false
-				}
-				if hasClosed && runwayView.Closed != closed.(bool) {
-					addView = false
-				}
-				if addView {
+				if filter.Matches(runway, runwayView.RunwayCode, runwayView.Heading) {
 					result = append(result, runwayView)
+					keys = append(keys, runwayView.RunwayCode)
 				}
 			}
 		}
 
 		if len(result) == 0 {
-			return nil, fmt.Errorf("Runways: Not Found")
+			return nil, failures.New(failures.NotFound, "Runways: Not Found")
 		}
 
-		return result, nil
+		return sliceConnection(request, result, keys), nil
 	},
 }
@@ -0,0 +1,83 @@
+package graphql
+
+import (
+	"strconv"
+
+	"github.com/graphql-go/graphql"
+
+	"../airports"
+	"../datatypes"
+	"../failures"
+)
+
+// runwayWindType is the GraphQL representation of the wind on a single runway direction
+var runwayWindType = graphql.NewObject(
+	graphql.ObjectConfig{
+		Name: "RunwayWind",
+		Fields: graphql.Fields{
+			"RunwayCode": &graphql.Field{
+				Type: graphql.String,
+			},
+			"Heading": &graphql.Field{
+				Type: graphql.Int,
+			},
+			"Length": &graphql.Field{
+				Type: graphql.Int,
+			},
+			"Closed": &graphql.Field{
+				Type: graphql.Boolean,
+			},
+			"Headwind": &graphql.Field{
+				Type: graphql.Float,
+			},
+			"Crosswind": &graphql.Field{
+				Type: graphql.Float,
+			},
+			"Usable": &graphql.Field{
+				Type: graphql.Boolean,
+			},
+			"Rank": &graphql.Field{
+				Type: graphql.Int,
+			},
+		},
+	})
+
+func addRunwayWindToAirport() {
+	airportType.AddFieldConfig("RunwayWinds", &graphql.Field{
+		Type: graphql.NewList(runwayWindType),
+		Args: graphql.FieldConfigArgument{
+			"Direction": &graphql.ArgumentConfig{
+				Type: graphql.NewNonNull(graphql.Int),
+			},
+			"Speed": &graphql.ArgumentConfig{
+				Type: graphql.NewNonNull(graphql.Int),
+			},
+			"CrosswindLimit": &graphql.ArgumentConfig{
+				Type: graphql.Int,
+			},
+		},
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			airport := p.Source.(*airports.Airport)
+
+			// The arguments follow the rules of the parameters of the REST interface
+			direction, err := datatypes.WindDirection(strconv.Itoa(p.Args["Direction"].(int)), false)
+			if err != nil {
+				return nil, failures.New(failures.InvalidArgument, "Airport.RunwayWinds.Direction: %v", err)
+			}
+			speed, err := datatypes.WindSpeed(strconv.Itoa(p.Args["Speed"].(int)), false)
+			if err != nil {
+				return nil, failures.New(failures.InvalidArgument, "Airport.RunwayWinds.Speed: %v", err)
+			}
+			crosswindLimit, ok := p.Args["CrosswindLimit"].(int)
+			if !ok {
+				crosswindLimit = theAirports.CrosswindLimit()
+			}
+			crosswindLimit, err = datatypes.WindSpeed(strconv.Itoa(crosswindLimit), false)
+			if err != nil {
+				return nil, failures.New(failures.InvalidArgument, "Airport.RunwayWinds.CrosswindLimit: %v", err)
+			}
+
+			return airport.RunwayWinds(direction, speed, crosswindLimit), nil
+		},
+	})
+}
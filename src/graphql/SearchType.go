@@ -0,0 +1,113 @@
+package graphql
+
+import (
+	"fmt"
+
+	"github.com/graphql-go/graphql"
+
+	"../failures"
+	"../search"
+)
+
+// searchResultType is the GraphQL representation of a result of a search, only the field of its
+// kind is filled
+var searchResultType = graphql.NewObject(
+	graphql.ObjectConfig{
+		Name: "SearchResult",
+		Fields: graphql.Fields{
+			"Kind": &graphql.Field{
+				Type: graphql.String,
+			},
+			"Code": &graphql.Field{
+				Type: graphql.String,
+			},
+			"CountryCode": &graphql.Field{
+				Type: graphql.String,
+			},
+			"Name": &graphql.Field{
+				Type: graphql.String,
+			},
+			"Score": &graphql.Field{
+				Type: graphql.Float,
+			},
+			"Airport": &graphql.Field{
+				Type: airportType,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					result := p.Source.(*search.Result)
+					if result.Kind != search.Airport {
+						return nil, nil
+					}
+					airport, err := theAirports.GetByAirportCode(result.Code)
+					if err != nil {
+						return nil, fmt.Errorf("SearchResult.Airport: %w", err)
+					}
+					return airport, nil
+				},
+			},
+			"Country": &graphql.Field{
+				Type: countryType,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					result := p.Source.(*search.Result)
+					if result.Kind != search.Country {
+						return nil, nil
+					}
+					country, err := theCountries.GetByCountryCode(result.CountryCode)
+					if err != nil {
+						return nil, fmt.Errorf("SearchResult.Country: %w", err)
+					}
+					return country, nil
+				},
+			},
+			"Region": &graphql.Field{
+				Type: regionType,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					result := p.Source.(*search.Result)
+					if result.Kind != search.Region {
+						return nil, nil
+					}
+					country, err := theCountries.GetByCountryCode(result.CountryCode)
+					if err != nil {
+						return nil, fmt.Errorf("SearchResult.Region: %w", err)
+					}
+					for _, region := range country.Regions {
+						if region.RegionCode == result.Code {
+							return asRegionView(country, region), nil
+						}
+					}
+					return nil, failures.New(failures.NotFound, "SearchResult.Region: Not Found")
+				},
+			},
+		},
+	})
+
+// searchQuery finds airports, countries and regions by name, best first
+var searchQuery = &graphql.Field{
+	Type: graphql.NewList(searchResultType),
+	Args: graphql.FieldConfigArgument{
+		"Query": &graphql.ArgumentConfig{
+			Type: graphql.NewNonNull(graphql.String),
+		},
+		"Kinds": &graphql.ArgumentConfig{
+			Type: graphql.NewList(graphql.String),
+		},
+		"Limit": &graphql.ArgumentConfig{
+			Type:         graphql.Int,
+			DefaultValue: 10,
+		},
+	},
+	Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+		query := p.Args["Query"].(string)
+
+		var kinds []string
+		if list, ok := p.Args["Kinds"].([]interface{}); ok {
+			for _, kind := range list {
+				kinds = append(kinds, kind.(string))
+			}
+		}
+
+		results, err := theSearch.Search(query, kinds, p.Args["Limit"].(int))
+		if err != nil {
+			return nil, fmt.Errorf("Search(%s): %w", query, err)
+		}
+		return results, nil
+	}}
@@ -0,0 +1,206 @@
+package graphql
+
+import (
+	"fmt"
+
+	"github.com/graphql-go/graphql"
+
+	"../airports"
+	"../weather"
+)
+
+// windType is the GraphQL representation of the surface wind
+var windType = graphql.NewObject(
+	graphql.ObjectConfig{
+		Name: "Wind",
+		Fields: graphql.Fields{
+			"Direction": &graphql.Field{
+				Type: graphql.Int,
+			},
+			"Variable": &graphql.Field{
+				Type: graphql.Boolean,
+			},
+			"VariableFrom": &graphql.Field{
+				Type: graphql.Int,
+			},
+			"VariableTo": &graphql.Field{
+				Type: graphql.Int,
+			},
+			"Speed": &graphql.Field{
+				Type: graphql.Int,
+			},
+			"Gust": &graphql.Field{
+				Type: graphql.Int,
+			},
+		},
+	})
+
+// cloudType is the GraphQL representation of a cloud layer
+var cloudType = graphql.NewObject(
+	graphql.ObjectConfig{
+		Name: "Cloud",
+		Fields: graphql.Fields{
+			"Cover": &graphql.Field{
+				Type: graphql.String,
+			},
+			"Base": &graphql.Field{
+				Type: graphql.Int,
+			},
+			"Type": &graphql.Field{
+				Type: graphql.String,
+			},
+		},
+	})
+
+// conditionsType is the GraphQL representation of the weather shared by METAR and TAF
+var conditionsType = graphql.NewObject(
+	graphql.ObjectConfig{
+		Name: "Conditions",
+		Fields: graphql.Fields{
+			"Wind": &graphql.Field{
+				Type: windType,
+			},
+			"Visibility": &graphql.Field{
+				Type: graphql.Int,
+			},
+			"CAVOK": &graphql.Field{
+				Type: graphql.Boolean,
+			},
+			"Weather": &graphql.Field{
+				Type: graphql.NewList(graphql.String),
+			},
+			"Clouds": &graphql.Field{
+				Type: graphql.NewList(cloudType),
+			},
+			"Ceiling": &graphql.Field{
+				Type: graphql.Int,
+			},
+			"FlightCategory": &graphql.Field{
+				Type: graphql.String,
+			},
+		},
+	})
+
+// metarType is the GraphQL representation of a METAR observation
+var metarType = graphql.NewObject(
+	graphql.ObjectConfig{
+		Name: "Metar",
+		Fields: graphql.Fields{
+			"ICAOCode": &graphql.Field{
+				Type: graphql.String,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					metar := p.Source.(*weather.Metar)
+					return metar.AirportCode, nil
+				},
+			},
+			"Observed": &graphql.Field{
+				Type: graphql.DateTime,
+			},
+			"Raw": &graphql.Field{
+				Type: graphql.String,
+			},
+			"Auto": &graphql.Field{
+				Type: graphql.Boolean,
+			},
+			"Conditions": &graphql.Field{
+				Type: conditionsType,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					metar := p.Source.(*weather.Metar)
+					return &metar.Conditions, nil
+				},
+			},
+			"Temperature": &graphql.Field{
+				Type: graphql.Int,
+			},
+			"Dewpoint": &graphql.Field{
+				Type: graphql.Int,
+			},
+			"QNH": &graphql.Field{
+				Type: graphql.Int,
+			},
+		},
+	})
+
+// tafPeriodType is the GraphQL representation of a single period in a TAF
+var tafPeriodType = graphql.NewObject(
+	graphql.ObjectConfig{
+		Name: "TafPeriod",
+		Fields: graphql.Fields{
+			"Change": &graphql.Field{
+				Type: graphql.String,
+			},
+			"From": &graphql.Field{
+				Type: graphql.DateTime,
+			},
+			"Until": &graphql.Field{
+				Type: graphql.DateTime,
+			},
+			"Conditions": &graphql.Field{
+				Type: conditionsType,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					period := p.Source.(*weather.TafPeriod)
+					return &period.Conditions, nil
+				},
+			},
+		},
+	})
+
+// tafType is the GraphQL representation of a TAF forecast
+var tafType = graphql.NewObject(
+	graphql.ObjectConfig{
+		Name: "Taf",
+		Fields: graphql.Fields{
+			"ICAOCode": &graphql.Field{
+				Type: graphql.String,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					taf := p.Source.(*weather.Taf)
+					return taf.AirportCode, nil
+				},
+			},
+			"Issued": &graphql.Field{
+				Type: graphql.DateTime,
+			},
+			"ValidFrom": &graphql.Field{
+				Type: graphql.DateTime,
+			},
+			"ValidUntil": &graphql.Field{
+				Type: graphql.DateTime,
+			},
+			"Raw": &graphql.Field{
+				Type: graphql.String,
+			},
+			"Periods": &graphql.Field{
+				Type: graphql.NewList(tafPeriodType),
+			},
+		},
+	})
+
+func addWeatherToAirport() {
+	airportType.AddFieldConfig("Metar", &graphql.Field{
+		Type: metarType,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			airport := p.Source.(*airports.Airport)
+
+			report, err := theWeather.GetLatest(airport.AirportCode, weather.METAR)
+			if err != nil {
+				return nil, fmt.Errorf("Airport.Metar: %w", err)
+			}
+
+			return report.Metar, nil
+		},
+	})
+
+	airportType.AddFieldConfig("Taf", &graphql.Field{
+		Type: tafType,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			airport := p.Source.(*airports.Airport)
+
+			report, err := theWeather.GetLatest(airport.AirportCode, weather.TAF)
+			if err != nil {
+				return nil, fmt.Errorf("Airport.Taf: %w", err)
+			}
+
+			return report.Taf, nil
+		},
+	})
+}
@@ -4,16 +4,27 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"log"
 	"net/http"
 
 	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
 
 	"../airports"
 	"../countries"
+	"../failures"
+	"../magnetic"
+	"../search"
+	"../weather"
 )
 
 var theCountries *countries.Countries
 var theAirports *airports.Airports
+var theWeather *weather.Reports
+var theMagnetic *magnetic.Model
+var theBoundaries *countries.Boundaries
+var theSearch *search.Service
+var theMaxResults int64
 
 // The definition of the queries ------------------------------------------------------------------
 
@@ -21,27 +32,31 @@ var queryType = graphql.NewObject(
 	graphql.ObjectConfig{
 		Name: "Query",
 		Fields: graphql.Fields{
-			"country":     countryQuery,
-			"countries":   countriesQuery,
-			"region":      regionQuery,
-			"regions":     regionsQuery,
-			"airport":     airportQuery,
-			"airports":    airportsQuery,
-			"runway":      runwayQuery,
-			"runways":     runwaysQuery,
-			"frequency":   frequencyQuery,
-			"frequencies": frequenciesQuery,
+			"country":         countryQuery,
+			"countries":       countriesQuery,
+			"region":          regionQuery,
+			"regions":         regionsQuery,
+			"airport":         airportQuery,
+			"airports":        airportsQuery,
+			"airportsByCodes": airportsByCodesQuery,
+			"runway":          runwayQuery,
+			"runways":         runwaysQuery,
+			"frequency":       frequencyQuery,
+			"frequencies":     frequenciesQuery,
+			"locate":          locateQuery,
+			"search":          searchQuery,
 		},
 	})
 
-var schema, _ = graphql.NewSchema(
-	graphql.SchemaConfig{
-		Query: queryType,
-	})
+// schema is built by Init, once the fields that refer back are added to the types, so that every
+// type is in the schema
+var schema graphql.Schema
 
 // The interface of this component ----------------------------------------------------------------
 
-// Handler resolves the calls to the graphql end-point
+// Handler resolves the calls to the graphql end-point, a query is posted as JSON or passed in the
+// URL of a GET. Errors carry the kind of failure as extensions.code, a query that fails as a whole
+// is a bad request while the errors of fields come with the data that could be resolved.
 func Handler(w http.ResponseWriter, r *http.Request) {
 	var graphqlRequest struct {
 		Query         string                 `json:"query"`
@@ -49,23 +64,37 @@ func Handler(w http.ResponseWriter, r *http.Request) {
 		Variables     map[string]interface{} `json:"variables"`
 	}
 
-	// Verify content type
-	contentType := r.Header.Get("Content-type")
-	if contentType != "application/json" {
-		errors := fmt.Sprintf("failed to execute graphql operation, use application/json")
-		http.Error(w, errors, http.StatusBadRequest)
-		return
-	}
-
-	// Parse the request
-	buffer, err := ioutil.ReadAll(r.Body)
-	if err == nil {
-		err = json.Unmarshal(buffer, &graphqlRequest)
-	}
-	if err != nil {
-		errors := fmt.Sprintf("failed to execute graphql operation, errors: %v", err)
-		http.Error(w, errors, http.StatusBadRequest)
-		return
+	if r.Method == http.MethodGet {
+		// A query in the URL, so that it can be cached like the REST resources
+		graphqlRequest.Query = r.FormValue("query")
+		graphqlRequest.OperationName = r.FormValue("operationName")
+		if len(r.FormValue("variables")) != 0 {
+			err := json.Unmarshal([]byte(r.FormValue("variables")), &graphqlRequest.Variables)
+			if err != nil {
+				writeErrors(w, http.StatusBadRequest, gqlerrors.FormatErrors(
+					failures.New(failures.InvalidArgument, "failed to execute graphql operation, variables: %v", err)))
+				return
+			}
+		}
+	} else {
+		// Verify content type
+		contentType := r.Header.Get("Content-type")
+		if contentType != "application/json" {
+			writeErrors(w, http.StatusBadRequest, gqlerrors.FormatErrors(
+				failures.New(failures.InvalidArgument, "failed to execute graphql operation, use application/json")))
+			return
+		}
+
+		// Parse the request
+		buffer, err := ioutil.ReadAll(r.Body)
+		if err == nil {
+			err = json.Unmarshal(buffer, &graphqlRequest)
+		}
+		if err != nil {
+			writeErrors(w, http.StatusBadRequest, gqlerrors.FormatErrors(
+				failures.New(failures.InvalidArgument, "failed to execute graphql operation, errors: %v", err)))
+			return
+		}
 	}
 
 	// Run the query
@@ -73,12 +102,18 @@ func Handler(w http.ResponseWriter, r *http.Request) {
 		Schema:         schema,
 		RequestString:  graphqlRequest.Query,
 		VariableValues: graphqlRequest.Variables,
+		OperationName:  graphqlRequest.OperationName,
 	})
-	if len(output.Errors) > 0 {
-		errors := fmt.Sprintf("failed to execute graphql operation, errors: %+v", output.Errors)
-		http.Error(w, errors, http.StatusBadRequest)
+	if output.Data == nil && len(output.Errors) > 0 {
+		writeErrors(w, http.StatusBadRequest, output.Errors)
 		return
 	}
+	if !addErrorCodes(output.Errors) {
+		// The data is incomplete for now, it is not to be cached
+		w.Header().Del("ETag")
+		w.Header().Del("Last-Modified")
+		w.Header().Set("Cache-Control", "no-store")
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
@@ -86,12 +121,63 @@ func Handler(w http.ResponseWriter, r *http.Request) {
 	result.Encode(output)
 }
 
+// addErrorCodes adds the kind of failure of each error as its code. Errors in the query itself
+// have no cause and are invalid arguments. It tells whether the errors are lasting, rather than
+// internal or of an unavailable database.
+func addErrorCodes(errors []gqlerrors.FormattedError) bool {
+	lasting := true
+	for i := range errors {
+		kind := failures.InvalidArgument
+		switch original := errors[i].OriginalError().(type) {
+		case *gqlerrors.Error:
+			if original.OriginalError != nil {
+				kind = failures.KindOf(original.OriginalError)
+			}
+		case nil:
+		default:
+			kind = failures.KindOf(original)
+		}
+		// The errors of the server are logged, clients get a generic message
+		if kind == failures.Internal || kind == failures.Unavailable {
+			log.Printf("GraphQL: %v", errors[i].Message)
+			errors[i].Message = failures.Detail(failures.New(kind, "%s", errors[i].Message))
+		}
+		lasting = lasting && kind != failures.Internal && kind != failures.Unavailable
+
+		if errors[i].Extensions == nil {
+			errors[i].Extensions = map[string]interface{}{}
+		}
+		errors[i].Extensions["code"] = string(kind)
+	}
+	return lasting
+}
+
+// writeErrors replies with errors only, without data as the query could not be executed
+func writeErrors(w http.ResponseWriter, status int, errors []gqlerrors.FormattedError) {
+	var output struct {
+		Errors []gqlerrors.FormattedError `json:"errors"`
+	}
+	output.Errors = errors
+
+	addErrorCodes(errors)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	result := json.NewEncoder(w)
+	result.Encode(output)
+}
+
 // Init sets up the graphql module
-func Init(countries *countries.Countries, airports *airports.Airports) error {
+func Init(countries *countries.Countries, airports *airports.Airports, weather *weather.Reports,
+	magnetic *magnetic.Model, searchService *search.Service, maxResults int64) error {
 
 	// Register link to the database
 	theCountries = countries
 	theAirports = airports
+	theWeather = weather
+	theMagnetic = magnetic
+	theBoundaries = countries.NewBoundaries()
+	theSearch = searchService
+	theMaxResults = maxResults
 
 	// Add referencials seperately to prevent circular references
 	addCountryToRegion()
@@ -103,6 +189,21 @@ func Init(countries *countries.Countries, airports *airports.Airports) error {
 	addRunwayToAirport()
 	addAirportToFrequency()
 	addFrequencyToAirport()
+	addRunwayWindToAirport()
+	addWeatherToAirport()
+	addDaylightToAirport()
+	addMagneticFieldToAirport()
+	addGeometryToCountry()
+	addGeometryToRegion()
+
+	var err error
+	schema, err = graphql.NewSchema(
+		graphql.SchemaConfig{
+			Query: queryType,
+		})
+	if err != nil {
+		return fmt.Errorf("GraphQL schema: %v", err)
+	}
 
 	return nil
 }
@@ -0,0 +1,316 @@
+package magnetic
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Magnetic implements the World Magnetic Model (WMM) to calculate the magnetic variation
+// (declination) for any position and date. The coefficients are read from the .COF files as
+// published by NOAA, so a new model release only needs a new file.
+
+const maxDegree = 12
+
+// WGS-84 ellipsoid and the geomagnetic reference radius, in km
+const (
+	semiMajorAxis   = 6378.137
+	semiMinorAxis   = 6356.7523142
+	referenceRadius = 6371.2
+)
+
+// Model holds the Schmidt semi-normalized Gauss coefficients of a model release, converted
+// once into the unnormalized form the calculation works with.
+type Model struct {
+	Name     string
+	Epoch    float64
+	Released string
+	c        [maxDegree + 1][maxDegree + 1]float64 // main field, g(n,m) at [m][n], h(n,m) at [n][m-1]
+	cd       [maxDegree + 1][maxDegree + 1]float64 // secular variation, same layout
+	k        [maxDegree + 1][maxDegree + 1]float64 // recursion constants
+}
+
+// Field is the magnetic field at a position. Components are in nanotesla, angles in degrees;
+// the declination is positive when magnetic north lies east of true north.
+type Field struct {
+	Model        string  `json:"model"`
+	North        float64 `json:"north"`
+	East         float64 `json:"east"`
+	Down         float64 `json:"down"`
+	Horizontal   float64 `json:"horizontal-intensity"`
+	Total        float64 `json:"total-intensity"`
+	Declination  float64 `json:"declination"`
+	Inclination  float64 `json:"inclination"`
+	DecimalYear  float64 `json:"decimal-year"`
+	OutsideEpoch bool    `json:"outside-epoch,omitempty"`
+}
+
+// LoadModel reads a model from a .COF file
+func LoadModel(fileName string) (*Model, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	model, err := ReadModel(file)
+	if err != nil {
+		return nil, fmt.Errorf("LoadModel(%s): %v", fileName, err)
+	}
+
+	return model, nil
+}
+
+// ReadModel reads a model in the .COF format: a header with epoch, name and release date,
+// followed by lines of n, m, g, h, g-dot and h-dot, closed by a line of nines.
+func ReadModel(reader io.Reader) (*Model, error) {
+	var model Model
+
+	scanner := bufio.NewScanner(reader)
+
+	// Header
+	if !scanner.Scan() {
+		return nil, fmt.Errorf("Missing header")
+	}
+	header := strings.Fields(scanner.Text())
+	if len(header) < 2 {
+		return nil, fmt.Errorf("Invalid header")
+	}
+	epoch, err := strconv.ParseFloat(header[0], 64)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid epoch(%s)", header[0])
+	}
+	model.Epoch = epoch
+	model.Name = header[1]
+	if len(header) > 2 {
+		model.Released = header[2]
+	}
+
+	// Coefficients
+	lineNumber := 1
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.Fields(scanner.Text())
+		if len(line) == 0 {
+			continue
+		}
+		if strings.HasPrefix(line[0], "9999") {
+			break
+		}
+		if len(line) != 6 {
+			return nil, fmt.Errorf("Line[%d]: Expected 6 values", lineNumber)
+		}
+
+		var values [6]float64
+		for i := range line {
+			values[i], err = strconv.ParseFloat(line[i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("Line[%d](%s): Invalid number", lineNumber, line[i])
+			}
+		}
+
+		n := int(values[0])
+		m := int(values[1])
+		if n < 1 || n > maxDegree || m < 0 || m > n {
+			return nil, fmt.Errorf("Line[%d]: Invalid degree/order", lineNumber)
+		}
+
+		model.c[m][n] = values[2]
+		model.cd[m][n] = values[4]
+		if m != 0 {
+			model.c[n][m-1] = values[3]
+			model.cd[n][m-1] = values[5]
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	model.normalize()
+
+	return &model, nil
+}
+
+// normalize converts the Schmidt semi-normalized coefficients and sets up the constants for
+// the Legendre recursion
+func (model *Model) normalize() {
+	var snorm [maxDegree + 1][maxDegree + 1]float64
+
+	snorm[0][0] = 1.0
+	for n := 1; n <= maxDegree; n++ {
+		snorm[0][n] = snorm[0][n-1] * float64(2*n-1) / float64(n)
+		j := 2.0
+		for m := 0; m <= n; m++ {
+			model.k[m][n] = float64((n-1)*(n-1)-m*m) / float64((2*n-1)*(2*n-3))
+			if m > 0 {
+				flnmj := float64(n-m+1) * j / float64(n+m)
+				snorm[m][n] = snorm[m-1][n] * math.Sqrt(flnmj)
+				j = 1.0
+				model.c[n][m-1] *= snorm[m][n]
+				model.cd[n][m-1] *= snorm[m][n]
+			}
+			model.c[m][n] *= snorm[m][n]
+			model.cd[m][n] *= snorm[m][n]
+		}
+	}
+	model.k[1][1] = 0.0
+}
+
+// DecimalYear expresses a date as a year with fraction, the way the model counts time
+func DecimalYear(date time.Time) float64 {
+	date = date.UTC()
+	start := time.Date(date.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(1, 0, 0)
+
+	return float64(date.Year()) + date.Sub(start).Seconds()/end.Sub(start).Seconds()
+}
+
+// Field calculates the magnetic field at a geodetic position; latitude and longitude in degrees,
+// altitude in km above the ellipsoid. A model is valid for five years after its epoch, beyond
+// that the secular variation is extrapolated and the result flagged.
+func (model *Model) Field(latitude float64, longitude float64, altitude float64, date time.Time) *Field {
+	var p, dp [maxDegree + 1][maxDegree + 1]float64
+	var tc [maxDegree + 1][maxDegree + 1]float64
+	var sp, cp, pp [maxDegree + 1]float64
+
+	year := DecimalYear(date)
+	dt := year - model.Epoch
+
+	rlat := latitude * math.Pi / 180.0
+	rlon := longitude * math.Pi / 180.0
+	srlat, crlat := math.Sin(rlat), math.Cos(rlat)
+	srlon, crlon := math.Sin(rlon), math.Cos(rlon)
+	srlat2 := srlat * srlat
+	crlat2 := crlat * crlat
+
+	// Geodetic to spherical coordinates
+	a2 := semiMajorAxis * semiMajorAxis
+	b2 := semiMinorAxis * semiMinorAxis
+	c2 := a2 - b2
+	a4 := a2 * a2
+	b4 := b2 * b2
+	c4 := a4 - b4
+
+	q := math.Sqrt(a2 - c2*srlat2)
+	q1 := altitude * q
+	q2 := ((q1 + a2) / (q1 + b2)) * ((q1 + a2) / (q1 + b2))
+	ct := srlat / math.Sqrt(q2*crlat2+srlat2)
+	st := math.Sqrt(1.0 - ct*ct)
+	r2 := altitude*altitude + 2.0*q1 + (a4-c4*srlat2)/(q*q)
+	r := math.Sqrt(r2)
+	d := math.Sqrt(a2*crlat2 + b2*srlat2)
+	ca := (altitude + d) / r
+	sa := c2 * crlat * srlat / (r * d)
+
+	sp[0], cp[0] = 0.0, 1.0
+	sp[1], cp[1] = srlon, crlon
+	for m := 2; m <= maxDegree; m++ {
+		sp[m] = sp[1]*cp[m-1] + cp[1]*sp[m-1]
+		cp[m] = cp[1]*cp[m-1] - sp[1]*sp[m-1]
+	}
+
+	p[0][0] = 1.0
+	pp[0] = 1.0
+
+	aor := referenceRadius / r
+	ar := aor * aor
+	br, bt, bp, bpp := 0.0, 0.0, 0.0, 0.0
+
+	for n := 1; n <= maxDegree; n++ {
+		ar *= aor
+		for m := 0; m <= n; m++ {
+
+			// Associated Legendre polynomials and their derivatives
+			switch {
+			case n == m:
+				p[m][n] = st * p[m-1][n-1]
+				dp[m][n] = st*dp[m-1][n-1] + ct*p[m-1][n-1]
+			case n == 1 && m == 0:
+				p[m][n] = ct * p[m][n-1]
+				dp[m][n] = ct*dp[m][n-1] - st*p[m][n-1]
+			default:
+				var p2, dp2 float64
+				if m <= n-2 {
+					p2, dp2 = p[m][n-2], dp[m][n-2]
+				}
+				p[m][n] = ct*p[m][n-1] - model.k[m][n]*p2
+				dp[m][n] = ct*dp[m][n-1] - st*p[m][n-1] - model.k[m][n]*dp2
+			}
+
+			// Coefficients at the requested date
+			tc[m][n] = model.c[m][n] + dt*model.cd[m][n]
+			if m != 0 {
+				tc[n][m-1] = model.c[n][m-1] + dt*model.cd[n][m-1]
+			}
+
+			// Accumulate the spherical components
+			par := ar * p[m][n]
+			var temp1, temp2 float64
+			if m == 0 {
+				temp1 = tc[m][n] * cp[m]
+				temp2 = tc[m][n] * sp[m]
+			} else {
+				temp1 = tc[m][n]*cp[m] + tc[n][m-1]*sp[m]
+				temp2 = tc[m][n]*sp[m] - tc[n][m-1]*cp[m]
+			}
+			bt -= ar * temp1 * dp[m][n]
+			bp += float64(m) * temp2 * par
+			br += float64(n+1) * temp1 * par
+
+			// At the geographic poles the east component needs its own recursion
+			if st == 0.0 && m == 1 {
+				if n == 1 {
+					pp[n] = pp[n-1]
+				} else {
+					pp[n] = ct*pp[n-1] - model.k[m][n]*pp[n-2]
+				}
+				bpp += float64(m) * temp2 * ar * pp[n]
+			}
+		}
+	}
+
+	if st == 0.0 {
+		bp = bpp
+	} else {
+		bp /= st
+	}
+
+	// Back to geodetic components
+	north := -bt*ca - br*sa
+	east := bp
+	down := bt*sa - br*ca
+	horizontal := math.Sqrt(north*north + east*east)
+
+	return &Field{
+		Model:        model.Name,
+		North:        north,
+		East:         east,
+		Down:         down,
+		Horizontal:   horizontal,
+		Total:        math.Sqrt(horizontal*horizontal + down*down),
+		Declination:  math.Atan2(east, north) * 180.0 / math.Pi,
+		Inclination:  math.Atan2(down, horizontal) * 180.0 / math.Pi,
+		DecimalYear:  year,
+		OutsideEpoch: dt < 0 || dt > 5}
+}
+
+// Declination calculates the magnetic variation in degrees for a position with its elevation
+// in feet, as used throughout the geography database
+func (model *Model) Declination(latitude float64, longitude float64, elevation float64, date time.Time) float64 {
+	return model.Field(latitude, longitude, elevation*0.3048/1000.0, date).Declination
+}
+
+// MagneticHeading converts a true heading into a magnetic heading (1..360) given the declination
+func MagneticHeading(heading int, declination float64) int {
+	result := int(math.Round(float64(heading)-declination)) % 360
+	if result <= 0 {
+		result += 360
+	}
+	return result
+}
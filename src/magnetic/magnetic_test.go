@@ -0,0 +1,133 @@
+package magnetic
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadModel(t *testing.T) {
+	var tests = []struct {
+		value   string
+		correct bool
+	}{
+		{"", false}, // no header
+		{"    2020.0            WMM-2020        12/10/2019\n" +
+			"  1  0  -29404.5       0.0        6.7        0.0\n" +
+			"999999999999999999999999999999999999999999999999\n", true}, // minimal model
+		{"WMM-2020\n", false}, // no epoch
+		{"    2020.0            WMM-2020        12/10/2019\n" +
+			"  1  0  -29404.5       0.0        6.7\n", false}, // missing value
+		{"    2020.0            WMM-2020        12/10/2019\n" +
+			" 13  0      -2.0       0.0        0.0        0.0\n", false}, // degree too high
+		{"    2020.0            WMM-2020        12/10/2019\n" +
+			"  1  2      -2.0       0.0        0.0        0.0\n", false}, // order above degree
+	}
+
+	for _, test := range tests {
+		_, err := ReadModel(strings.NewReader(test.value))
+		if (test.correct && err != nil) || (!test.correct && err == nil) {
+			t.Errorf("ReadModel(%q) expected %t, got %t", test.value, test.correct, (err == nil))
+		}
+	}
+}
+
+func TestField(t *testing.T) {
+	model, err := LoadModel("../../data/WMM2020.COF")
+	if err != nil {
+		t.Fatalf("Internal error: [%v]", err)
+	}
+
+	if model.Name != "WMM-2020" || model.Epoch != 2020.0 {
+		t.Errorf("LoadModel expected WMM-2020 at 2020.0, got %s at %.1f", model.Name, model.Epoch)
+	}
+
+	// The first test point published with WMM-2020, followed by a few well known airports
+	var tests = []struct {
+		latitude    float64
+		longitude   float64
+		date        time.Time
+		declination float64
+		inclination float64
+	}{
+		{80, 0, time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC), -1.28, 83.14},
+		{52.31, 4.76, time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC), 1.60, 67.34},      // EHAM
+		{40.64, -73.78, time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC), -12.84, 66.12},  // KJFK
+		{-33.95, 151.18, time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC), 12.66, -64.41}, // YSSY
+	}
+
+	for _, test := range tests {
+		field := model.Field(test.latitude, test.longitude, 0, test.date)
+		if math.Abs(field.Declination-test.declination) > 0.05 || math.Abs(field.Inclination-test.inclination) > 0.05 {
+			t.Errorf("Field(%.2f, %.2f) expected %.2f/%.2f, got %.2f/%.2f", test.latitude, test.longitude,
+				test.declination, test.inclination, field.Declination, field.Inclination)
+		}
+	}
+
+	if model.Field(80, 0, 0, time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)).OutsideEpoch != true {
+		t.Errorf("Field expected 2026 to be outside the WMM-2020 epoch")
+	}
+}
+
+func TestFieldWMM2025(t *testing.T) {
+	model, err := LoadModel("../../data/WMM2025.COF")
+	if err != nil {
+		t.Fatalf("Internal error: [%v]", err)
+	}
+
+	if model.Name != "WMM-2025" || model.Epoch != 2025.0 {
+		t.Errorf("LoadModel expected WMM-2025 at 2025.0, got %s at %.1f", model.Name, model.Epoch)
+	}
+
+	// The airports of the WMM-2020 test, five years on
+	var tests = []struct {
+		latitude    float64
+		longitude   float64
+		date        time.Time
+		declination float64
+		inclination float64
+	}{
+		{52.31, 4.76, time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC), 2.43, 67.43},      // EHAM
+		{40.64, -73.78, time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC), -12.62, 65.60},  // KJFK
+		{-33.95, 151.18, time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC), 12.81, -64.47}, // YSSY
+	}
+
+	for _, test := range tests {
+		field := model.Field(test.latitude, test.longitude, 0, test.date)
+		if math.Abs(field.Declination-test.declination) > 0.05 || math.Abs(field.Inclination-test.inclination) > 0.05 {
+			t.Errorf("Field(%.2f, %.2f) expected %.2f/%.2f, got %.2f/%.2f", test.latitude, test.longitude,
+				test.declination, test.inclination, field.Declination, field.Inclination)
+		}
+		if field.OutsideEpoch {
+			t.Errorf("Field(%.2f, %.2f) expected 2025 to be within the WMM-2025 epoch", test.latitude, test.longitude)
+		}
+	}
+
+	if model.Field(80, 0, 0, time.Date(2031, time.January, 1, 0, 0, 0, 0, time.UTC)).OutsideEpoch != true {
+		t.Errorf("Field expected 2031 to be outside the WMM-2025 epoch")
+	}
+}
+
+func TestMagneticHeading(t *testing.T) {
+	var tests = []struct {
+		heading     int
+		declination float64
+		result      int
+	}{
+		{90, 0.0, 90},    // no variation
+		{90, 10.0, 80},   // easterly variation is subtracted
+		{90, -10.0, 100}, // westerly variation is added
+		{5, 10.0, 355},   // wraps below north
+		{355, -10.0, 5},  // wraps beyond north
+		{360, 0.0, 360},  // north stays 360
+		{2, 1.6, 360},    // rounds to north
+	}
+
+	for _, test := range tests {
+		result := MagneticHeading(test.heading, test.declination)
+		if result != test.result {
+			t.Errorf("MagneticHeading(%d, %.1f) expected %d, got %d", test.heading, test.declination, test.result, result)
+		}
+	}
+}
@@ -0,0 +1,607 @@
+package paging
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"../failures"
+)
+
+// Paging splits a list into pages on a unique key, like the code of an airport. A page starts
+// after or ends before the key of a cursor (keyset pagination), so pages stay stable while the
+// list changes and a deep page costs as much as the first one. A list sorted on other fields is
+// paged on those fields followed by the key, its cursors hold the values of all of them.
+
+// DefaultSize is the number of items of a page when no size is asked for
+const DefaultSize = 100
+
+// nestedDocuments is the number of documents read at a time for a page of the items nested in them
+var nestedDocuments = DefaultSize
+
+// The prefixes mark the version of the cursor encoding, of a key or of the values of a sort
+const (
+	cursorPrefix       = "k1:"
+	sortedCursorPrefix = "s1:"
+)
+
+// Request asks for a page: the items after the After key, or the items before the Before key
+// when Backward. Without a key it is the first page, or the last one when Backward.
+type Request struct {
+	After    string
+	Before   string
+	Size     int
+	Backward bool
+	Total    bool        // count all items of the list
+	Sort     []SortField // the order of the list before the key, see SetSort
+	Fields   []string    // the fields of the items to retrieve, all when empty
+
+	after      []interface{} // the values of the sort and the key of the cursors
+	before     []interface{}
+	cursorSort string // the sort the cursors were made in
+	inclusive  bool   // the page starts at the cursor instead of beyond it
+}
+
+// SortField is a field a list is sorted on
+type SortField struct {
+	Name       string
+	Descending bool
+}
+
+// Page tells where a page is in the list
+type Page struct {
+	StartCursor string `json:"start-cursor,omitempty"`
+	EndCursor   string `json:"end-cursor,omitempty"`
+	HasNext     bool   `json:"has-next"`
+	HasPrevious bool   `json:"has-previous"`
+	Total       *int64 `json:"total,omitempty"`
+}
+
+// EncodeCursor makes the opaque cursor of a key
+func EncodeCursor(key string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(cursorPrefix + key))
+}
+
+// DecodeCursor retrieves the key of a cursor, the empty cursor is the empty key
+func DecodeCursor(cursor string) (string, error) {
+	key, _, _, err := decodeCursor(cursor)
+	return key, err
+}
+
+// decodeCursor retrieves the key of a cursor, with the sort it was made in and the values of the
+// sort followed by the key
+func decodeCursor(cursor string) (string, []interface{}, string, error) {
+	if len(cursor) == 0 {
+		return "", nil, "", nil
+	}
+
+	invalid := failures.New(failures.InvalidArgument, "Invalid Cursor(%s)", cursor)
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", nil, "", invalid
+	}
+
+	switch {
+	case strings.HasPrefix(string(decoded), cursorPrefix):
+		key := strings.TrimPrefix(string(decoded), cursorPrefix)
+		return key, []interface{}{key}, "", nil
+	case strings.HasPrefix(string(decoded), sortedCursorPrefix):
+		var values []interface{}
+		err = json.Unmarshal(decoded[len(sortedCursorPrefix):], &values)
+		if err != nil || len(values) < 2 {
+			return "", nil, "", invalid
+		}
+		sortName, ok := values[0].(string)
+		key, isKey := values[len(values)-1].(string)
+		if !ok || !isKey {
+			return "", nil, "", invalid
+		}
+		return key, values[1:], sortName, nil
+	}
+	return "", nil, "", invalid
+}
+
+// encodeCursor makes the cursor of the values of a sort followed by the key, without a sort it
+// is the cursor of the key
+func encodeCursor(sort []SortField, values []interface{}) string {
+	if len(sort) == 0 {
+		return EncodeCursor(fmt.Sprint(values[len(values)-1]))
+	}
+	encoded, _ := json.Marshal(append([]interface{}{SortName(sort)}, values...))
+	return base64.RawURLEncoding.EncodeToString(append([]byte(sortedCursorPrefix), encoded...))
+}
+
+// SortName writes a sort the way ParseSort reads it, like -elevation
+func SortName(sort []SortField) string {
+	var names []string
+	for _, field := range sort {
+		if field.Descending {
+			names = append(names, "-"+field.Name)
+		} else {
+			names = append(names, field.Name)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// ParseSort reads a sort on one of the allowed fields, with a leading minus it is descending.
+// A sort is one field followed by the key, the fields of a collection are indexed that way; sorting
+// on more fields would need an index for every combination of them.
+func ParseSort(s string, allowed []string) ([]SortField, error) {
+	var result []SortField
+	if len(strings.TrimSpace(s)) == 0 {
+		return result, nil
+	}
+	if strings.Contains(s, ",") {
+		return nil, failures.New(failures.InvalidArgument, "Invalid Sort(%s), sort on one field", s)
+	}
+
+	field := SortField{Name: strings.TrimSpace(s)}
+	if strings.HasPrefix(field.Name, "-") {
+		field.Name, field.Descending = field.Name[1:], true
+	} else {
+		field.Name = strings.TrimPrefix(field.Name, "+")
+	}
+	if !contains(allowed, field.Name) {
+		return nil, failures.New(failures.InvalidArgument, "Invalid Sort(%s), use %s", s,
+			strings.Join(allowed, ", "))
+	}
+	return append(result, field), nil
+}
+
+// ParseFields reads a comma separated list of the allowed fields
+func ParseFields(s string, allowed []string) ([]string, error) {
+	var result []string
+	if len(strings.TrimSpace(s)) == 0 {
+		return result, nil
+	}
+
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if !contains(allowed, name) {
+			return nil, failures.New(failures.InvalidArgument, "Invalid Field(%s), use %s", name,
+				strings.Join(allowed, ", "))
+		}
+		if !contains(result, name) {
+			result = append(result, name)
+		}
+	}
+	return result, nil
+}
+
+func contains(list []string, value string) bool {
+	for _, candidate := range list {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
+
+// NewRequest validates the cursors and size of a request. The page goes backward from the before
+// cursor when there is one. A size of 0 is the default size, more than maxSize is an error.
+func NewRequest(after string, before string, size int, backward bool, maxSize int64) (*Request, error) {
+	var request = Request{Size: size, Backward: backward || len(before) != 0}
+	var err error
+
+	if len(after) != 0 && len(before) != 0 {
+		return nil, failures.New(failures.InvalidArgument, "Invalid Cursor: use either after or before")
+	}
+	var afterSort, beforeSort string
+	request.After, request.after, afterSort, err = decodeCursor(after)
+	if err != nil {
+		return nil, err
+	}
+	request.Before, request.before, beforeSort, err = decodeCursor(before)
+	if err != nil {
+		return nil, err
+	}
+	request.cursorSort = afterSort + beforeSort
+
+	if request.Size == 0 {
+		request.Size = DefaultSize
+		if int64(request.Size) > maxSize {
+			request.Size = int(maxSize)
+		}
+	}
+	if request.Size < 1 || int64(request.Size) > maxSize {
+		return nil, failures.New(failures.InvalidArgument, "Invalid Size(%d), use 1 .. %d", size, maxSize)
+	}
+
+	return &request, nil
+}
+
+// SetSort sorts the list before its key, a cursor has to be made in the same sort
+func (request *Request) SetSort(sort []SortField) error {
+	if !request.First() && request.cursorSort != SortName(sort) {
+		return failures.New(failures.InvalidArgument, "Invalid Cursor: made for another sort")
+	}
+	request.Sort = sort
+	return nil
+}
+
+// First tells whether the request is for the first or the last page, rather than one after or
+// before a cursor
+func (request *Request) First() bool {
+	return len(request.After) == 0 && len(request.Before) == 0
+}
+
+// Slice pages a list that is held in memory, sorted by its unique keys. It returns the range of
+// the page in the list.
+func (request *Request) Slice(keys []string) (int, int, *Page) {
+	start, end := 0, len(keys)
+	if len(request.After) != 0 {
+		start = sort.SearchStrings(keys, request.After)
+		if start < len(keys) && keys[start] == request.After {
+			start++
+		}
+	}
+	if len(request.Before) != 0 {
+		end = sort.SearchStrings(keys, request.Before)
+	}
+	if end < start {
+		end = start
+	}
+
+	if end-start > request.Size {
+		if request.Backward {
+			start = end - request.Size
+		} else {
+			end = start + request.Size
+		}
+	}
+
+	page := Page{HasPrevious: start > 0, HasNext: end < len(keys)}
+	if end > start {
+		page.StartCursor = EncodeCursor(keys[start])
+		page.EndCursor = EncodeCursor(keys[end-1])
+	}
+	if request.Total {
+		total := int64(len(keys))
+		page.Total = &total
+	}
+
+	return start, end, &page
+}
+
+// order is the sort of the list followed by the key, which goes in the direction of the first
+// field so a single field sort is served by an index on the field and the key. The sort ends at
+// the key, as it is unique.
+func (request *Request) order(key string) []SortField {
+	var result []SortField
+	for _, field := range request.Sort {
+		if field.Name == key {
+			return append(result, field)
+		}
+		result = append(result, field)
+	}
+	return append(result, SortField{Name: key, Descending: len(result) != 0 && result[0].Descending})
+}
+
+// beyond matches the documents after the values of a cursor in the order, or before them
+// when not forward. Inclusive it matches the document of the cursor as well.
+func beyond(order []SortField, values []interface{}, forward bool, inclusive bool) bson.D {
+	var terms bson.A
+	for i, field := range order {
+		var term bson.D
+		for j := 0; j < i; j++ {
+			term = append(term, bson.E{Key: order[j].Name, Value: values[j]})
+		}
+		operator := "$gt"
+		if forward == field.Descending {
+			operator = "$lt"
+		}
+		if inclusive && i == len(order)-1 {
+			operator += "e"
+		}
+		term = append(term, bson.E{Key: field.Name, Value: bson.D{{Key: operator, Value: values[i]}}})
+		terms = append(terms, term)
+	}
+
+	if len(terms) == 1 {
+		return terms[0].(bson.D)
+	}
+	return bson.D{{Key: "$or", Value: terms}}
+}
+
+// Find retrieves a page of the documents matching the query in the order of the request, the
+// values of the key field are unique strings. The documents are handed to decode in order.
+func Find(context context.Context, collection *mongo.Collection, query bson.D, key string,
+	request *Request, decode func(bson.Raw) error) (*Page, error) {
+
+	var page Page
+
+	if !request.First() && request.cursorSort != SortName(request.Sort) {
+		return nil, failures.New(failures.InvalidArgument, "Invalid Cursor: made for another sort")
+	}
+
+	order := request.order(key)
+	match := func(filter bson.D) bson.D {
+		return bson.D{{Key: "$and", Value: bson.A{query, filter}}}
+	}
+	exists := func(filter bson.D) (bool, error) {
+		count, err := collection.CountDocuments(context, filter, options.Count().SetLimit(1))
+		return count > 0, err
+	}
+
+	// Going backward the page is read in reverse, from the cursor on
+	filter := query
+	if !request.Backward && len(request.after) != 0 {
+		filter = match(beyond(order, request.after, true, request.inclusive))
+	}
+	if request.Backward && len(request.before) != 0 {
+		filter = match(beyond(order, request.before, false, request.inclusive))
+	}
+	var sorting bson.D
+	for _, field := range order {
+		direction := 1
+		if field.Descending != request.Backward {
+			direction = -1
+		}
+		sorting = append(sorting, bson.E{Key: field.Name, Value: direction})
+	}
+	limit := int64(request.Size)
+
+	findOptions := options.Find().SetSort(sorting).SetLimit(limit + 1).SetAllowDiskUse(true)
+	if len(request.Fields) != 0 {
+		projection := bson.D{}
+		for _, field := range request.Fields {
+			projection = append(projection, bson.E{Key: field, Value: 1})
+		}
+		for _, field := range order {
+			if !contains(request.Fields, field.Name) {
+				projection = append(projection, bson.E{Key: field.Name, Value: 1})
+			}
+		}
+		findOptions.SetProjection(projection)
+	}
+
+	cur, err := collection.Find(context, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(context)
+
+	var documents []bson.Raw
+	more := false
+	for cur.Next(context) {
+		if int64(len(documents)) == limit {
+			more = true
+			break
+		}
+		documents = append(documents, append(bson.Raw{}, cur.Current...))
+	}
+	if cur.Err() != nil {
+		return nil, cur.Err()
+	}
+
+	if request.Backward {
+		page.HasPrevious = more
+		for i, j := 0, len(documents)-1; i < j; i, j = i+1, j-1 {
+			documents[i], documents[j] = documents[j], documents[i]
+		}
+	} else {
+		page.HasNext = more
+	}
+
+	for i, document := range documents {
+		values, err := sortValues(document, order)
+		if err != nil {
+			return nil, err
+		}
+		cursor := encodeCursor(request.Sort, values)
+		if i == 0 {
+			page.StartCursor = cursor
+		}
+		page.EndCursor = cursor
+
+		err = decode(document)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// The other side of the page is outside the filter, so it is looked up
+	if request.Backward && len(request.before) != 0 {
+		page.HasNext, err = exists(match(beyond(order, request.before, true, true)))
+	} else if !request.Backward && len(request.after) != 0 {
+		page.HasPrevious, err = exists(match(beyond(order, request.after, false, true)))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if request.Total {
+		total, err := collection.CountDocuments(context, query)
+		if err != nil {
+			return nil, err
+		}
+		page.Total = &total
+	}
+
+	return &page, nil
+}
+
+// sortValues retrieves the values of the fields of an order from a document, the last one being
+// the key
+func sortValues(document bson.Raw, order []SortField) ([]interface{}, error) {
+	var result []interface{}
+	for i, field := range order {
+		var value interface{}
+		raw, err := document.LookupErr(strings.Split(field.Name, ".")...)
+		if err == nil {
+			err = raw.Unmarshal(&value)
+		}
+		if i == len(order)-1 {
+			if _, ok := value.(string); !ok {
+				return nil, fmt.Errorf("Page: %s is not a string", field.Name)
+			}
+		}
+		result = append(result, value)
+	}
+	return result, nil
+}
+
+// Export reads every document from a value of the key field on, in order of the key, without the
+// limit of a page. The documents are read batchSize at a time, which bounds the memory an export
+// takes, and handed to row one by one, an error of row ends the export.
+func Export(context context.Context, collection *mongo.Collection, key string, from string, batchSize int32,
+	row func(bson.Raw) error) error {
+
+	query := bson.D{}
+	if len(from) != 0 {
+		query = bson.D{{Key: key, Value: bson.D{{Key: "$gte", Value: from}}}}
+	}
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: key, Value: 1}}).
+		SetBatchSize(batchSize)
+
+	cur, err := collection.Find(context, query, findOptions)
+	if err != nil {
+		return failures.Database(err)
+	}
+	defer cur.Close(context)
+
+	for cur.Next(context) {
+		err = row(cur.Current)
+		if err != nil {
+			return err
+		}
+	}
+
+	if cur.Err() != nil {
+		return failures.Database(cur.Err())
+	}
+	return nil
+}
+
+// Nested pages the items nested in the documents of a list, like the regions of the countries,
+// without reading the whole list. The key of an item starts with the key of its document and the
+// separator, like NL-NH. The documents are read a page at a time by read, from the document of
+// the cursor on until the page of items is full. read hands the keys of the items it keeps of a
+// page of documents, Nested returns the keys of the page of items in order.
+func Nested(request *Request, separator string,
+	read func(documents *Request) ([]string, *Page, error)) ([]string, *Page, error) {
+
+	var page Page
+
+	if len(request.Sort) != 0 || len(request.cursorSort) != 0 {
+		return nil, nil, failures.New(failures.InvalidArgument, "Invalid Cursor: a nested list is not sorted")
+	}
+
+	// The document of the cursor is read again, as it can hold items on either side of the cursor
+	documentOf := func(key string) string {
+		if i := strings.Index(key, separator); i >= 0 {
+			return key[:i]
+		}
+		return key
+	}
+	collect := func(key string, backward bool, limit int, keep func(string) bool) ([]string, error) {
+		var result []string
+		documents := &Request{Size: nestedDocuments, Backward: backward, inclusive: true}
+		for {
+			if len(key) != 0 && backward {
+				documents.Before, documents.before = key, []interface{}{key}
+			} else if len(key) != 0 {
+				documents.After, documents.after = key, []interface{}{key}
+			}
+
+			keys, documentPage, err := read(documents)
+			if failures.KindOf(err) == failures.NotFound && documents.First() {
+				return result, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			sort.Strings(keys)
+			for n := range keys {
+				i := n
+				if backward {
+					i = len(keys) - 1 - n
+				}
+				if keep(keys[i]) {
+					result = append(result, keys[i])
+					if limit >= 0 && len(result) > limit {
+						return result, nil
+					}
+				}
+			}
+
+			more, cursor := documentPage.HasNext, documentPage.EndCursor
+			if backward {
+				more, cursor = documentPage.HasPrevious, documentPage.StartCursor
+			}
+			if !more || len(cursor) == 0 {
+				return result, nil
+			}
+			key, err = DecodeCursor(cursor)
+			if err != nil {
+				return nil, err
+			}
+			documents = &Request{Size: nestedDocuments, Backward: backward}
+		}
+	}
+
+	var keys []string
+	var err error
+	if request.Backward {
+		before := request.Before
+		keys, err = collect(documentOf(before), true, request.Size, func(key string) bool {
+			return len(before) == 0 || key < before
+		})
+		if err == nil && len(keys) > request.Size {
+			keys, page.HasPrevious = keys[:request.Size], true
+		}
+		for i, j := 0, len(keys)-1; i < j; i, j = i+1, j-1 {
+			keys[i], keys[j] = keys[j], keys[i]
+		}
+		if err == nil && len(before) != 0 {
+			var next []string
+			next, err = collect(documentOf(before), false, 0, func(key string) bool { return key >= before })
+			page.HasNext = len(next) != 0
+		}
+	} else {
+		after := request.After
+		keys, err = collect(documentOf(after), false, request.Size, func(key string) bool {
+			return len(after) == 0 || key > after
+		})
+		if err == nil && len(keys) > request.Size {
+			keys, page.HasNext = keys[:request.Size], true
+		}
+		if err == nil && len(after) != 0 {
+			var previous []string
+			previous, err = collect(documentOf(after), true, 0, func(key string) bool { return key <= after })
+			page.HasPrevious = len(previous) != 0
+		}
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(keys) == 0 && request.First() {
+		return nil, nil, failures.New(failures.NotFound, "Not found")
+	}
+	if len(keys) != 0 {
+		page.StartCursor = EncodeCursor(keys[0])
+		page.EndCursor = EncodeCursor(keys[len(keys)-1])
+	}
+
+	if request.Total {
+		all, err := collect("", false, -1, func(string) bool { return true })
+		if err != nil {
+			return nil, nil, err
+		}
+		total := int64(len(all))
+		page.Total = &total
+	}
+
+	return keys, &page, nil
+}
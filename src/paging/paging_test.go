@@ -0,0 +1,279 @@
+package paging
+
+import (
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"../failures"
+)
+
+func TestCursor(t *testing.T) {
+	for _, key := range []string{"EHAM", "NL-NH", "EHAM/0001"} {
+		decoded, err := DecodeCursor(EncodeCursor(key))
+		if err != nil || decoded != key {
+			t.Errorf("DecodeCursor(EncodeCursor(%s)) expected %s, got %s (%v)", key, key, decoded, err)
+		}
+	}
+
+	for _, cursor := range []string{"EHAM", "!!", EncodeCursor("EHAM")[3:]} {
+		if _, err := DecodeCursor(cursor); err == nil {
+			t.Errorf("DecodeCursor(%s) expected an error", cursor)
+		}
+	}
+}
+
+func TestNewRequest(t *testing.T) {
+	var tests = []struct {
+		after    string
+		before   string
+		size     int
+		backward bool
+		maxSize  int64
+		result   *Request
+	}{
+		{"", "", 0, false, 1000, &Request{Size: DefaultSize}},
+		{"", "", 0, false, 10, &Request{Size: 10}},
+		{EncodeCursor("EHAM"), "", 5, false, 10, &Request{After: "EHAM", Size: 5}},
+		{"", EncodeCursor("EHAM"), 5, false, 10, &Request{Before: "EHAM", Size: 5, Backward: true}},
+		{"", "", 5, true, 10, &Request{Size: 5, Backward: true}},
+		{"", "", 11, false, 10, nil},
+		{"", "", -1, false, 10, nil},
+		{"EHAM", "", 5, false, 10, nil},
+		{EncodeCursor("EHAM"), EncodeCursor("EHRD"), 5, false, 10, nil},
+	}
+
+	for _, test := range tests {
+		request, err := NewRequest(test.after, test.before, test.size, test.backward, test.maxSize)
+		if test.result == nil {
+			if err == nil {
+				t.Errorf("NewRequest(%s, %s, %d) expected an error", test.after, test.before, test.size)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(Request{After: request.After, Before: request.Before, Size: request.Size,
+			Backward: request.Backward}, *test.result) {
+			t.Errorf("NewRequest(%s, %s, %d) expected %v, got %v (%v)", test.after, test.before, test.size,
+				test.result, request, err)
+		}
+	}
+}
+
+func TestSlice(t *testing.T) {
+	keys := []string{"A", "B", "C", "D", "E"}
+
+	var tests = []struct {
+		request     Request
+		start       int
+		end         int
+		hasPrevious bool
+		hasNext     bool
+	}{
+		{Request{Size: 2}, 0, 2, false, true},
+		{Request{After: "B", Size: 2}, 2, 4, true, true},
+		{Request{After: "D", Size: 2}, 4, 5, true, false},
+		{Request{After: "E", Size: 2}, 5, 5, true, false},
+		{Request{After: "BB", Size: 2}, 2, 4, true, true},
+		{Request{Before: "D", Size: 2, Backward: true}, 1, 3, true, true},
+		{Request{Before: "B", Size: 2, Backward: true}, 0, 1, false, true},
+		{Request{Size: 2, Backward: true}, 3, 5, true, false},
+		{Request{Size: 10}, 0, 5, false, false},
+	}
+
+	for _, test := range tests {
+		start, end, page := test.request.Slice(keys)
+		if start != test.start || end != test.end || page.HasPrevious != test.hasPrevious || page.HasNext != test.hasNext {
+			t.Errorf("Slice(%v) expected %d..%d %t %t, got %d..%d %t %t", test.request, test.start, test.end,
+				test.hasPrevious, test.hasNext, start, end, page.HasPrevious, page.HasNext)
+		}
+		if end > start && page.EndCursor != EncodeCursor(keys[end-1]) {
+			t.Errorf("Slice(%v) expected end cursor %s", test.request, keys[end-1])
+		}
+	}
+
+	_, _, page := (&Request{Size: 2, Total: true}).Slice(keys)
+	if page.Total == nil || *page.Total != 5 {
+		t.Errorf("Slice expected a total of 5, got %v", page.Total)
+	}
+}
+
+func TestNested(t *testing.T) {
+	nestedDocuments = 1
+	defer func() { nestedDocuments = DefaultSize }()
+
+	// The regions of the countries, read a country at a time
+	documents := []string{"DE", "NL", "US", "ZA"}
+	items := map[string][]string{"DE": {"DE-BY"}, "NL": {"NL-NH", "NL-DR", "NL-ZH"}, "US": {"US-AK"}}
+	read := func(request *Request) ([]string, *Page, error) {
+		start, end := 0, len(documents)
+		if len(request.Before) != 0 {
+			end = 0
+		}
+		for i, document := range documents {
+			if len(request.After) != 0 && (document < request.After || document == request.After && !request.inclusive) {
+				start = i + 1
+			}
+			if len(request.Before) != 0 && (document < request.Before || document == request.Before && request.inclusive) {
+				end = i + 1
+			}
+		}
+		if end-start > request.Size {
+			if request.Backward {
+				start = end - request.Size
+			} else {
+				end = start + request.Size
+			}
+		}
+
+		page := Page{HasPrevious: start > 0, HasNext: end < len(documents)}
+		var keys []string
+		for i := start; i < end; i++ {
+			keys = append(keys, items[documents[i]]...)
+			if i == start {
+				page.StartCursor = EncodeCursor(documents[i])
+			}
+			page.EndCursor = EncodeCursor(documents[i])
+		}
+		return keys, &page, nil
+	}
+
+	var tests = []struct {
+		request     Request
+		keys        []string
+		hasPrevious bool
+		hasNext     bool
+	}{
+		{Request{Size: 2}, []string{"DE-BY", "NL-DR"}, false, true},
+		{Request{After: "NL-DR", Size: 2}, []string{"NL-NH", "NL-ZH"}, true, true},
+		{Request{After: "NL-ZH", Size: 2}, []string{"US-AK"}, true, false},
+		{Request{After: "US-AK", Size: 2}, nil, true, false},
+		{Request{Before: "NL-NH", Size: 2, Backward: true}, []string{"DE-BY", "NL-DR"}, false, true},
+		{Request{Before: "US-AK", Size: 2, Backward: true}, []string{"NL-NH", "NL-ZH"}, true, true},
+		{Request{Size: 2, Backward: true}, []string{"NL-ZH", "US-AK"}, true, false},
+		{Request{Size: 10}, []string{"DE-BY", "NL-DR", "NL-NH", "NL-ZH", "US-AK"}, false, false},
+	}
+
+	for _, test := range tests {
+		keys, page, err := Nested(&test.request, "-", read)
+		if err != nil || !reflect.DeepEqual(keys, test.keys) || page.HasPrevious != test.hasPrevious ||
+			page.HasNext != test.hasNext {
+			t.Errorf("Nested(%v) expected %v %t %t, got %v %v (%v)", test.request, test.keys, test.hasPrevious,
+				test.hasNext, keys, page, err)
+		}
+	}
+
+	_, page, err := Nested(&Request{Size: 2, Total: true}, "-", read)
+	if err != nil || page.Total == nil || *page.Total != 5 {
+		t.Errorf("Nested expected a total of 5, got %v (%v)", page, err)
+	}
+
+	_, _, err = Nested(&Request{Size: 2}, "-", func(request *Request) ([]string, *Page, error) {
+		return nil, nil, failures.New(failures.NotFound, "Not found")
+	})
+	if failures.KindOf(err) != failures.NotFound {
+		t.Errorf("Nested of an empty list expected Not found, got %v", err)
+	}
+}
+
+func TestSortedCursor(t *testing.T) {
+	sort := []SortField{{Name: "elevation", Descending: true}, {Name: "airport-name"}}
+	cursor := encodeCursor(sort, []interface{}{-12.0, "Schiphol", "EHAM"})
+
+	key, values, sortName, err := decodeCursor(cursor)
+	if err != nil || key != "EHAM" || sortName != "-elevation,airport-name" ||
+		!reflect.DeepEqual(values, []interface{}{-12.0, "Schiphol", "EHAM"}) {
+		t.Errorf("decodeCursor expected the values of the sort, got %s %v %s (%v)", key, values, sortName, err)
+	}
+
+	if encodeCursor(nil, []interface{}{"EHAM"}) != EncodeCursor("EHAM") {
+		t.Errorf("encodeCursor expected the cursor of the key without a sort")
+	}
+
+	request, err := NewRequest(cursor, "", 10, false, 100)
+	if err != nil {
+		t.Fatalf("NewRequest failed: %v", err)
+	}
+	if request.SetSort(sort[:1]) == nil {
+		t.Errorf("SetSort expected an error for a cursor of another sort")
+	}
+	if request.SetSort(sort) != nil || len(request.Sort) != 2 {
+		t.Errorf("SetSort expected the sort of the cursor")
+	}
+}
+
+func TestParseSort(t *testing.T) {
+	allowed := []string{"icao-airport-code", "airport-name", "elevation"}
+
+	var tests = []struct {
+		s      string
+		result []SortField
+		valid  bool
+	}{
+		{"", nil, true},
+		{"-elevation", []SortField{{"elevation", true}}, true},
+		{" +airport-name ", []SortField{{"airport-name", false}}, true},
+		{"website", nil, false},
+		{"-elevation,airport-name", nil, false},
+		{"elevation,-elevation", nil, false},
+	}
+
+	for _, test := range tests {
+		result, err := ParseSort(test.s, allowed)
+		if test.valid != (err == nil) || (test.valid && len(result)+len(test.result) != 0 &&
+			!reflect.DeepEqual(result, test.result)) {
+			t.Errorf("ParseSort(%s) expected %v %t, got %v (%v)", test.s, test.result, test.valid, result, err)
+		}
+		if test.valid && SortName(result) != SortName(test.result) {
+			t.Errorf("SortName expected %s", SortName(test.result))
+		}
+	}
+}
+
+func TestParseFields(t *testing.T) {
+	allowed := []string{"icao-airport-code", "airport-name", "latitude"}
+
+	fields, err := ParseFields("icao-airport-code, airport-name,icao-airport-code", allowed)
+	if err != nil || !reflect.DeepEqual(fields, []string{"icao-airport-code", "airport-name"}) {
+		t.Errorf("ParseFields expected the fields once, got %v (%v)", fields, err)
+	}
+	if _, err := ParseFields("runways", allowed); err == nil {
+		t.Errorf("ParseFields expected an error for an unknown field")
+	}
+}
+
+func TestOrder(t *testing.T) {
+	request := Request{Sort: []SortField{{Name: "elevation", Descending: true}}}
+	order := request.order("icao-airport-code")
+	if !reflect.DeepEqual(order, []SortField{{"elevation", true}, {"icao-airport-code", true}}) {
+		t.Errorf("order expected the key in the direction of the sort, got %v", order)
+	}
+
+	request.Sort = []SortField{{Name: "icao-airport-code"}, {Name: "elevation"}}
+	if order := request.order("icao-airport-code"); len(order) != 1 {
+		t.Errorf("order expected to end at the key, got %v", order)
+	}
+
+	if order := (&Request{}).order("icao-airport-code"); !reflect.DeepEqual(order, []SortField{{"icao-airport-code", false}}) {
+		t.Errorf("order expected the key without a sort, got %v", order)
+	}
+}
+
+func TestBeyond(t *testing.T) {
+	key := []SortField{{Name: "icao-airport-code"}}
+	filter := beyond(key, []interface{}{"EHAM"}, true, false)
+	expected := bson.D{{Key: "icao-airport-code", Value: bson.D{{Key: "$gt", Value: "EHAM"}}}}
+	if !reflect.DeepEqual(filter, expected) {
+		t.Errorf("beyond expected %v, got %v", expected, filter)
+	}
+
+	order := []SortField{{Name: "elevation", Descending: true}, {Name: "icao-airport-code", Descending: true}}
+	filter = beyond(order, []interface{}{-12.0, "EHAM"}, false, true)
+	expected = bson.D{{Key: "$or", Value: bson.A{
+		bson.D{{Key: "elevation", Value: bson.D{{Key: "$gt", Value: -12.0}}}},
+		bson.D{{Key: "elevation", Value: -12.0}, {Key: "icao-airport-code", Value: bson.D{{Key: "$gte", Value: "EHAM"}}}},
+	}}}
+	if !reflect.DeepEqual(filter, expected) {
+		t.Errorf("beyond expected %v, got %v", expected, filter)
+	}
+}
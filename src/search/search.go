@@ -0,0 +1,424 @@
+package search
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"../failures"
+)
+
+// Search finds airports, countries and regions by what people call them: their names, the place
+// they are in, keywords and codes. Texts are folded to lower case without diacritics, so "sao
+// paulo" finds São Paulo. A word of the query matches a word of a text exactly, as the start of
+// it while typing, or with a typo or two in longer words. Every word of the query has to match.
+// The score of a result is how well its words match, raised by the boost of the entry, like that
+// of a larger airport. The index is kept in memory and built again when the datasets change.
+
+// The kinds of results
+const (
+	Airport = "airport"
+	Country = "country"
+	Region  = "region"
+)
+
+// Kinds are the kinds of results in order
+var Kinds = []string{Airport, Country, Region}
+
+// The weights of the texts of an entry, a code or a name counts more than the place it is in
+const (
+	CodeWeight    = 1.2
+	NameWeight    = 1.0
+	PlaceWeight   = 0.8
+	KeywordWeight = 0.7
+	ParentWeight  = 0.5
+)
+
+// MaxLimit is the largest number of results of a search
+const MaxLimit = 100
+
+// Entry is something that can be found
+type Entry struct {
+	Kind        string
+	Code        string // the ICAO airport code, ISO country code or ISO region code
+	CountryCode string
+	Name        string
+	Texts       []Text
+	Boost       float64 // raises the score, a negative boost lowers it
+}
+
+// Text is a text an entry can be found by, with its weight
+type Text struct {
+	Value  string
+	Weight float64
+}
+
+// Result is an entry that was found
+type Result struct {
+	Kind        string  `json:"kind"`
+	Code        string  `json:"code"`
+	CountryCode string  `json:"iso-country-code"`
+	Name        string  `json:"name"`
+	Score       float64 `json:"score"`
+}
+
+// Index finds entries by the words of their texts
+type Index struct {
+	entries  []*Entry
+	terms    []string // the words of the texts in order, to find the words starting with a prefix
+	postings map[string][]posting
+}
+
+// posting is an entry having a word, with the weight of its best text having it
+type posting struct {
+	entry  int
+	weight float64
+}
+
+// folding spells the letters with diacritics without them
+var folding = map[rune]string{}
+
+func init() {
+	for letters, plain := range map[string]string{
+		"àáâãäåāăą": "a", "çćĉċč": "c", "ďđ": "d", "èéêëēĕėęě": "e", "ĝğġģ": "g", "ĥħ": "h",
+		"ìíîïĩīĭįı": "i", "ĵ": "j", "ķ": "k", "ĺļľŀł": "l", "ñńņňŉ": "n", "òóôõöøōŏő": "o",
+		"ŕŗř": "r", "śŝşšș": "s", "ţťŧț": "t", "ùúûüũūŭůűų": "u", "ŵ": "w", "ýÿŷ": "y",
+		"źżž": "z", "ß": "ss", "æ": "ae", "œ": "oe", "þ": "th", "ð": "d",
+	} {
+		for _, letter := range letters {
+			folding[letter] = plain
+		}
+	}
+}
+
+// Fold writes a text in lower case without diacritics
+func Fold(text string) string {
+	var result strings.Builder
+	for _, letter := range text {
+		result.WriteString(foldLetter(letter))
+	}
+	return result.String()
+}
+
+// foldLetter writes a letter in lower case without diacritics, a mark on its own is left out
+func foldLetter(letter rune) string {
+	letter = unicode.ToLower(letter)
+	if plain, ok := folding[letter]; ok {
+		return plain
+	}
+	if unicode.Is(unicode.Mn, letter) {
+		return ""
+	}
+	return string(letter)
+}
+
+// Terms are the folded words of a text
+func Terms(text string) []string {
+	return strings.FieldsFunc(Fold(text), separator)
+}
+
+// separator tells whether a letter separates words
+func separator(letter rune) bool {
+	return !unicode.IsLetter(letter) && !unicode.IsDigit(letter)
+}
+
+// Word is a folded word of a text, with where it is in the text counted in letters
+type Word struct {
+	Text  string
+	Start int
+	End   int
+}
+
+// Words are the folded words of a text with where they are, to point them out
+func Words(text string) []Word {
+	var result []Word
+	var word strings.Builder
+	start := 0
+	i := 0
+	for _, letter := range text {
+		folded := foldLetter(letter)
+		if len(folded) != 0 && separator([]rune(folded)[0]) {
+			if word.Len() != 0 {
+				result = append(result, Word{Text: word.String(), Start: start, End: i})
+				word.Reset()
+			}
+		} else {
+			if word.Len() == 0 {
+				start = i
+			}
+			word.WriteString(folded)
+		}
+		i++
+	}
+	if word.Len() != 0 {
+		result = append(result, Word{Text: word.String(), Start: start, End: i})
+	}
+	return result
+}
+
+// Prefix is where the folded prefix of a word is in the text, counted in letters
+func Prefix(text string, word Word, prefix string) (int, int) {
+	length := 0
+	letters := []rune(text)
+	for i := word.Start; i < word.End; i++ {
+		if length >= len(prefix) {
+			return word.Start, i
+		}
+		length += len(foldLetter(letters[i]))
+	}
+	return word.Start, word.End
+}
+
+// NewIndex indexes the words of the texts of the entries
+func NewIndex(entries []*Entry) *Index {
+	index := Index{entries: entries, postings: map[string][]posting{}}
+
+	for i, entry := range entries {
+		weights := map[string]float64{}
+		var terms []string
+		for _, text := range entry.Texts {
+			for _, term := range Terms(text.Value) {
+				if _, ok := weights[term]; !ok {
+					terms = append(terms, term)
+				}
+				if text.Weight > weights[term] {
+					weights[term] = text.Weight
+				}
+			}
+		}
+		for _, term := range terms {
+			if _, ok := index.postings[term]; !ok {
+				index.terms = append(index.terms, term)
+			}
+			index.postings[term] = append(index.postings[term], posting{entry: i, weight: weights[term]})
+		}
+	}
+	sort.Strings(index.terms)
+
+	return &index
+}
+
+// Search finds the entries of the kinds, all kinds when none are given, that match every word of
+// the query, best first
+func (index *Index) Search(query string, kinds []string, limit int) []*Result {
+	words := Terms(query)
+	if len(words) == 0 {
+		return nil
+	}
+
+	var scores map[int]float64
+	for i, word := range words {
+		// The best match of the word per entry
+		matches := map[int]float64{}
+		for term, similarity := range index.matches(word) {
+			for _, posting := range index.postings[term] {
+				if score := similarity * posting.weight; score > matches[posting.entry] {
+					matches[posting.entry] = score
+				}
+			}
+		}
+
+		next := map[int]float64{}
+		for entry, score := range matches {
+			if previous, ok := scores[entry]; ok || i == 0 {
+				next[entry] = previous + score
+			}
+		}
+		scores = next
+	}
+
+	var result []*Result
+	for i, score := range scores {
+		entry := index.entries[i]
+		if len(kinds) != 0 && !contains(kinds, entry.Kind) {
+			continue
+		}
+		result = append(result, &Result{
+			Kind:        entry.Kind,
+			Code:        entry.Code,
+			CountryCode: entry.CountryCode,
+			Name:        entry.Name,
+			Score:       score / float64(len(words)) * (1 + entry.Boost),
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Score != result[j].Score {
+			return result[i].Score > result[j].Score
+		}
+		if result[i].Name != result[j].Name {
+			return result[i].Name < result[j].Name
+		}
+		return result[i].Kind+result[i].Code < result[j].Kind+result[j].Code
+	})
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result
+}
+
+// matches finds the words of the index that match a word of a query, with their similarity: 1
+// for the word itself, less for a word it starts and less again for a word with typos
+func (index *Index) matches(word string) map[string]float64 {
+	result := map[string]float64{}
+	if _, ok := index.postings[word]; ok {
+		result[word] = 1
+	}
+
+	length := utf8.RuneCountInString(word)
+	if length >= 2 {
+		for i := sort.SearchStrings(index.terms, word); i < len(index.terms); i++ {
+			term := index.terms[i]
+			if !strings.HasPrefix(term, word) {
+				break
+			}
+			if term != word {
+				result[term] = 0.5 + 0.4*float64(length)/float64(utf8.RuneCountInString(term))
+			}
+		}
+	}
+
+	typos := maxTypos(length)
+	if typos == 0 {
+		return result
+	}
+	for _, term := range index.terms {
+		if _, ok := result[term]; ok {
+			continue
+		}
+		difference := utf8.RuneCountInString(term) - length
+		if difference > typos || difference < -typos {
+			continue
+		}
+		if distance := distance([]rune(word), []rune(term), typos); distance <= typos {
+			result[term] = 0.8 - 0.2*float64(distance)
+		}
+	}
+	return result
+}
+
+// maxTypos is the number of typos allowed in a word, none in short words
+func maxTypos(length int) int {
+	switch {
+	case length >= 8:
+		return 2
+	case length >= 4:
+		return 1
+	}
+	return 0
+}
+
+// distance counts the letters to insert, delete, replace or swap to spell one word as the other,
+// a count above the limit is returned as limit + 1
+func distance(a []rune, b []rune, limit int) int {
+	previous2 := make([]int, len(b)+1)
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		smallest := current[0]
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = minimum(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				current[j] = minimum(current[j], previous2[j-2]+1)
+			}
+			smallest = minimum(smallest, current[j])
+		}
+		if smallest > limit {
+			return limit + 1
+		}
+		previous2, previous, current = previous, current, previous2
+	}
+
+	if previous[len(b)] > limit {
+		return limit + 1
+	}
+	return previous[len(b)]
+}
+
+func minimum(values ...int) int {
+	result := values[0]
+	for _, value := range values[1:] {
+		if value < result {
+			result = value
+		}
+	}
+	return result
+}
+
+func contains(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Service keeps the index of the current datasets
+type Service struct {
+	mutex   sync.Mutex
+	load    func() ([]*Entry, error)
+	version func() (string, error)
+	index   *Index
+	current string
+}
+
+// NewService makes a service that loads the entries into an index, and loads them again when the
+// version of the datasets changes
+func NewService(load func() ([]*Entry, error), version func() (string, error)) *Service {
+	return &Service{load: load, version: version}
+}
+
+// Index is the index of the current datasets, it is built on first use and after an import
+func (service *Service) Index() (*Index, error) {
+	version, err := service.version()
+	if err != nil {
+		return nil, err
+	}
+
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	if service.index == nil || version != service.current {
+		entries, err := service.load()
+		if err != nil {
+			return nil, err
+		}
+		service.index = NewIndex(entries)
+		service.current = version
+	}
+	return service.index, nil
+}
+
+// Search checks the arguments of a search and searches the index of the current datasets
+func (service *Service) Search(query string, kinds []string, limit int) ([]*Result, error) {
+	if len(Terms(query)) == 0 {
+		return nil, failures.New(failures.InvalidArgument, "Search(%s): Missing query", query)
+	}
+	for _, kind := range kinds {
+		if !contains(Kinds, kind) {
+			return nil, failures.New(failures.InvalidArgument, "Search.Kind(%s): use %s", kind,
+				strings.Join(Kinds, ", "))
+		}
+	}
+	if limit < 1 || limit > MaxLimit {
+		return nil, failures.New(failures.InvalidArgument, "Search.Limit(%d): use 1 to %d", limit, MaxLimit)
+	}
+
+	index, err := service.Index()
+	if err != nil {
+		return nil, err
+	}
+	return index.Search(query, kinds, limit), nil
+}
@@ -0,0 +1,184 @@
+package search
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+var testEntries = []*Entry{
+	{Kind: Airport, Code: "EHAM", CountryCode: "NL", Name: "Amsterdam Airport Schiphol", Boost: 0.9,
+		Texts: []Text{{"EHAM", CodeWeight}, {"AMS", CodeWeight}, {"Amsterdam Airport Schiphol", NameWeight},
+			{"Amsterdam", PlaceWeight}, {"Netherlands", ParentWeight}}},
+	{Kind: Airport, Code: "EHLE", CountryCode: "NL", Name: "Lelystad Airport", Boost: 0.3,
+		Texts: []Text{{"EHLE", CodeWeight}, {"Lelystad Airport", NameWeight}, {"Lelystad", PlaceWeight},
+			{"Amsterdam Lelystad", KeywordWeight}, {"Netherlands", ParentWeight}}},
+	{Kind: Airport, Code: "EGLL", CountryCode: "GB", Name: "London Heathrow Airport", Boost: 0.9,
+		Texts: []Text{{"EGLL", CodeWeight}, {"LHR", CodeWeight}, {"London Heathrow Airport", NameWeight},
+			{"London", PlaceWeight}, {"United Kingdom", ParentWeight}}},
+	{Kind: Airport, Code: "SBGR", CountryCode: "BR", Name: "São Paulo/Guarulhos International Airport",
+		Boost: 0.9, Texts: []Text{{"SBGR", CodeWeight}, {"GRU", CodeWeight},
+			{"São Paulo/Guarulhos International Airport", NameWeight}, {"São Paulo", PlaceWeight},
+			{"Brazil", ParentWeight}}},
+	{Kind: Airport, Code: "SDSP", CountryCode: "BR", Name: "São Paulo Heliport", Boost: 0,
+		Texts: []Text{{"SDSP", CodeWeight}, {"São Paulo Heliport", NameWeight}, {"São Paulo", PlaceWeight},
+			{"Brazil", ParentWeight}}},
+	{Kind: Country, Code: "NL", CountryCode: "NL", Name: "Netherlands", Boost: 1,
+		Texts: []Text{{"NL", CodeWeight}, {"Netherlands", NameWeight}}},
+	{Kind: Region, Code: "SP", CountryCode: "BR", Name: "São Paulo", Boost: 0.5,
+		Texts: []Text{{"São Paulo", NameWeight}, {"Brazil", ParentWeight}}},
+}
+
+func TestFold(t *testing.T) {
+	var tests = []struct {
+		text  string
+		terms []string
+	}{
+		{"São Paulo/Guarulhos", []string{"sao", "paulo", "guarulhos"}},
+		{"Zürich-Kloten", []string{"zurich", "kloten"}},
+		{"Łódź Władysław Reymont", []string{"lodz", "wladyslaw", "reymont"}},
+		{"Straße  Ærø", []string{"strasse", "aero"}},
+		{"São", []string{"sao"}},
+		{"  ", []string{}},
+	}
+
+	for _, test := range tests {
+		if terms := Terms(test.text); !reflect.DeepEqual(terms, test.terms) {
+			t.Errorf("Terms(%s) expected %v, got %v", test.text, test.terms, terms)
+		}
+	}
+}
+
+func TestDistance(t *testing.T) {
+	var tests = []struct {
+		a        string
+		b        string
+		distance int
+	}{
+		{"schiphol", "schiphol", 0},
+		{"shiphol", "schiphol", 1},
+		{"paolo", "paulo", 1},
+		{"heathrwo", "heathrow", 1},
+		{"hethrow", "heathrow", 1},
+		{"london", "lisbon", 3},
+	}
+
+	for _, test := range tests {
+		if distance := distance([]rune(test.a), []rune(test.b), 2); distance != test.distance {
+			t.Errorf("distance(%s, %s) expected %d, got %d", test.a, test.b, test.distance, distance)
+		}
+	}
+}
+
+func TestSearch(t *testing.T) {
+	index := NewIndex(testEntries)
+
+	var tests = []struct {
+		query string
+		kinds []string
+		codes []string
+	}{
+		{"schiphol", nil, []string{"EHAM"}},
+		{"SCHIPHOL", nil, []string{"EHAM"}},
+		{"shiphol", nil, []string{"EHAM"}},
+		{"heathrow", nil, []string{"EGLL"}},
+		{"heathro", nil, []string{"EGLL"}},
+		{"LHR", nil, []string{"EGLL"}},
+		{"sao paulo", nil, []string{"SBGR", "SP", "SDSP"}},
+		{"sao paulo", []string{Region}, []string{"SP"}},
+		{"são paolo heliport", nil, []string{"SDSP"}},
+		{"amsterdam", nil, []string{"EHAM", "EHLE"}},
+		{"netherlands", nil, []string{"NL", "EHAM", "EHLE"}},
+		{"xyzzy", nil, nil},
+		{"", nil, nil},
+	}
+
+	for _, test := range tests {
+		var codes []string
+		for _, result := range index.Search(test.query, test.kinds, 10) {
+			codes = append(codes, result.Code)
+		}
+		if !reflect.DeepEqual(codes, test.codes) {
+			t.Errorf("Search(%s) expected %v, got %v", test.query, test.codes, codes)
+		}
+	}
+
+	if results := index.Search("airport", nil, 2); len(results) != 2 || results[0].Score < results[1].Score {
+		t.Errorf("Search expected the best results up to the limit, got %v", results)
+	}
+}
+
+func TestService(t *testing.T) {
+	loads := 0
+	version := "1"
+	service := NewService(func() ([]*Entry, error) {
+		loads++
+		return testEntries, nil
+	}, func() (string, error) {
+		return version, nil
+	})
+
+	service.Search("schiphol", nil, 10)
+	service.Search("heathrow", nil, 10)
+	if loads != 1 {
+		t.Errorf("Service expected to load the entries once, got %d", loads)
+	}
+	version = "2"
+	service.Search("schiphol", nil, 10)
+	if loads != 2 {
+		t.Errorf("Service expected to load the entries again for a new version, got %d", loads)
+	}
+
+	var tests = []struct {
+		query string
+		kinds []string
+		limit int
+	}{
+		{"", nil, 10},
+		{"schiphol", []string{"runway"}, 10},
+		{"schiphol", nil, 0},
+		{"schiphol", nil, MaxLimit + 1},
+	}
+	for _, test := range tests {
+		if _, err := service.Search(test.query, test.kinds, test.limit); err == nil {
+			t.Errorf("Search(%s, %v, %d) expected an error", test.query, test.kinds, test.limit)
+		}
+	}
+
+	failing := NewService(func() ([]*Entry, error) {
+		return nil, errors.New("Unavailable")
+	}, func() (string, error) {
+		return "1", nil
+	})
+	if _, err := failing.Search("schiphol", nil, 10); err == nil {
+		t.Errorf("Search expected the error of loading the entries")
+	}
+}
+
+func TestWords(t *testing.T) {
+	text := "São Paulo/Guarulhos Straße"
+	words := Words(text)
+	expected := []Word{{"sao", 0, 3}, {"paulo", 4, 9}, {"guarulhos", 10, 19}, {"strasse", 20, 26}}
+	if !reflect.DeepEqual(words, expected) {
+		t.Fatalf("Words(%s) expected %v, got %v", text, expected, words)
+	}
+
+	var tests = []struct {
+		word   Word
+		prefix string
+		start  int
+		end    int
+	}{
+		{words[0], "sa", 0, 2},
+		{words[0], "sao", 0, 3},
+		{words[2], "guar", 10, 14},
+		{words[3], "strass", 20, 25},
+		{words[3], "strasse", 20, 26},
+	}
+
+	for _, test := range tests {
+		if start, end := Prefix(text, test.word, test.prefix); start != test.start || end != test.end {
+			t.Errorf("Prefix(%s) expected %d..%d, got %d..%d", test.prefix, test.start, test.end, start, end)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package solar
+
+import (
+	"math"
+	"time"
+)
+
+// Solar calculates the position of the sun to find sunrise, sunset and twilight for a position
+// and date, using the sunrise equation with the orbital corrections of the Astronomical Almanac.
+// The results are accurate to about a minute, good enough for flight planning.
+
+// Altitudes of the center of the sun defining the events, in degrees. Sunrise includes
+// refraction and the radius of the sun, the dip of the horizon is added for the elevation.
+const (
+	sunriseAltitude  = -0.833
+	civilAltitude    = -6.0
+	nauticalAltitude = -12.0
+)
+
+const (
+	julian1970  = 2440587.5
+	julian2000  = 2451545.0
+	obliquity   = 23.4397
+	degrees     = math.Pi / 180.0
+	secondsADay = 86400.0
+)
+
+// Times are the moments of the events on a day. An event is absent when the sun does not
+// cross its altitude that day, as happens near the poles.
+type Times struct {
+	NauticalDawn *time.Time `json:"nautical-dawn,omitempty"`
+	CivilDawn    *time.Time `json:"civil-dawn,omitempty"`
+	Sunrise      *time.Time `json:"sunrise,omitempty"`
+	SolarNoon    time.Time  `json:"solar-noon"`
+	Sunset       *time.Time `json:"sunset,omitempty"`
+	CivilDusk    *time.Time `json:"civil-dusk,omitempty"`
+	NauticalDusk *time.Time `json:"nautical-dusk,omitempty"`
+}
+
+// Daylight describes the daylight on a date, in UTC and, when known, in local time
+type Daylight struct {
+	Date       string `json:"date"`
+	TimeZone   string `json:"time-zone,omitempty"`
+	DayLength  int    `json:"day-length"`
+	PolarDay   bool   `json:"polar-day,omitempty"`
+	PolarNight bool   `json:"polar-night,omitempty"`
+	UTC        *Times `json:"utc"`
+	Local      *Times `json:"local,omitempty"`
+}
+
+// Calculate finds the events for a position on a date; latitude and longitude in degrees and
+// the elevation in feet. Only the year, month and day of the date are used, the events are those
+// around the solar noon of that day at the position.
+func Calculate(latitude float64, longitude float64, elevation float64, date time.Time) *Daylight {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 12, 0, 0, 0, time.UTC)
+	n := math.Round(float64(day.Unix())/secondsADay + julian1970 - julian2000)
+
+	// Mean solar noon, the anomaly and the ecliptic longitude of the sun
+	meanNoon := n - longitude/360.0
+	anomaly := math.Mod(357.5291+0.98560028*meanNoon, 360.0) * degrees
+	center := 1.9148*math.Sin(anomaly) + 0.0200*math.Sin(2*anomaly) + 0.0003*math.Sin(3*anomaly)
+	eclipticLongitude := math.Mod(anomaly/degrees+center+180.0+102.9372, 360.0) * degrees
+
+	transit := julian2000 + meanNoon + 0.0053*math.Sin(anomaly) - 0.0069*math.Sin(2*eclipticLongitude)
+	declination := math.Asin(math.Sin(eclipticLongitude) * math.Sin(obliquity*degrees))
+
+	// The hour angle at which the sun passes an altitude; -1 is always above, +1 always below
+	hourAngle := func(altitude float64) float64 {
+		return (math.Sin(altitude*degrees) - math.Sin(latitude*degrees)*math.Sin(declination)) /
+			(math.Cos(latitude*degrees) * math.Cos(declination))
+	}
+	event := func(altitude float64, sign float64) *time.Time {
+		cosine := hourAngle(altitude)
+		if cosine < -1 || cosine > 1 {
+			return nil
+		}
+		result := toTime(transit + sign*math.Acos(cosine)/(2*math.Pi))
+		return &result
+	}
+
+	// The horizon drops with the elevation of the observer
+	altitude := sunriseAltitude
+	if elevation > 0 {
+		altitude -= 2.076 * math.Sqrt(elevation*0.3048) / 60.0
+	}
+
+	daylight := Daylight{
+		Date: day.Format("2006-01-02"),
+		UTC: &Times{
+			NauticalDawn: event(nauticalAltitude, -1),
+			CivilDawn:    event(civilAltitude, -1),
+			Sunrise:      event(altitude, -1),
+			SolarNoon:    toTime(transit),
+			Sunset:       event(altitude, 1),
+			CivilDusk:    event(civilAltitude, 1),
+			NauticalDusk: event(nauticalAltitude, 1)}}
+
+	cosine := hourAngle(altitude)
+	switch {
+	case cosine < -1:
+		daylight.PolarDay = true
+		daylight.DayLength = 24 * 60
+	case cosine > 1:
+		daylight.PolarNight = true
+	default:
+		daylight.DayLength = int(math.Round(daylight.UTC.Sunset.Sub(*daylight.UTC.Sunrise).Minutes()))
+	}
+
+	return &daylight
+}
+
+// InLocation adds the events in the local time of a time zone
+func (daylight *Daylight) InLocation(timeZone string, location *time.Location) {
+	local := func(moment *time.Time) *time.Time {
+		if moment == nil {
+			return nil
+		}
+		result := moment.In(location)
+		return &result
+	}
+
+	daylight.TimeZone = timeZone
+	daylight.Local = &Times{
+		NauticalDawn: local(daylight.UTC.NauticalDawn),
+		CivilDawn:    local(daylight.UTC.CivilDawn),
+		Sunrise:      local(daylight.UTC.Sunrise),
+		SolarNoon:    daylight.UTC.SolarNoon.In(location),
+		Sunset:       local(daylight.UTC.Sunset),
+		CivilDusk:    local(daylight.UTC.CivilDusk),
+		NauticalDusk: local(daylight.UTC.NauticalDusk)}
+}
+
+// toTime converts a julian date to a moment in UTC, rounded to the second
+func toTime(julian float64) time.Time {
+	seconds := math.Round((julian - julian1970) * secondsADay)
+	return time.Unix(int64(seconds), 0).UTC()
+}
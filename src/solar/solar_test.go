@@ -0,0 +1,100 @@
+package solar
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCalculate(t *testing.T) {
+	var tests = []struct {
+		name       string
+		latitude   float64
+		longitude  float64
+		date       time.Time
+		sunrise    time.Time
+		sunset     time.Time
+		polarDay   bool
+		polarNight bool
+	}{
+		{"London", 51.5074, -0.1278, time.Date(2020, time.June, 21, 0, 0, 0, 0, time.UTC),
+			time.Date(2020, time.June, 21, 3, 43, 0, 0, time.UTC), time.Date(2020, time.June, 21, 20, 21, 0, 0, time.UTC), false, false},
+		{"Sydney", -33.8688, 151.2093, time.Date(2020, time.December, 21, 0, 0, 0, 0, time.UTC), // the day starts the evening before in UTC
+			time.Date(2020, time.December, 20, 18, 41, 0, 0, time.UTC), time.Date(2020, time.December, 21, 9, 5, 0, 0, time.UTC), false, false},
+		{"Tromso", 69.6492, 18.9553, time.Date(2020, time.June, 21, 0, 0, 0, 0, time.UTC),
+			time.Time{}, time.Time{}, true, false},
+		{"Tromso", 69.6492, 18.9553, time.Date(2020, time.December, 21, 0, 0, 0, 0, time.UTC),
+			time.Time{}, time.Time{}, false, true},
+	}
+
+	for _, test := range tests {
+		daylight := Calculate(test.latitude, test.longitude, 0, test.date)
+
+		if daylight.PolarDay != test.polarDay || daylight.PolarNight != test.polarNight {
+			t.Errorf("Calculate(%s, %s) expected polar day %t, night %t, got %t, %t", test.name, daylight.Date,
+				test.polarDay, test.polarNight, daylight.PolarDay, daylight.PolarNight)
+		}
+
+		if test.polarDay || test.polarNight {
+			if daylight.UTC.Sunrise != nil || daylight.UTC.Sunset != nil {
+				t.Errorf("Calculate(%s, %s) expected no sunrise and sunset, got %v, %v", test.name, daylight.Date,
+					daylight.UTC.Sunrise, daylight.UTC.Sunset)
+			}
+			continue
+		}
+
+		if daylight.UTC.Sunrise == nil || daylight.UTC.Sunset == nil {
+			t.Errorf("Calculate(%s, %s) expected sunrise and sunset", test.name, daylight.Date)
+			continue
+		}
+		if diff := daylight.UTC.Sunrise.Sub(test.sunrise); diff < -2*time.Minute || diff > 2*time.Minute {
+			t.Errorf("Calculate(%s, %s) expected sunrise %v, got %v", test.name, daylight.Date, test.sunrise, daylight.UTC.Sunrise)
+		}
+		if diff := daylight.UTC.Sunset.Sub(test.sunset); diff < -2*time.Minute || diff > 2*time.Minute {
+			t.Errorf("Calculate(%s, %s) expected sunset %v, got %v", test.name, daylight.Date, test.sunset, daylight.UTC.Sunset)
+		}
+	}
+}
+
+func TestTwilight(t *testing.T) {
+	// Around midsummer Edinburgh has a civil but no nautical night
+	daylight := Calculate(55.9533, -3.1883, 0, time.Date(2020, time.June, 21, 0, 0, 0, 0, time.UTC))
+	if daylight.UTC.CivilDawn == nil || daylight.UTC.CivilDusk == nil {
+		t.Errorf("Calculate(Edinburgh) expected civil twilight")
+	} else if !daylight.UTC.CivilDawn.Before(*daylight.UTC.Sunrise) || !daylight.UTC.CivilDusk.After(*daylight.UTC.Sunset) {
+		t.Errorf("Calculate(Edinburgh) expected civil twilight around sunrise and sunset, got %v - %v",
+			daylight.UTC.CivilDawn, daylight.UTC.CivilDusk)
+	}
+	if daylight.UTC.NauticalDawn != nil || daylight.UTC.NauticalDusk != nil {
+		t.Errorf("Calculate(Edinburgh) expected no nautical twilight, got %v - %v",
+			daylight.UTC.NauticalDawn, daylight.UTC.NauticalDusk)
+	}
+
+	// Tromso in polar night still has civil twilight around noon
+	daylight = Calculate(69.6492, 18.9553, 0, time.Date(2020, time.December, 21, 0, 0, 0, 0, time.UTC))
+	if daylight.UTC.CivilDawn == nil || daylight.UTC.CivilDusk == nil || daylight.DayLength != 0 {
+		t.Errorf("Calculate(Tromso) expected civil twilight without daylight")
+	}
+}
+
+func TestElevation(t *testing.T) {
+	// From higher up the sun rises earlier
+	date := time.Date(2020, time.March, 14, 0, 0, 0, 0, time.UTC)
+	low := Calculate(46.0, 8.0, 0, date)
+	high := Calculate(46.0, 8.0, 10000, date)
+	if !high.UTC.Sunrise.Before(*low.UTC.Sunrise) || high.DayLength <= low.DayLength {
+		t.Errorf("Calculate expected an earlier sunrise at elevation, got %v and %v", high.UTC.Sunrise, low.UTC.Sunrise)
+	}
+}
+
+func TestInLocation(t *testing.T) {
+	location := time.FixedZone("CEST", 2*3600)
+	daylight := Calculate(52.3086, 4.7639, 0, time.Date(2020, time.June, 21, 0, 0, 0, 0, time.UTC))
+	daylight.InLocation("Europe/Amsterdam", location)
+
+	if daylight.Local == nil || daylight.Local.Sunrise == nil || !daylight.Local.Sunrise.Equal(*daylight.UTC.Sunrise) {
+		t.Fatalf("InLocation expected the same sunrise in local time")
+	}
+	if daylight.Local.Sunrise.Hour() != 5 || daylight.TimeZone != "Europe/Amsterdam" {
+		t.Errorf("InLocation expected sunrise at 5 local, got %v", daylight.Local.Sunrise)
+	}
+}
@@ -0,0 +1,319 @@
+package tiles
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"../geometry"
+)
+
+// Tiles implements Mapbox Vector Tiles (version 2.1 of the specification): the web mercator
+// tile grid and the protobuf encoding of the layers, which is simple enough to write directly.
+
+// Extent is the size of a tile in tile coordinates
+const Extent = 4096
+
+// Buffer is the margin around a tile in tile coordinates, features within it are included so
+// that symbols on the edge are not cut off
+const Buffer = 64
+
+// MaxZoom is the highest zoom level served
+const MaxZoom = 22
+
+// Geometry types
+const (
+	point      = 1
+	lineString = 2
+)
+
+// Commands in the geometry encoding
+const (
+	moveTo = 1
+	lineTo = 2
+)
+
+// maxLatitude is where the web mercator projection is cut off
+var maxLatitude = math.Atan(math.Sinh(math.Pi)) * 180.0 / math.Pi
+
+// Tile is a single tile being built
+type Tile struct {
+	Zoom   int
+	X      int
+	Y      int
+	layers []*Layer
+}
+
+// Layer is a named layer in a tile with its features
+type Layer struct {
+	tile     *Tile
+	name     string
+	keys     []string
+	keyIndex map[string]int
+	values   []interface{}
+	valIndex map[interface{}]int
+	features [][]byte
+}
+
+// NewTile validates the tile address and sets up an empty tile
+func NewTile(zoom int, x int, y int) (*Tile, error) {
+	if zoom < 0 || zoom > MaxZoom {
+		return nil, fmt.Errorf("Tile(%d/%d/%d): Invalid zoom", zoom, x, y)
+	}
+	if x < 0 || y < 0 || x >= 1<<uint(zoom) || y >= 1<<uint(zoom) {
+		return nil, fmt.Errorf("Tile(%d/%d/%d): Invalid tile", zoom, x, y)
+	}
+
+	return &Tile{Zoom: zoom, X: x, Y: y}, nil
+}
+
+// Bounds calculates the area covered by the tile including the buffer
+func (tile *Tile) Bounds() geometry.Box {
+	n := float64(int(1) << uint(tile.Zoom))
+	margin := float64(Buffer) / Extent
+
+	longitude := func(x float64) float64 {
+		return math.Max(-180, math.Min(180, x/n*360.0-180.0))
+	}
+	latitude := func(y float64) float64 {
+		return math.Atan(math.Sinh(math.Pi*(1-2*y/n))) * 180.0 / math.Pi
+	}
+
+	return geometry.Box{
+		MinLongitude: longitude(float64(tile.X) - margin),
+		MaxLongitude: longitude(float64(tile.X) + 1 + margin),
+		MinLatitude:  latitude(float64(tile.Y) + 1 + margin),
+		MaxLatitude:  latitude(float64(tile.Y) - margin)}
+}
+
+// Project converts a position to the coordinates within the tile
+func (tile *Tile) Project(latitude float64, longitude float64) (int, int) {
+	n := float64(int(1) << uint(tile.Zoom))
+	latitude = math.Max(-maxLatitude, math.Min(maxLatitude, latitude)) * math.Pi / 180.0
+
+	x := (longitude + 180.0) / 360.0 * n
+	y := (1 - math.Log(math.Tan(latitude)+1/math.Cos(latitude))/math.Pi) / 2 * n
+
+	return int(math.Round((x - float64(tile.X)) * Extent)), int(math.Round((y - float64(tile.Y)) * Extent))
+}
+
+// inside checks whether tile coordinates fall within the tile and its buffer
+func inside(x int, y int) bool {
+	return x >= -Buffer && x <= Extent+Buffer && y >= -Buffer && y <= Extent+Buffer
+}
+
+// AddLayer adds a layer to the tile
+func (tile *Tile) AddLayer(name string) *Layer {
+	layer := Layer{
+		tile:     tile,
+		name:     name,
+		keyIndex: map[string]int{},
+		valIndex: map[interface{}]int{}}
+	tile.layers = append(tile.layers, &layer)
+	return &layer
+}
+
+// AddPoint adds a point feature, false when it lies outside the tile
+func (layer *Layer) AddPoint(latitude float64, longitude float64, properties map[string]interface{}) bool {
+	x, y := layer.tile.Project(latitude, longitude)
+	if !inside(x, y) {
+		return false
+	}
+
+	layer.addFeature(point, encodeGeometry(point, [][2]int{{x, y}}), properties)
+	return true
+}
+
+// AddLine adds a line feature through the positions (latitude, longitude), false when it is
+// entirely outside the tile or has no length at this zoom level
+func (layer *Layer) AddLine(positions []geometry.Position, properties map[string]interface{}) bool {
+	var points [][2]int
+	anyInside := false
+	for _, position := range positions {
+		x, y := layer.tile.Project(position.Latitude, position.Longitude)
+		if len(points) > 0 && points[len(points)-1] == [2]int{x, y} {
+			continue
+		}
+		points = append(points, [2]int{x, y})
+		anyInside = anyInside || inside(x, y)
+	}
+	if !anyInside || len(points) < 2 {
+		return false
+	}
+
+	layer.addFeature(lineString, encodeGeometry(lineString, points), properties)
+	return true
+}
+
+// addFeature encodes a feature with its properties as tags
+func (layer *Layer) addFeature(geometryType int, geometry []uint32, properties map[string]interface{}) {
+	// Sorted for a stable encoding
+	var keys []string
+	for key := range properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var tags []uint32
+	for _, key := range keys {
+		value := normalizeValue(properties[key])
+		if value == nil {
+			continue
+		}
+		tags = append(tags, uint32(layer.key(key)), uint32(layer.value(value)))
+	}
+
+	var feature []byte
+	feature = appendVarintField(feature, 1, uint64(len(layer.features)+1))
+	feature = appendPacked(feature, 2, tags)
+	feature = appendVarintField(feature, 3, uint64(geometryType))
+	feature = appendPacked(feature, 4, geometry)
+	layer.features = append(layer.features, feature)
+}
+
+func (layer *Layer) key(key string) int {
+	index, ok := layer.keyIndex[key]
+	if !ok {
+		index = len(layer.keys)
+		layer.keys = append(layer.keys, key)
+		layer.keyIndex[key] = index
+	}
+	return index
+}
+
+func (layer *Layer) value(value interface{}) int {
+	index, ok := layer.valIndex[value]
+	if !ok {
+		index = len(layer.values)
+		layer.values = append(layer.values, value)
+		layer.valIndex[value] = index
+	}
+	return index
+}
+
+// normalizeValue reduces the property types to those of the tile format, nil skips the property
+func normalizeValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		if len(v) == 0 {
+			return nil
+		}
+		return v
+	case bool:
+		return v
+	case int:
+		return int64(v)
+	case int64:
+		return v
+	case float32:
+		return float64(v)
+	case float64:
+		return v
+	}
+	return nil
+}
+
+// Encode produces the protobuf encoding of the tile, layers without features are left out
+func (tile *Tile) Encode() []byte {
+	var result []byte
+	for _, layer := range tile.layers {
+		if len(layer.features) == 0 {
+			continue
+		}
+		result = appendBytesField(result, 3, layer.encode())
+	}
+	return result
+}
+
+func (layer *Layer) encode() []byte {
+	var result []byte
+	result = appendVarintField(result, 15, 2)
+	result = appendBytesField(result, 1, []byte(layer.name))
+	for _, feature := range layer.features {
+		result = appendBytesField(result, 2, feature)
+	}
+	for _, key := range layer.keys {
+		result = appendBytesField(result, 3, []byte(key))
+	}
+	for _, value := range layer.values {
+		var encoded []byte
+		switch v := value.(type) {
+		case string:
+			encoded = appendBytesField(encoded, 1, []byte(v))
+		case float64:
+			encoded = appendVarint(encoded, 3<<3|1)
+			bits := math.Float64bits(v)
+			for i := uint(0); i < 8; i++ {
+				encoded = append(encoded, byte(bits>>(8*i)))
+			}
+		case int64:
+			encoded = appendVarintField(encoded, 6, zigzag(v))
+		case bool:
+			var b uint64
+			if v {
+				b = 1
+			}
+			encoded = appendVarintField(encoded, 7, b)
+		}
+		result = appendBytesField(result, 4, encoded)
+	}
+	result = appendVarintField(result, 5, Extent)
+	return result
+}
+
+// encodeGeometry writes the commands for a point or a line; coordinates are relative to the
+// previous point and zigzag encoded
+func encodeGeometry(geometryType int, points [][2]int) []uint32 {
+	var result []uint32
+	var cx, cy int
+
+	for i, p := range points {
+		switch {
+		case i == 0:
+			result = append(result, command(moveTo, 1))
+		case i == 1 && geometryType == lineString:
+			result = append(result, command(lineTo, len(points)-1))
+		}
+		result = append(result, uint32(zigzag(int64(p[0]-cx))), uint32(zigzag(int64(p[1]-cy))))
+		cx, cy = p[0], p[1]
+	}
+
+	return result
+}
+
+func command(id int, count int) uint32 {
+	return uint32(id&0x7 | count<<3)
+}
+
+func zigzag(n int64) uint64 {
+	return uint64((n << 1) ^ (n >> 63))
+}
+
+// Protobuf wire format --------------------------------------------------------------------------
+
+func appendVarint(buffer []byte, value uint64) []byte {
+	for value >= 0x80 {
+		buffer = append(buffer, byte(value)|0x80)
+		value >>= 7
+	}
+	return append(buffer, byte(value))
+}
+
+func appendVarintField(buffer []byte, field int, value uint64) []byte {
+	buffer = appendVarint(buffer, uint64(field<<3))
+	return appendVarint(buffer, value)
+}
+
+func appendBytesField(buffer []byte, field int, value []byte) []byte {
+	buffer = appendVarint(buffer, uint64(field<<3|2))
+	buffer = appendVarint(buffer, uint64(len(value)))
+	return append(buffer, value...)
+}
+
+func appendPacked(buffer []byte, field int, values []uint32) []byte {
+	var packed []byte
+	for _, value := range values {
+		packed = appendVarint(packed, uint64(value))
+	}
+	return appendBytesField(buffer, field, packed)
+}
@@ -0,0 +1,128 @@
+package tiles
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"../geometry"
+)
+
+func TestNewTile(t *testing.T) {
+	var tests = []struct {
+		zoom    int
+		x       int
+		y       int
+		correct bool
+	}{
+		{0, 0, 0, true},
+		{1, 1, 1, true},
+		{1, 2, 0, false}, // only two columns
+		{10, 525, 336, true},
+		{-1, 0, 0, false},
+		{23, 0, 0, false},
+	}
+
+	for _, test := range tests {
+		_, err := NewTile(test.zoom, test.x, test.y)
+		if (test.correct && err != nil) || (!test.correct && err == nil) {
+			t.Errorf("NewTile(%d/%d/%d) expected %t, got %t", test.zoom, test.x, test.y, test.correct, (err == nil))
+		}
+	}
+}
+
+func TestProject(t *testing.T) {
+	var tests = []struct {
+		zoom      int
+		x         int
+		y         int
+		latitude  float64
+		longitude float64
+		px        int
+		py        int
+	}{
+		{0, 0, 0, 0, 0, 2048, 2048},
+		{0, 0, 0, 89, -180, 0, 0}, // beyond the cut off
+		{1, 1, 0, 0, 90, 2048, 4096},
+		{1, 0, 1, 0, 0, 4096, 0},
+	}
+
+	for _, test := range tests {
+		tile, _ := NewTile(test.zoom, test.x, test.y)
+		px, py := tile.Project(test.latitude, test.longitude)
+		if px != test.px || py != test.py {
+			t.Errorf("Project(%d/%d/%d, %f, %f) expected %d,%d, got %d,%d", test.zoom, test.x, test.y,
+				test.latitude, test.longitude, test.px, test.py, px, py)
+		}
+	}
+}
+
+func TestBounds(t *testing.T) {
+	// The tile with EHAM at zoom 10
+	tile, _ := NewTile(10, 525, 336)
+	box := tile.Bounds()
+	if !box.Contains(52.3086, 4.7639) || box.Contains(52.3086, 5.5) {
+		t.Errorf("Bounds expected EHAM only, got %+v", box)
+	}
+}
+
+func TestEncodeGeometry(t *testing.T) {
+	// The examples from the specification
+	var tests = []struct {
+		geometryType int
+		points       [][2]int
+		result       []uint32
+	}{
+		{point, [][2]int{{25, 17}}, []uint32{9, 50, 34}},
+		{lineString, [][2]int{{2, 2}, {2, 10}, {10, 10}}, []uint32{9, 4, 4, 18, 0, 16, 16, 0}},
+	}
+
+	for _, test := range tests {
+		result := encodeGeometry(test.geometryType, test.points)
+		if !reflect.DeepEqual(result, test.result) {
+			t.Errorf("encodeGeometry(%v) expected %v, got %v", test.points, test.result, result)
+		}
+	}
+}
+
+func TestEncode(t *testing.T) {
+	tile, _ := NewTile(10, 525, 336)
+	airports := tile.AddLayer("airports")
+	tile.AddLayer("empty")
+
+	if !airports.AddPoint(52.3086, 4.7639, map[string]interface{}{"icao-airport-code": "EHAM", "large": true}) {
+		t.Errorf("AddPoint expected EHAM inside the tile")
+	}
+	if airports.AddPoint(40.6398, -73.7789, map[string]interface{}{"icao-airport-code": "KJFK"}) {
+		t.Errorf("AddPoint expected KJFK outside the tile")
+	}
+	if airports.AddLine([]geometry.Position{{Latitude: 52.3, Longitude: 4.7}, {Latitude: 52.3, Longitude: 4.7}}, nil) {
+		t.Errorf("AddLine expected a line without length to be skipped")
+	}
+
+	result := tile.Encode()
+	if len(result) == 0 || result[0] != 0x1a {
+		t.Fatalf("Encode expected a layer, got %v", result)
+	}
+	if !bytes.Contains(result, []byte("airports")) || !bytes.Contains(result, []byte("EHAM")) {
+		t.Errorf("Encode expected the airports layer with EHAM")
+	}
+	if bytes.Contains(result, []byte("empty")) || bytes.Contains(result, []byte("KJFK")) {
+		t.Errorf("Encode expected no empty layer and no KJFK")
+	}
+}
+
+func TestZigzag(t *testing.T) {
+	var tests = []struct {
+		value  int64
+		result uint64
+	}{
+		{0, 0}, {-1, 1}, {1, 2}, {-2, 3}, {2147483647, 4294967294}, {-2147483648, 4294967295},
+	}
+
+	for _, test := range tests {
+		if result := zigzag(test.value); result != test.result {
+			t.Errorf("zigzag(%d) expected %d, got %d", test.value, test.result, result)
+		}
+	}
+}
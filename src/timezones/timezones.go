@@ -0,0 +1,164 @@
+package timezones
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/minio-go"
+
+	"../application"
+	"../geometry"
+)
+
+// Timezones resolves the IANA time zone for a position from the time zone boundary polygons,
+// in the GeoJSON format of the timezone-boundary-builder project (a "tzid" property per feature).
+
+// maxNearest is the distance in km within which a position outside all zones, like a coastal
+// airport just beyond the mapped coastline, is given the nearest zone
+const maxNearest = 50.0
+
+// Zone is a single time zone with its area
+type Zone struct {
+	TimeZone string
+	Area     geometry.MultiPolygon
+	Bounds   geometry.Box
+}
+
+// Zones is the set of time zone boundaries
+type Zones struct {
+	zones []*Zone
+}
+
+var locations sync.Map
+
+// LoadZones reads the boundaries from a local file or, with a "s3:" prefix, from an object in
+// the csv bucket
+func LoadZones(application *application.Context, source string) (*Zones, error) {
+	var reader io.ReadCloser
+	var err error
+
+	if strings.HasPrefix(source, "s3:") {
+		reader, err = application.S3Client.GetObject("csv", strings.TrimPrefix(source, "s3:"),
+			minio.GetObjectOptions{})
+	} else {
+		reader, err = os.Open(source)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	zones, err := ReadZones(reader)
+	if err != nil {
+		return nil, fmt.Errorf("LoadZones(%s): %v", source, err)
+	}
+
+	return zones, nil
+}
+
+// ReadZones reads the boundaries from a GeoJSON feature collection
+func ReadZones(reader io.Reader) (*Zones, error) {
+	var collection geometry.FeatureCollection
+
+	err := json.NewDecoder(reader).Decode(&collection)
+	if err != nil {
+		return nil, err
+	}
+
+	var zones Zones
+	for i, feature := range collection.Features {
+		timeZone := feature.Property("tzid")
+		if len(timeZone) == 0 {
+			return nil, fmt.Errorf("Zone[%d]: Missing tzid", i)
+		}
+
+		area, err := feature.Geometry.MultiPolygon()
+		if err != nil {
+			return nil, fmt.Errorf("Zone[%d](%s): %v", i, timeZone, err)
+		}
+
+		zones.zones = append(zones.zones, &Zone{TimeZone: timeZone, Area: area, Bounds: area.Bounds()})
+	}
+
+	return &zones, nil
+}
+
+// Lookup finds the time zone for a position. Outside the boundaries the nearest zone within
+// reach is used, on open sea the nautical zone for the longitude.
+func (zones *Zones) Lookup(latitude float64, longitude float64) string {
+	for _, zone := range zones.zones {
+		if zone.Bounds.Contains(latitude, longitude) && zone.Area.Contains(latitude, longitude) {
+			return zone.TimeZone
+		}
+	}
+
+	var nearest *Zone
+	nearestDistance := maxNearest
+	for _, zone := range zones.zones {
+		if zone.Bounds.Distance(latitude, longitude) > nearestDistance {
+			continue
+		}
+		distance := zone.Area.Distance(latitude, longitude)
+		if distance <= nearestDistance {
+			nearest = zone
+			nearestDistance = distance
+		}
+	}
+	if nearest != nil {
+		return nearest.TimeZone
+	}
+
+	return NauticalZone(longitude)
+}
+
+// NauticalZone is the Etc/GMT zone for a longitude, note that the sign of these zones is
+// inverted: Etc/GMT-5 is five hours ahead of UTC
+func NauticalZone(longitude float64) string {
+	offset := int(math.Round(longitude / 15.0))
+	switch {
+	case offset > 0:
+		return fmt.Sprintf("Etc/GMT-%d", offset)
+	case offset < 0:
+		return fmt.Sprintf("Etc/GMT+%d", -offset)
+	}
+	return "Etc/GMT"
+}
+
+// Location loads a time zone from the zone database of the system, once per zone
+func Location(timeZone string) (*time.Location, error) {
+	if len(timeZone) == 0 {
+		return nil, fmt.Errorf("Unknown time zone")
+	}
+
+	location, ok := locations.Load(timeZone)
+	if ok {
+		return location.(*time.Location), nil
+	}
+
+	result, err := time.LoadLocation(timeZone)
+	if err != nil {
+		return nil, fmt.Errorf("TimeZone(%s): %v", timeZone, err)
+	}
+	locations.Store(timeZone, result)
+
+	return result, nil
+}
+
+// UtcOffset formats the offset of a moment to UTC as +hh:mm
+func UtcOffset(moment time.Time) string {
+	_, offset := moment.Zone()
+
+	sign := '+'
+	if offset < 0 {
+		sign = '-'
+		offset = -offset
+	}
+
+	return fmt.Sprintf("%c%02d:%02d", sign, offset/3600, (offset%3600)/60)
+}
@@ -0,0 +1,134 @@
+package timezones
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Two neighbouring zones, one of them a MultiPolygon with an island
+const boundaries = `{"type": "FeatureCollection", "features": [
+	{"type": "Feature", "properties": {"tzid": "Europe/Amsterdam"},
+	 "geometry": {"type": "Polygon", "coordinates": [[[3, 50], [7, 50], [7, 54], [3, 54], [3, 50]]]}},
+	{"type": "Feature", "properties": {"tzid": "Europe/London"},
+	 "geometry": {"type": "MultiPolygon", "coordinates": [
+		[[[-6, 50], [2, 50], [2, 56], [-6, 56], [-6, 50]]],
+		[[[-7, 49.8], [-6.2, 49.8], [-6.2, 50], [-7, 50], [-7, 49.8]]]]}}
+]}`
+
+func TestLookup(t *testing.T) {
+	zones, err := ReadZones(strings.NewReader(boundaries))
+	if err != nil {
+		t.Fatalf("ReadZones expected success, got %v", err)
+	}
+
+	var tests = []struct {
+		latitude  float64
+		longitude float64
+		timeZone  string
+	}{
+		{52.3086, 4.7639, "Europe/Amsterdam"}, // inside
+		{51.4706, -0.4619, "Europe/London"},   // inside
+		{49.9, -6.5, "Europe/London"},         // on the island
+		{52.0, 2.3, "Europe/London"},          // between the zones, nearest
+		{52.0, 2.8, "Europe/Amsterdam"},       // between the zones, nearest
+		{45.0, -30.0, "Etc/GMT+2"},            // open sea
+		{0.0, 0.0, "Etc/GMT"},                 // open sea
+	}
+
+	for _, test := range tests {
+		result := zones.Lookup(test.latitude, test.longitude)
+		if result != test.timeZone {
+			t.Errorf("Lookup(%f, %f) expected %s, got %s", test.latitude, test.longitude, test.timeZone, result)
+		}
+	}
+}
+
+// TestBoundaryAirports checks the zones of airports on either side of the borders between zones
+// against the boundaries of the timezone-boundary-builder project, which are not part of the
+// repository. It runs with TIMEZONE_BOUNDARIES set to the file of the boundaries.
+func TestBoundaryAirports(t *testing.T) {
+	fileName := os.Getenv("TIMEZONE_BOUNDARIES")
+	if len(fileName) == 0 {
+		t.Skip("TIMEZONE_BOUNDARIES names no file of time zone boundaries")
+	}
+	zones, err := LoadZones(nil, fileName)
+	if err != nil {
+		t.Fatalf("LoadZones(%s) expected success, got %v", fileName, err)
+	}
+
+	var tests = []struct {
+		airportCode string
+		latitude    float64
+		longitude   float64
+		timeZone    string
+	}{
+		{"KSEA", 47.4502, -122.3088, "America/Los_Angeles"},
+		{"KLAX", 33.9425, -118.4081, "America/Los_Angeles"},
+		{"KSLC", 40.7884, -111.9778, "America/Denver"},
+		{"KDEN", 39.8617, -104.6731, "America/Denver"},
+		{"KAMA", 35.2194, -101.7059, "America/Chicago"},
+		{"KOKC", 35.3931, -97.6007, "America/Chicago"},
+		{"KDFW", 32.8998, -97.0403, "America/Chicago"},
+		{"KAUS", 30.1945, -97.6699, "America/Chicago"},
+		{"KIAH", 29.9844, -95.3414, "America/Chicago"},
+		{"KMCI", 39.2976, -94.7139, "America/Chicago"},
+		{"KMSP", 44.8848, -93.2223, "America/Chicago"},
+		{"KMSY", 29.9934, -90.2580, "America/Chicago"},
+		{"KJFK", 40.6398, -73.7789, "America/New_York"},
+		{"EHAM", 52.3086, 4.7639, "Europe/Amsterdam"},
+		{"VABB", 19.0887, 72.8679, "Asia/Kolkata"},
+		{"ZBAA", 40.0801, 116.5846, "Asia/Shanghai"},
+	}
+
+	for _, test := range tests {
+		result := zones.Lookup(test.latitude, test.longitude)
+		if result != test.timeZone {
+			t.Errorf("Lookup(%s) expected %s, got %s", test.airportCode, test.timeZone, result)
+		}
+	}
+}
+
+func TestReadZones(t *testing.T) {
+	var tests = []struct {
+		value   string
+		correct bool
+	}{
+		{boundaries, true},
+		{`{"type": "FeatureCollection", "features": []}`, true},
+		{`{"type": "FeatureCollection", "features": [{"type": "Feature", "properties": {},
+			"geometry": {"type": "Polygon", "coordinates": [[[0, 0], [1, 0], [1, 1], [0, 0]]]}}]}`, false}, // no tzid
+		{`{"type": "FeatureCollection", "features": [{"type": "Feature", "properties": {"tzid": "UTC"},
+			"geometry": {"type": "Point", "coordinates": [0, 0]}}]}`, false}, // no area
+		{`{"type": "FeatureCollection"`, false}, // broken
+	}
+
+	for _, test := range tests {
+		_, err := ReadZones(strings.NewReader(test.value))
+		if (test.correct && err != nil) || (!test.correct && err == nil) {
+			t.Errorf("ReadZones(%.40s) expected %t, got %t (%v)", test.value, test.correct, (err == nil), err)
+		}
+	}
+}
+
+func TestUtcOffset(t *testing.T) {
+	var tests = []struct {
+		offset int
+		result string
+	}{
+		{0, "+00:00"},
+		{3600, "+01:00"},
+		{-5 * 3600, "-05:00"},
+		{5*3600 + 1800, "+05:30"},
+		{-(9*3600 + 1800), "-09:30"},
+	}
+
+	for _, test := range tests {
+		moment := time.Date(2020, time.March, 14, 12, 0, 0, 0, time.FixedZone("", test.offset))
+		result := UtcOffset(moment)
+		if result != test.result {
+			t.Errorf("UtcOffset(%d) expected %s, got %s", test.offset, test.result, result)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package versions
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"../application"
+	"../failures"
+)
+
+// Versions keeps a version number per dataset that goes up with every import, so that anything
+// derived from a dataset (tiles, cached responses) can tell whether it is still current.
+
+// The datasets
+const (
+	Countries  = "countries"
+	Airports   = "airports"
+	Boundaries = "boundaries"
+	Weather    = "weather"
+)
+
+// Versions is the representation of the versions collection in the database
+type Versions struct {
+	context    *application.Context
+	collection *mongo.Collection
+}
+
+// Version is the current version of a dataset
+type Version struct {
+	Dataset string    `bson:"dataset" json:"dataset"`
+	Version int64     `bson:"version" json:"version"`
+	Updated time.Time `bson:"updated" json:"updated"`
+}
+
+// NewVersions sets up the connection to the database
+func NewVersions(application *application.Context) *Versions {
+	versions := Versions{context: application}
+
+	versions.collection = application.DBClient.Database("flight-schedule").Collection("versions")
+	versionIndex := mongo.IndexModel{Keys: bson.M{"dataset": 1}}
+	versions.collection.Indexes().CreateOne(application.DBContext, versionIndex)
+
+	return &versions
+}
+
+// Get retrieves the version of a dataset, a dataset that was never imported has version 0
+func (versions *Versions) Get(dataset string) (*Version, error) {
+	var result Version
+
+	err := versions.collection.FindOne(versions.context.DBContext,
+		bson.D{{Key: "dataset", Value: dataset}}).Decode(&result)
+
+	if err == mongo.ErrNoDocuments {
+		return &Version{Dataset: dataset}, nil
+	}
+	if err != nil {
+		return nil, failures.New(failures.Unavailable, "Version(%s): %v", dataset, err)
+	}
+
+	return &result, nil
+}
+
+// Bump raises the version of a dataset after an import
+func (versions *Versions) Bump(dataset string) error {
+	_, err := versions.collection.UpdateOne(versions.context.DBContext,
+		bson.D{{Key: "dataset", Value: dataset}},
+		bson.M{
+			"$inc": bson.M{"version": 1},
+			"$set": bson.M{"updated": time.Now().UTC()}},
+		options.Update().SetUpsert(true))
+
+	if err != nil {
+		return fmt.Errorf("Version(%s): %v", dataset, err)
+	}
+
+	return nil
+}
+
+// Cache keeps the versions read for a while, so that not every request that depends on a
+// dataset reads its version
+type Cache struct {
+	get     func(dataset string) (*Version, error)
+	timeout time.Duration
+	mutex   sync.Mutex
+	entries map[string]*cacheEntry
+}
+
+type cacheEntry struct {
+	version *Version
+	read    time.Time
+}
+
+// NewCache keeps the versions that get reads for the timeout
+func NewCache(get func(dataset string) (*Version, error), timeout time.Duration) *Cache {
+	return &Cache{get: get, timeout: timeout, entries: map[string]*cacheEntry{}}
+}
+
+// Get retrieves the version of a dataset, from the cache while it is recent
+func (cache *Cache) Get(dataset string) (*Version, error) {
+	now := time.Now()
+
+	cache.mutex.Lock()
+	entry, ok := cache.entries[dataset]
+	cache.mutex.Unlock()
+	if ok && now.Sub(entry.read) < cache.timeout {
+		return entry.version, nil
+	}
+
+	version, err := cache.get(dataset)
+	if err != nil {
+		return nil, err
+	}
+
+	cache.mutex.Lock()
+	cache.entries[dataset] = &cacheEntry{version: version, read: now}
+	cache.mutex.Unlock()
+
+	return version, nil
+}
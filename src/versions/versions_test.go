@@ -0,0 +1,46 @@
+package versions
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	reads := 0
+	cache := NewCache(func(dataset string) (*Version, error) {
+		reads++
+		if dataset == "broken" {
+			return nil, fmt.Errorf("Unavailable")
+		}
+		return &Version{Dataset: dataset, Version: int64(reads)}, nil
+	}, time.Minute)
+
+	first, err := cache.Get(Airports)
+	if err != nil || first.Version != 1 {
+		t.Fatalf("Get expected version 1, got %v (%v)", first, err)
+	}
+	second, _ := cache.Get(Airports)
+	if second.Version != 1 || reads != 1 {
+		t.Errorf("Get expected the cached version, got %d after %d reads", second.Version, reads)
+	}
+
+	_, err = cache.Get("broken")
+	if err == nil {
+		t.Errorf("Get expected the error of the read")
+	}
+	_, err = cache.Get("broken")
+	if err == nil || reads != 3 {
+		t.Errorf("Get expected errors not to be cached, got %d reads", reads)
+	}
+
+	expired := NewCache(func(dataset string) (*Version, error) {
+		reads++
+		return &Version{Dataset: dataset, Version: int64(reads)}, nil
+	}, 0)
+	a, _ := expired.Get(Countries)
+	b, _ := expired.Get(Countries)
+	if a.Version == b.Version {
+		t.Errorf("Get expected a read after the timeout")
+	}
+}
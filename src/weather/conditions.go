@@ -0,0 +1,223 @@
+package weather
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Conditions implements the part of the weather that METAR reports and TAF forecasts have in
+// common: wind, visibility, weather phenomena and clouds.
+
+// Flight categories as defined by the FAA
+const (
+	VFR  = "VFR"
+	MVFR = "MVFR"
+	IFR  = "IFR"
+	LIFR = "LIFR"
+)
+
+// Wind expresses the surface wind; direction in degrees true, speeds in knots
+type Wind struct {
+	Direction    int  `bson:"direction" json:"direction"`
+	Variable     bool `bson:"variable" json:"variable,omitempty"`
+	VariableFrom int  `bson:"variable-from" json:"variable-from,omitempty"`
+	VariableTo   int  `bson:"variable-to" json:"variable-to,omitempty"`
+	Speed        int  `bson:"speed" json:"speed"`
+	Gust         int  `bson:"gust" json:"gust,omitempty"`
+}
+
+// Cloud is a single cloud layer, the base is in feet above the aerodrome
+type Cloud struct {
+	Cover string `bson:"cover" json:"cover"`
+	Base  int    `bson:"base" json:"base"`
+	Type  string `bson:"type" json:"type,omitempty"`
+}
+
+// Conditions is the observed or forecasted weather. Visibility is in meters and the ceiling
+// in feet; both are absent when not reported.
+type Conditions struct {
+	Wind           *Wind    `bson:"wind" json:"wind,omitempty"`
+	Visibility     *int     `bson:"visibility" json:"visibility,omitempty"`
+	CAVOK          bool     `bson:"cavok" json:"cavok,omitempty"`
+	Weather        []string `bson:"weather" json:"weather,omitempty"`
+	Clouds         []*Cloud `bson:"clouds" json:"clouds,omitempty"`
+	Ceiling        *int     `bson:"ceiling" json:"ceiling,omitempty"`
+	FlightCategory string   `bson:"flight-category" json:"flight-category,omitempty"`
+}
+
+const metersPerStatuteMile = 1609.344
+
+var (
+	windPattern       = regexp.MustCompile(`^(\d{3}|VRB)(\d{2,3})(?:G(\d{2,3}))?(KT|MPS|KMH)$`)
+	variablePattern   = regexp.MustCompile(`^(\d{3})V(\d{3})$`)
+	metersPattern     = regexp.MustCompile(`^(\d{4})(?:NDV)?$`)
+	milesPattern      = regexp.MustCompile(`^([MP])?(\d+)?(?:(\d)/(\d{1,2}))?SM$`)
+	wholeMilesPattern = regexp.MustCompile(`^\d$`)
+	cloudPattern      = regexp.MustCompile(`^(FEW|SCT|BKN|OVC|VV)(\d{3}|///)(CB|TCU|///)?$`)
+	clearPattern      = regexp.MustCompile(`^(SKC|CLR|NSC|NCD)$`)
+	weatherPattern    = regexp.MustCompile(`^(\+|-|VC)?(MI|PR|BC|DR|BL|SH|TS|FZ)?(DZ|RA|SN|SG|IC|PL|GR|GS|UP|BR|FG|FU|VA|DU|SA|HZ|PY|PO|SQ|FC|SS|DS)*$`)
+)
+
+// parseToken tries to interpret the token at position i, it returns the number of tokens
+// consumed (zero when the token doesn't describe conditions)
+func (conditions *Conditions) parseToken(tokens []string, i int) int {
+	token := tokens[i]
+
+	if match := windPattern.FindStringSubmatch(token); match != nil {
+		wind := Wind{}
+		if match[1] == "VRB" {
+			wind.Variable = true
+		} else {
+			wind.Direction, _ = strconv.Atoi(match[1])
+		}
+		wind.Speed = windSpeed(match[2], match[4])
+		if len(match[3]) > 0 {
+			wind.Gust = windSpeed(match[3], match[4])
+		}
+		conditions.Wind = &wind
+		return 1
+	}
+
+	if match := variablePattern.FindStringSubmatch(token); match != nil && conditions.Wind != nil {
+		conditions.Wind.VariableFrom, _ = strconv.Atoi(match[1])
+		conditions.Wind.VariableTo, _ = strconv.Atoi(match[2])
+		return 1
+	}
+
+	if token == "CAVOK" {
+		visibility := 10000
+		conditions.CAVOK = true
+		conditions.Visibility = &visibility
+		return 1
+	}
+
+	if match := metersPattern.FindStringSubmatch(token); match != nil {
+		visibility, _ := strconv.Atoi(match[1])
+		if visibility == 9999 {
+			visibility = 10000
+		}
+		conditions.Visibility = &visibility
+		return 1
+	}
+
+	// Statute miles may be split over two tokens, as in "1 1/2SM"
+	if wholeMilesPattern.MatchString(token) && i+1 < len(tokens) {
+		if match := milesPattern.FindStringSubmatch(tokens[i+1]); match != nil && len(match[2]) == 0 && len(match[3]) > 0 {
+			whole, _ := strconv.ParseFloat(token, 64)
+			conditions.setMiles(whole, match)
+			return 2
+		}
+	}
+
+	if match := milesPattern.FindStringSubmatch(token); match != nil && (len(match[2]) > 0 || len(match[3]) > 0) {
+		whole := 0.0
+		if len(match[2]) > 0 {
+			whole, _ = strconv.ParseFloat(match[2], 64)
+		}
+		conditions.setMiles(whole, match)
+		return 1
+	}
+
+	if match := cloudPattern.FindStringSubmatch(token); match != nil {
+		cloud := Cloud{Cover: match[1]}
+		if match[2] != "///" {
+			base, _ := strconv.Atoi(match[2])
+			cloud.Base = base * 100
+		}
+		if match[3] != "///" {
+			cloud.Type = match[3]
+		}
+		conditions.Clouds = append(conditions.Clouds, &cloud)
+		return 1
+	}
+
+	if clearPattern.MatchString(token) || token == "NSW" {
+		return 1
+	}
+
+	if match := weatherPattern.FindStringSubmatch(token); match != nil && len(match[2])+len(match[3]) > 0 {
+		conditions.Weather = append(conditions.Weather, token)
+		return 1
+	}
+
+	return 0
+}
+
+// setMiles converts a visibility in statute miles to meters
+func (conditions *Conditions) setMiles(whole float64, match []string) {
+	miles := whole
+	if len(match[3]) > 0 {
+		numerator, _ := strconv.ParseFloat(match[3], 64)
+		denominator, _ := strconv.ParseFloat(match[4], 64)
+		if denominator > 0 {
+			miles += numerator / denominator
+		}
+	}
+
+	visibility := int(math.Round(miles * metersPerStatuteMile))
+	conditions.Visibility = &visibility
+}
+
+// windSpeed converts a reported wind speed to knots
+func windSpeed(value string, unit string) int {
+	speed, _ := strconv.ParseFloat(value, 64)
+
+	switch unit {
+	case "MPS":
+		speed = speed * 1.943844
+	case "KMH":
+		speed = speed / 1.852
+	}
+
+	return int(math.Round(speed))
+}
+
+// complete derives the ceiling and the flight category from what has been parsed
+func (conditions *Conditions) complete() {
+	conditions.Ceiling = nil
+	for _, cloud := range conditions.Clouds {
+		if cloud.Cover == "BKN" || cloud.Cover == "OVC" || cloud.Cover == "VV" {
+			if conditions.Ceiling == nil || cloud.Base < *conditions.Ceiling {
+				base := cloud.Base
+				conditions.Ceiling = &base
+			}
+		}
+	}
+
+	conditions.FlightCategory = conditions.flightCategory()
+}
+
+// flightCategory applies the FAA categories to the ceiling and visibility, it has nothing to
+// say when neither is known
+func (conditions *Conditions) flightCategory() string {
+	if conditions.Visibility == nil && conditions.Ceiling == nil && len(conditions.Clouds) == 0 {
+		return ""
+	}
+
+	visibility := math.MaxInt32
+	if conditions.Visibility != nil {
+		visibility = *conditions.Visibility
+	}
+	ceiling := math.MaxInt32
+	if conditions.Ceiling != nil {
+		ceiling = *conditions.Ceiling
+	}
+
+	switch {
+	case ceiling < 500 || float64(visibility) < 1*metersPerStatuteMile:
+		return LIFR
+	case ceiling < 1000 || float64(visibility) < 3*metersPerStatuteMile:
+		return IFR
+	case ceiling <= 3000 || float64(visibility) <= 5*metersPerStatuteMile:
+		return MVFR
+	}
+
+	return VFR
+}
+
+// tokenize splits a report into its groups, dropping the optional '=' terminator
+func tokenize(text string) []string {
+	return strings.Fields(strings.TrimRight(strings.TrimSpace(text), "="))
+}
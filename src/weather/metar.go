@@ -0,0 +1,176 @@
+package weather
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"time"
+
+	"../datatypes"
+)
+
+// Metar is a decoded METAR (or SPECI) observation. Temperatures are in degrees Celsius and
+// the QNH in hectopascal.
+type Metar struct {
+	AirportCode string    `bson:"icao-airport-code" json:"icao-airport-code"`
+	Observed    time.Time `bson:"observed" json:"observed"`
+	Raw         string    `bson:"raw" json:"raw"`
+	Auto        bool      `bson:"auto" json:"auto,omitempty"`
+	Conditions  `bson:",inline"`
+	Temperature *int `bson:"temperature" json:"temperature,omitempty"`
+	Dewpoint    *int `bson:"dewpoint" json:"dewpoint,omitempty"`
+	QNH         *int `bson:"qnh" json:"qnh,omitempty"`
+}
+
+var (
+	dayTimePattern     = regexp.MustCompile(`^(\d{2})(\d{2})(\d{2})Z$`)
+	temperaturePattern = regexp.MustCompile(`^(M?\d{2})/(M?\d{2})?$`)
+	pressurePattern    = regexp.MustCompile(`^([QA])(\d{4})$`)
+)
+
+// ParseMetar decodes a METAR report. The report only carries day and time, the reference
+// (usually the moment of ingestion) supplies the month and year.
+func ParseMetar(text string, reference time.Time) (*Metar, error) {
+	tokens := tokenize(text)
+	i := 0
+
+	if i < len(tokens) && (tokens[i] == "METAR" || tokens[i] == "SPECI") {
+		i++
+	}
+	if i < len(tokens) && tokens[i] == "COR" {
+		i++
+	}
+
+	// Station
+	if i >= len(tokens) {
+		return nil, fmt.Errorf("Metar: Missing station")
+	}
+	airportCode, err := datatypes.ICAOAirportCode(tokens[i], false, false)
+	if err != nil || len(airportCode) != 4 {
+		return nil, fmt.Errorf("Metar.Station(%s): Invalid ICAO Airport Code", tokens[i])
+	}
+	i++
+
+	// Observation time
+	if i >= len(tokens) {
+		return nil, fmt.Errorf("Metar(%s): Missing observation time", airportCode)
+	}
+	observed, err := parseDayTime(tokens[i], reference)
+	if err != nil {
+		return nil, fmt.Errorf("Metar(%s).Observed(%s): %v", airportCode, tokens[i], err)
+	}
+	i++
+
+	metar := Metar{
+		AirportCode: airportCode,
+		Observed:    observed,
+		Raw:         text}
+
+	for ; i < len(tokens); i++ {
+		token := tokens[i]
+
+		// Remarks and trends are not part of the observation
+		if token == "RMK" || token == "NOSIG" || token == "BECMG" || token == "TEMPO" {
+			break
+		}
+
+		switch token {
+		case "NIL":
+			return nil, fmt.Errorf("Metar(%s): NIL report", airportCode)
+		case "AUTO":
+			metar.Auto = true
+			continue
+		case "COR":
+			continue
+		}
+
+		if consumed := metar.Conditions.parseToken(tokens, i); consumed > 0 {
+			i += consumed - 1
+			continue
+		}
+
+		if match := temperaturePattern.FindStringSubmatch(token); match != nil {
+			temperature := parseTemperature(match[1])
+			metar.Temperature = &temperature
+			if len(match[2]) > 0 {
+				dewpoint := parseTemperature(match[2])
+				metar.Dewpoint = &dewpoint
+			}
+			continue
+		}
+
+		if match := pressurePattern.FindStringSubmatch(token); match != nil {
+			value, _ := strconv.Atoi(match[2])
+			qnh := value
+			if match[1] == "A" {
+				qnh = int(math.Round(float64(value) / 100.0 * 33.8639))
+			}
+			metar.QNH = &qnh
+			continue
+		}
+
+		// Anything else (runway visual range, recent weather, windshear) is ignored
+	}
+
+	metar.Conditions.complete()
+
+	return &metar, nil
+}
+
+// parseTemperature reads a temperature where a leading M means minus
+func parseTemperature(s string) int {
+	if s[0] == 'M' {
+		value, _ := strconv.Atoi(s[1:])
+		return -value
+	}
+	value, _ := strconv.Atoi(s)
+	return value
+}
+
+// parseDayTime reads a DDHHMMZ group
+func parseDayTime(s string, reference time.Time) (time.Time, error) {
+	match := dayTimePattern.FindStringSubmatch(s)
+	if match == nil {
+		return time.Time{}, fmt.Errorf("Invalid day/time")
+	}
+
+	day, _ := strconv.Atoi(match[1])
+	hour, _ := strconv.Atoi(match[2])
+	minute, _ := strconv.Atoi(match[3])
+	if hour > 24 || minute > 59 {
+		return time.Time{}, fmt.Errorf("Invalid day/time")
+	}
+
+	date, err := resolveDay(day, reference)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return date.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute), nil
+}
+
+// resolveDay finds the latest date with the given day of month that isn't more than two days
+// past the reference: reports are never made for the distant future, so anything beyond that
+// belongs to the previous month.
+func resolveDay(day int, reference time.Time) (time.Time, error) {
+	var result time.Time
+
+	reference = reference.UTC()
+	limit := reference.AddDate(0, 0, 2)
+	found := false
+	for _, offset := range []int{-1, 0, 1} {
+		date := time.Date(reference.Year(), reference.Month()+time.Month(offset), day, 0, 0, 0, 0, time.UTC)
+		if date.Day() != day || date.After(limit) {
+			continue
+		}
+		result = date
+		found = true
+	}
+
+	if !found {
+		return time.Time{}, fmt.Errorf("Invalid day")
+	}
+
+	return result, nil
+}
@@ -0,0 +1,184 @@
+package weather
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"../datatypes"
+)
+
+// TafPeriod is a single part of a forecast. The base forecast has no change indicator, the
+// others are FM, BECMG, TEMPO, PROB30, PROB40 or a PROB combined with TEMPO.
+type TafPeriod struct {
+	Change     string    `bson:"change" json:"change,omitempty"`
+	From       time.Time `bson:"from" json:"from"`
+	Until      time.Time `bson:"until" json:"until"`
+	Conditions `bson:",inline"`
+}
+
+// Taf is a decoded TAF forecast
+type Taf struct {
+	AirportCode string       `bson:"icao-airport-code" json:"icao-airport-code"`
+	Issued      time.Time    `bson:"issued" json:"issued"`
+	ValidFrom   time.Time    `bson:"valid-from" json:"valid-from"`
+	ValidUntil  time.Time    `bson:"valid-until" json:"valid-until"`
+	Raw         string       `bson:"raw" json:"raw"`
+	Periods     []*TafPeriod `bson:"periods" json:"periods"`
+}
+
+var (
+	validityPattern = regexp.MustCompile(`^(\d{2})(\d{2})/(\d{2})(\d{2})$`)
+	fromPattern     = regexp.MustCompile(`^FM(\d{2})(\d{2})(\d{2})$`)
+	probPattern     = regexp.MustCompile(`^PROB(30|40)$`)
+)
+
+// ParseTaf decodes a TAF forecast. Like a METAR it only carries days and times, the reference
+// supplies month and year.
+func ParseTaf(text string, reference time.Time) (*Taf, error) {
+	tokens := tokenize(text)
+	i := 0
+
+	if i < len(tokens) && tokens[i] == "TAF" {
+		i++
+	}
+	for i < len(tokens) && (tokens[i] == "AMD" || tokens[i] == "COR") {
+		i++
+	}
+
+	// Station
+	if i >= len(tokens) {
+		return nil, fmt.Errorf("Taf: Missing station")
+	}
+	airportCode, err := datatypes.ICAOAirportCode(tokens[i], false, false)
+	if err != nil || len(airportCode) != 4 {
+		return nil, fmt.Errorf("Taf.Station(%s): Invalid ICAO Airport Code", tokens[i])
+	}
+	i++
+
+	// Issue time
+	if i >= len(tokens) {
+		return nil, fmt.Errorf("Taf(%s): Missing issue time", airportCode)
+	}
+	issued, err := parseDayTime(tokens[i], reference)
+	if err != nil {
+		return nil, fmt.Errorf("Taf(%s).Issued(%s): %v", airportCode, tokens[i], err)
+	}
+	i++
+
+	// Validity
+	if i >= len(tokens) {
+		return nil, fmt.Errorf("Taf(%s): Missing validity", airportCode)
+	}
+	validFrom, validUntil, err := parseValidity(tokens[i], issued)
+	if err != nil {
+		return nil, fmt.Errorf("Taf(%s).Validity(%s): %v", airportCode, tokens[i], err)
+	}
+	i++
+
+	if i < len(tokens) && (tokens[i] == "NIL" || tokens[i] == "CNL") {
+		return nil, fmt.Errorf("Taf(%s): %s forecast", airportCode, tokens[i])
+	}
+
+	taf := Taf{
+		AirportCode: airportCode,
+		Issued:      issued,
+		ValidFrom:   validFrom,
+		ValidUntil:  validUntil,
+		Raw:         text}
+
+	period := &TafPeriod{From: validFrom, Until: validUntil}
+	taf.Periods = append(taf.Periods, period)
+
+	for ; i < len(tokens); i++ {
+		token := tokens[i]
+
+		if token == "RMK" {
+			break
+		}
+
+		// A change group starts a new period
+		if match := fromPattern.FindStringSubmatch(token); match != nil {
+			from, err := parseDayTime(match[1]+match[2]+match[3]+"Z", issued)
+			if err != nil {
+				return nil, fmt.Errorf("Taf(%s).From(%s): %v", airportCode, token, err)
+			}
+			period = &TafPeriod{Change: "FM", From: from, Until: validUntil}
+			taf.Periods = append(taf.Periods, period)
+			continue
+		}
+
+		change := ""
+		if token == "BECMG" || token == "TEMPO" {
+			change = token
+		} else if probPattern.MatchString(token) {
+			change = token
+			if i+1 < len(tokens) && tokens[i+1] == "TEMPO" {
+				change = token + " TEMPO"
+				i++
+			}
+		}
+		if len(change) > 0 {
+			if i+1 >= len(tokens) {
+				return nil, fmt.Errorf("Taf(%s).%s: Missing validity", airportCode, change)
+			}
+			from, until, err := parseValidity(tokens[i+1], issued)
+			if err != nil {
+				return nil, fmt.Errorf("Taf(%s).%s(%s): %v", airportCode, change, tokens[i+1], err)
+			}
+			i++
+			period = &TafPeriod{Change: change, From: from, Until: until}
+			taf.Periods = append(taf.Periods, period)
+			continue
+		}
+
+		if consumed := period.Conditions.parseToken(tokens, i); consumed > 0 {
+			i += consumed - 1
+		}
+
+		// Anything else (temperature forecasts, icing, turbulence) is ignored
+	}
+
+	// A base or FM period lasts until the next FM period
+	var last *TafPeriod
+	for _, period := range taf.Periods {
+		if period.Change == "" || period.Change == "FM" {
+			if last != nil {
+				last.Until = period.From
+			}
+			last = period
+		}
+		period.Conditions.complete()
+	}
+
+	return &taf, nil
+}
+
+// parseValidity reads a DDHH/DDHH group
+func parseValidity(s string, reference time.Time) (time.Time, time.Time, error) {
+	match := validityPattern.FindStringSubmatch(s)
+	if match == nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("Invalid validity")
+	}
+
+	var result [2]time.Time
+	for i := 0; i < 2; i++ {
+		day, _ := strconv.Atoi(match[1+2*i])
+		hour, _ := strconv.Atoi(match[2+2*i])
+		if hour > 24 {
+			return time.Time{}, time.Time{}, fmt.Errorf("Invalid validity")
+		}
+		date, err := resolveDay(day, reference)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		result[i] = date.Add(time.Duration(hour) * time.Hour)
+	}
+
+	if result[1].Before(result[0]) {
+		return time.Time{}, time.Time{}, fmt.Errorf("Invalid validity")
+	}
+
+	return result[0], result[1], nil
+}
@@ -0,0 +1,313 @@
+package weather
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"../application"
+	"../datatypes"
+	"../failures"
+)
+
+// Weather implements the storage of METAR and TAF reports per airport
+
+// Report types
+const (
+	METAR = "METAR"
+	TAF   = "TAF"
+)
+
+// Reports is the representation of the collection of weather reports in the database
+type Reports struct {
+	context    *application.Context
+	collection *mongo.Collection
+}
+
+// Report is a single stored METAR or TAF, only one of the two is filled
+type Report struct {
+	Report      primitive.ObjectID `bson:"_id" json:"-"`
+	AirportCode string             `bson:"icao-airport-code" json:"icao-airport-code"`
+	ReportType  string             `bson:"report-type" json:"report-type"`
+	Observed    time.Time          `bson:"observed" json:"observed"`
+	Metar       *Metar             `bson:"metar,omitempty" json:"metar,omitempty"`
+	Taf         *Taf               `bson:"taf,omitempty" json:"taf,omitempty"`
+}
+
+var datePattern = regexp.MustCompile(`^\d{4}/\d{2}/\d{2} \d{2}:\d{2}$`)
+
+// NewReports sets up the connection to the database
+func NewReports(application *application.Context) *Reports {
+	reports := Reports{context: application}
+
+	reports.collection = application.DBClient.Database("flight-schedule").Collection("weather")
+	reportIndex := mongo.IndexModel{Keys: bson.D{
+		{Key: "icao-airport-code", Value: 1},
+		{Key: "report-type", Value: 1},
+		{Key: "observed", Value: -1}}}
+	reports.collection.Indexes().CreateOne(application.DBContext, reportIndex)
+
+	return &reports
+}
+
+// GetLatest retrieves the most recent report of the given type for an airport
+func (reports *Reports) GetLatest(airportCode string, reportType string) (*Report, error) {
+	var result Report
+
+	parameter, err := datatypes.ICAOAirportCode(airportCode, false, false)
+	if err != nil {
+		return nil, failures.New(failures.InvalidArgument, "GetLatest.AirportCode(%s): %v", airportCode, err)
+	}
+
+	findOptions := options.FindOne()
+	findOptions.SetSort(bson.D{{Key: "observed", Value: -1}})
+
+	err = reports.collection.FindOne(reports.context.DBContext,
+		bson.D{
+			{Key: "icao-airport-code", Value: parameter},
+			{Key: "report-type", Value: reportType}},
+		findOptions).Decode(&result)
+
+	if err != nil {
+		return nil, failures.Database(err)
+	}
+
+	return &result, nil
+}
+
+// GetHistory retrieves the reports for an airport observed since the given time, newest first
+func (reports *Reports) GetHistory(airportCode string, since time.Time) ([]*Report, error) {
+	var result []*Report
+
+	parameter, err := datatypes.ICAOAirportCode(airportCode, false, false)
+	if err != nil {
+		return nil, failures.New(failures.InvalidArgument, "GetHistory.AirportCode(%s): %v", airportCode, err)
+	}
+
+	findOptions := options.Find()
+	findOptions.SetSort(bson.D{{Key: "observed", Value: -1}})
+	findOptions.SetLimit(reports.context.MaxResults + 1)
+
+	cur, err := reports.collection.Find(reports.context.DBContext,
+		bson.D{
+			{Key: "icao-airport-code", Value: parameter},
+			{Key: "observed", Value: bson.D{{Key: "$gte", Value: since}}}},
+		findOptions)
+	if err != nil {
+		return nil, failures.Database(err)
+	}
+
+	defer cur.Close(reports.context.DBContext)
+
+	for cur.Next(reports.context.DBContext) {
+		var report Report
+		err = cur.Decode(&report)
+		if err != nil {
+			return nil, failures.Database(err)
+		}
+		result = append(result, &report)
+	}
+
+	if cur.Err() != nil {
+		return nil, failures.Database(cur.Err())
+	}
+
+	if int64(len(result)) > reports.context.MaxResults {
+		return nil, failures.New(failures.TooLarge, "Too many results")
+	}
+
+	if len(result) == 0 {
+		return nil, failures.New(failures.NotFound, "Not found")
+	}
+
+	return result, nil
+}
+
+// Store saves a report, replacing an earlier copy of the same report
+func (reports *Reports) Store(report *Report) error {
+
+	// The insert type ommits the ID to prevent race conditions in upserting
+	type insertReport struct {
+		AirportCode string    `bson:"icao-airport-code"`
+		ReportType  string    `bson:"report-type"`
+		Observed    time.Time `bson:"observed"`
+		Metar       *Metar    `bson:"metar,omitempty"`
+		Taf         *Taf      `bson:"taf,omitempty"`
+	}
+
+	insert := insertReport{
+		AirportCode: report.AirportCode,
+		ReportType:  report.ReportType,
+		Observed:    report.Observed,
+		Metar:       report.Metar,
+		Taf:         report.Taf}
+
+	_, err := reports.collection.UpdateOne(reports.context.DBContext,
+		bson.D{
+			{Key: "icao-airport-code", Value: insert.AirportCode},
+			{Key: "report-type", Value: insert.ReportType},
+			{Key: "observed", Value: insert.Observed}},
+		bson.M{"$set": insert},
+		options.Update().SetUpsert(true))
+
+	return err
+}
+
+// Purge removes the reports that have fallen out of the history window
+func (reports *Reports) Purge() (int64, error) {
+	window := time.Duration(reports.context.WeatherHistory) * time.Hour
+	if window <= 0 {
+		return 0, nil
+	}
+
+	result, err := reports.collection.DeleteMany(reports.context.DBContext,
+		bson.D{{Key: "observed", Value: bson.D{{Key: "$lt", Value: time.Now().UTC().Add(-window)}}}})
+	if err != nil {
+		return 0, err
+	}
+
+	return result.DeletedCount, nil
+}
+
+// ParseReport decodes a single METAR or TAF, deciding on the type from its content
+func ParseReport(text string, reference time.Time) (*Report, error) {
+	tokens := tokenize(text)
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("Empty report")
+	}
+
+	if tokens[0] == TAF || isTaf(tokens) {
+		taf, err := ParseTaf(text, reference)
+		if err != nil {
+			return nil, err
+		}
+		return &Report{AirportCode: taf.AirportCode, ReportType: TAF, Observed: taf.Issued, Taf: taf}, nil
+	}
+
+	metar, err := ParseMetar(text, reference)
+	if err != nil {
+		return nil, err
+	}
+	return &Report{AirportCode: metar.AirportCode, ReportType: METAR, Observed: metar.Observed, Metar: metar}, nil
+}
+
+// isTaf recognizes a TAF without the TAF prefix by the validity group after the issue time
+func isTaf(tokens []string) bool {
+	i := 0
+	for i < len(tokens) && (tokens[i] == "AMD" || tokens[i] == "COR") {
+		i++
+	}
+	return i+2 < len(tokens) && validityPattern.MatchString(tokens[i+2])
+}
+
+// ImportFile imports the reports in a local text file
+func (reports *Reports) ImportFile(fileName string) error {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return reports.importText(file)
+}
+
+// ImportObject imports the reports in an object from the csv bucket
+func (reports *Reports) ImportObject(objectName string) error {
+	s3Client := reports.context.S3Client
+	object, err := s3Client.GetObject("csv", objectName,
+		minio.GetObjectOptions{})
+	if err != nil {
+		return err
+	}
+	defer object.Close()
+
+	return reports.importText(object)
+}
+
+// importText reads reports from a feed. Reports end with a '=' or a blank line, lines that start
+// with whitespace continue the previous report and date lines ("2020/03/14 12:00", as in the
+// NOAA cycle files) set the reference for the reports that follow.
+func (reports *Reports) importText(reader io.Reader) error {
+	// Open the logfile
+	_, err := reports.context.LogFile("weather")
+	if err != nil {
+		return err
+	}
+	defer reports.context.LogClose()
+
+	reports.context.LogPrintln("Start Import")
+
+	reference := time.Now().UTC()
+	var text strings.Builder
+	lineNumber := 0
+	reportNumber := 0
+
+	flush := func() {
+		if text.Len() == 0 {
+			return
+		}
+		report, err := ParseReport(text.String(), reference)
+		if err == nil {
+			err = reports.Store(report)
+		}
+		if err != nil {
+			reports.context.LogError(fmt.Errorf("Weather[%d]: %v", reportNumber, err))
+		}
+		text.Reset()
+	}
+
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := scanner.Text()
+		lineNumber++
+
+		switch {
+		case len(strings.TrimSpace(line)) == 0:
+			flush()
+		case datePattern.MatchString(strings.TrimSpace(line)):
+			flush()
+			date, err := time.Parse("2006/01/02 15:04", strings.TrimSpace(line))
+			if err == nil {
+				reference = date
+			}
+		case line[0] == ' ' || line[0] == '\t':
+			if text.Len() == 0 {
+				reportNumber = lineNumber
+			}
+			text.WriteString(" ")
+			text.WriteString(strings.TrimSpace(line))
+		default:
+			flush()
+			reportNumber = lineNumber
+			text.WriteString(strings.TrimSpace(line))
+		}
+
+		if strings.HasSuffix(strings.TrimSpace(line), "=") {
+			flush()
+		}
+	}
+	flush()
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	purged, err := reports.Purge()
+	reports.context.LogError(err)
+	reports.context.LogPrintln(fmt.Sprintf("Purged %d reports", purged))
+
+	reports.context.LogPrintln("End Import")
+
+	return nil
+}
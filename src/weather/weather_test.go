@@ -0,0 +1,138 @@
+package weather
+
+import (
+	"testing"
+	"time"
+)
+
+var reference = time.Date(2020, time.March, 14, 13, 0, 0, 0, time.UTC)
+
+func TestParseMetar(t *testing.T) {
+	var tests = []struct {
+		value       string
+		correct     bool
+		airportCode string
+		observed    time.Time
+		direction   int
+		speed       int
+		gust        int
+		visibility  int
+		ceiling     int
+		temperature int
+		qnh         int
+		category    string
+	}{
+		{"METAR EHAM 141225Z 24015G25KT 9999 FEW020 BKN035 12/08 Q1013 NOSIG=", true,
+			"EHAM", time.Date(2020, time.March, 14, 12, 25, 0, 0, time.UTC), 240, 15, 25, 10000, 3500, 12, 1013, VFR},
+		{"KJFK 141251Z 31008KT 1 1/2SM BR OVC007 M02/M03 A2992 RMK AO2", true,
+			"KJFK", time.Date(2020, time.March, 14, 12, 51, 0, 0, time.UTC), 310, 8, 0, 2414, 700, -2, 1013, IFR},
+		{"SPECI EGLL 282350Z 05005MPS CAVOK 05/01 Q1025", true, // day 28 is in the previous month
+			"EGLL", time.Date(2020, time.February, 28, 23, 50, 0, 0, time.UTC), 50, 10, 0, 10000, -1, 5, 1025, VFR},
+		{"LFPG 141300Z AUTO VRB02KT 0300 FG VV001 08/08 Q1019", true,
+			"LFPG", time.Date(2020, time.March, 14, 13, 0, 0, 0, time.UTC), 0, 2, 0, 300, 100, 8, 1019, LIFR},
+		{"EHAM 141225Z NIL=", false, "", time.Time{}, 0, 0, 0, 0, 0, 0, 0, ""},
+		{"METAR 141225Z 24015KT", false, "", time.Time{}, 0, 0, 0, 0, 0, 0, 0, ""},
+		{"METAR EHAM 1412Z 24015KT", false, "", time.Time{}, 0, 0, 0, 0, 0, 0, 0, ""},
+	}
+
+	for _, test := range tests {
+		metar, err := ParseMetar(test.value, reference)
+		if (test.correct && err != nil) || (!test.correct && err == nil) {
+			t.Errorf("ParseMetar(%s) expected %t, got %t (%v)", test.value, test.correct, (err == nil), err)
+			continue
+		}
+		if !test.correct {
+			continue
+		}
+		if metar.AirportCode != test.airportCode || !metar.Observed.Equal(test.observed) {
+			t.Errorf("ParseMetar(%s) expected %s at %v, got %s at %v", test.value,
+				test.airportCode, test.observed, metar.AirportCode, metar.Observed)
+		}
+		if metar.Wind == nil || metar.Wind.Direction != test.direction || metar.Wind.Speed != test.speed || metar.Wind.Gust != test.gust {
+			t.Errorf("ParseMetar(%s) expected wind %d/%dG%d, got %+v", test.value, test.direction, test.speed, test.gust, metar.Wind)
+		}
+		if metar.Visibility == nil || *metar.Visibility != test.visibility {
+			t.Errorf("ParseMetar(%s) expected visibility %d, got %v", test.value, test.visibility, metar.Visibility)
+		}
+		if (test.ceiling < 0 && metar.Ceiling != nil) || (test.ceiling >= 0 && (metar.Ceiling == nil || *metar.Ceiling != test.ceiling)) {
+			t.Errorf("ParseMetar(%s) expected ceiling %d, got %v", test.value, test.ceiling, metar.Ceiling)
+		}
+		if metar.Temperature == nil || *metar.Temperature != test.temperature {
+			t.Errorf("ParseMetar(%s) expected temperature %d, got %v", test.value, test.temperature, metar.Temperature)
+		}
+		if metar.QNH == nil || *metar.QNH != test.qnh {
+			t.Errorf("ParseMetar(%s) expected QNH %d, got %v", test.value, test.qnh, metar.QNH)
+		}
+		if metar.FlightCategory != test.category {
+			t.Errorf("ParseMetar(%s) expected %s, got %s", test.value, test.category, metar.FlightCategory)
+		}
+	}
+}
+
+func TestParseTaf(t *testing.T) {
+	text := "TAF EHAM 141100Z 1412/1518 24015KT 9999 SCT030 " +
+		"BECMG 1415/1417 27010KT " +
+		"TEMPO 1500/1506 4000 RA BKN012 " +
+		"PROB30 TEMPO 1506/1509 TSRA BKN008CB " +
+		"FM151200 30008KT CAVOK="
+
+	taf, err := ParseTaf(text, reference)
+	if err != nil {
+		t.Fatalf("ParseTaf expected success, got %v", err)
+	}
+
+	if taf.AirportCode != "EHAM" {
+		t.Errorf("ParseTaf expected EHAM, got %s", taf.AirportCode)
+	}
+	if !taf.ValidFrom.Equal(time.Date(2020, time.March, 14, 12, 0, 0, 0, time.UTC)) ||
+		!taf.ValidUntil.Equal(time.Date(2020, time.March, 15, 18, 0, 0, 0, time.UTC)) {
+		t.Errorf("ParseTaf expected validity 14 12:00 - 15 18:00, got %v - %v", taf.ValidFrom, taf.ValidUntil)
+	}
+
+	var tests = []struct {
+		change   string
+		until    time.Time
+		category string
+	}{
+		{"", time.Date(2020, time.March, 15, 12, 0, 0, 0, time.UTC), VFR},
+		{"BECMG", time.Date(2020, time.March, 14, 17, 0, 0, 0, time.UTC), ""},
+		{"TEMPO", time.Date(2020, time.March, 15, 6, 0, 0, 0, time.UTC), IFR},
+		{"PROB30 TEMPO", time.Date(2020, time.March, 15, 9, 0, 0, 0, time.UTC), IFR},
+		{"FM", time.Date(2020, time.March, 15, 18, 0, 0, 0, time.UTC), VFR},
+	}
+
+	if len(taf.Periods) != len(tests) {
+		t.Fatalf("ParseTaf expected %d periods, got %d", len(tests), len(taf.Periods))
+	}
+	for i, test := range tests {
+		period := taf.Periods[i]
+		if period.Change != test.change || !period.Until.Equal(test.until) || period.FlightCategory != test.category {
+			t.Errorf("ParseTaf period %d expected %s until %v (%s), got %s until %v (%s)", i,
+				test.change, test.until, test.category, period.Change, period.Until, period.FlightCategory)
+		}
+	}
+}
+
+func TestParseReport(t *testing.T) {
+	var tests = []struct {
+		value      string
+		reportType string
+	}{
+		{"METAR EHAM 141225Z 24015KT 9999 FEW020 12/08 Q1013", METAR},
+		{"EHAM 141225Z 24015KT 9999 FEW020 12/08 Q1013", METAR},
+		{"TAF EHAM 141100Z 1412/1518 24015KT 9999 SCT030", TAF},
+		{"EHAM 141100Z 1412/1518 24015KT 9999 SCT030", TAF},
+		{"TAF AMD EHAM 141100Z 1412/1518 24015KT 9999 SCT030", TAF},
+	}
+
+	for _, test := range tests {
+		report, err := ParseReport(test.value, reference)
+		if err != nil {
+			t.Errorf("ParseReport(%s) expected success, got %v", test.value, err)
+			continue
+		}
+		if report.ReportType != test.reportType {
+			t.Errorf("ParseReport(%s) expected %s, got %s", test.value, test.reportType, report.ReportType)
+		}
+	}
+}